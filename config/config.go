@@ -2,7 +2,11 @@ package config
 
 import (
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
@@ -16,6 +20,68 @@ var DB *gorm.DB
 var JWTSecret string
 var WebhookURL string
 
+// WebhookSecret signs webhook deliveries to WebhookURL (and X-Webhook-URL overrides)
+var WebhookSecret string
+
+// Goods receipt tolerances, in percent of the ordered quantity.
+// ReceiptOverTolerance is how much more than ordered may be received in total;
+// ReceiptUnderTolerance is how much less than ordered still counts as fully received.
+var ReceiptOverTolerance float64
+var ReceiptUnderTolerance float64
+
+// Supplier invoice matching tolerances, in percent.
+// InvoicePriceTolerance is how far an invoiced unit price may exceed the purchasing price before the
+// line is a variance; InvoiceQtyTolerance is how much more than received may be invoiced before it is blocked.
+var InvoicePriceTolerance float64
+var InvoiceQtyTolerance float64
+
+// Webhook delivery settings used by the outbox dispatcher.
+// A failed delivery is retried after WebhookRetryBase * 2^(attempt-1), capped at WebhookRetryMax
+// and jittered; after WebhookMaxAttempts failures the event is dead-lettered.
+var WebhookMaxAttempts int
+var WebhookRetryBase time.Duration
+var WebhookRetryMax time.Duration
+var WebhookDispatchInterval time.Duration
+
+// Webhook target restrictions, checked when a target URL is accepted and again on every connection.
+// WebhookAllowHeaderOverride enables the per-request X-Webhook-URL header. WebhookAllowedSchemes lists
+// the URL schemes receivers may use. When WebhookAllowedHostnames or WebhookAllowedCIDRs are set, only
+// matching targets are accepted; private, loopback and link-local addresses are always refused unless
+// they fall inside WebhookAllowedCIDRs.
+var WebhookAllowHeaderOverride bool
+var WebhookAllowedSchemes []string
+var WebhookAllowedHostnames []string
+var WebhookAllowedCIDRs []*net.IPNet
+
+// StockReconcileInterval is how often the stock ledger is checked against item stock
+var StockReconcileInterval time.Duration
+
+// LowStockThreshold is the stock level below which a stock.low webhook event is published
+var LowStockThreshold int
+
+// ReorderAutoDraft turns the scheduled reorder planner on; suggestions and manual runs work either way
+var ReorderAutoDraft bool
+
+// ReorderInterval is how often the reorder planner drafts purchasings for items at their reorder point
+var ReorderInterval time.Duration
+
+// ReorderUserID is the user recorded as creator of the planner's drafts; 0 means the oldest admin
+var ReorderUserID uint
+
+// ScorecardWindowDays is how many days back supplier scorecards look when no window is requested
+var ScorecardWindowDays int
+
+// ScorecardDefaultLeadDays is the lead time given to purchasings ordered without an expected
+// delivery date, and assumed by supplier scorecards for older orders that have none: they are
+// due that many days after their date
+var ScorecardDefaultLeadDays int
+
+// ContractAlertInterval is how often contracts entering their expiry alert period are looked for
+var ContractAlertInterval time.Duration
+
+// BaseCurrency is the currency purchasing totals are converted to for approvals and reports
+var BaseCurrency string
+
 // LoadEnv loads environment variables from .env file
 func LoadEnv() {
 	// Try loading from .env first (standard), then try "env" as fallback
@@ -35,6 +101,123 @@ func LoadEnv() {
 	if WebhookURL != "" {
 		log.Printf("Webhook URL loaded from environment: %s", WebhookURL)
 	}
+
+	WebhookSecret = os.Getenv("WEBHOOK_SECRET")
+	if WebhookURL != "" && WebhookSecret == "" {
+		log.Println("Warning: WEBHOOK_SECRET not set, webhook deliveries will not be signed")
+	}
+
+	ReceiptOverTolerance = getEnvFloat("RECEIPT_OVER_TOLERANCE_PERCENT", 0)
+	ReceiptUnderTolerance = getEnvFloat("RECEIPT_UNDER_TOLERANCE_PERCENT", 0)
+
+	InvoicePriceTolerance = getEnvFloat("INVOICE_PRICE_TOLERANCE_PERCENT", 0)
+	InvoiceQtyTolerance = getEnvFloat("INVOICE_QTY_TOLERANCE_PERCENT", 0)
+
+	WebhookMaxAttempts = getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8)
+	WebhookRetryBase = time.Duration(getEnvInt("WEBHOOK_RETRY_BASE_SECONDS", 30)) * time.Second
+	WebhookRetryMax = time.Duration(getEnvInt("WEBHOOK_RETRY_MAX_SECONDS", 3600)) * time.Second
+	WebhookDispatchInterval = time.Duration(getEnvInt("WEBHOOK_DISPATCH_INTERVAL_SECONDS", 5)) * time.Second
+
+	WebhookAllowHeaderOverride = getEnvBool("WEBHOOK_ALLOW_HEADER_OVERRIDE", true)
+	WebhookAllowedSchemes = getEnvList("WEBHOOK_ALLOWED_SCHEMES", "http,https")
+	WebhookAllowedHostnames, WebhookAllowedCIDRs = parseWebhookAllowlist(getEnvList("WEBHOOK_ALLOWED_HOSTS", ""))
+
+	LowStockThreshold = getEnvInt("LOW_STOCK_THRESHOLD", 10)
+	StockReconcileInterval = time.Duration(getEnvInt("STOCK_RECONCILE_INTERVAL_MINUTES", 60)) * time.Minute
+
+	ReorderAutoDraft = getEnvBool("REORDER_AUTO_DRAFT", true)
+	ReorderInterval = time.Duration(getEnvInt("REORDER_INTERVAL_MINUTES", 60)) * time.Minute
+	ReorderUserID = uint(getEnvInt("REORDER_USER_ID", 0))
+
+	ScorecardWindowDays = getEnvInt("SCORECARD_WINDOW_DAYS", 90)
+	ScorecardDefaultLeadDays = getEnvInt("SCORECARD_DEFAULT_LEAD_DAYS", 14)
+	ContractAlertInterval = time.Duration(getEnvInt("CONTRACT_ALERT_INTERVAL_MINUTES", 1440)) * time.Minute
+
+	BaseCurrency = strings.ToUpper(strings.TrimSpace(os.Getenv("BASE_CURRENCY")))
+	if BaseCurrency == "" {
+		BaseCurrency = "IDR"
+	}
+}
+
+// getEnvInt reads a positive integer environment variable, falling back to def when unset or invalid
+func getEnvInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		log.Printf("Warning: invalid %s value %q, using %d", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvFloat reads a float environment variable, falling back to def when unset or invalid
+func getEnvFloat(key string, def float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		log.Printf("Warning: invalid %s value %q, using %v", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvBool reads a boolean environment variable, falling back to def when unset or invalid
+func getEnvBool(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s value %q, using %v", key, value, def)
+		return def
+	}
+	return parsed
+}
+
+// getEnvList reads a comma-separated environment variable as a lowercased list without empty entries
+func getEnvList(key, def string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		value = def
+	}
+
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// parseWebhookAllowlist splits allowlist entries into hostnames (optionally "*.example.com")
+// and networks; single IP addresses become one-address networks
+func parseWebhookAllowlist(entries []string) ([]string, []*net.IPNet) {
+	var hostnames []string
+	var cidrs []*net.IPNet
+	for _, entry := range entries {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			cidrs = append(cidrs, network)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			cidrs = append(cidrs, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		hostnames = append(hostnames, entry)
+	}
+	return hostnames, cidrs
 }
 
 // InitDB initializes the database connection using GORM
@@ -45,7 +228,9 @@ func InitDB() error {
 	}
 
 	var err error
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// TranslateError turns unique key violations into gorm.ErrDuplicatedKey, so a race past a
+	// uniqueness check can be told apart from a failure
+	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return err
 	}
@@ -53,5 +238,3 @@ func InitDB() error {
 	log.Println("Database connected successfully")
 	return nil
 }
-
-
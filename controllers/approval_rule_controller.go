@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"strconv"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// ApprovalRuleController handles approval rule HTTP requests
+type ApprovalRuleController struct {
+	ruleRepo *repository.ApprovalRuleRepository
+}
+
+// NewApprovalRuleController creates a new ApprovalRuleController instance
+func NewApprovalRuleController() *ApprovalRuleController {
+	return &ApprovalRuleController{
+		ruleRepo: repository.NewApprovalRuleRepository(),
+	}
+}
+
+// ApprovalRuleRequest represents the request body for creating or updating an approval rule
+type ApprovalRuleRequest struct {
+	MinAmount         decimal.Decimal `json:"minAmount" validate:"required,min=0"`
+	ApproverRole      string          `json:"approverRole" validate:"required,oneof=admin staff"`
+	RequiredApprovals int             `json:"requiredApprovals" validate:"required,min=1"`
+}
+
+// validate checks the fields the struct tags describe
+func (req *ApprovalRuleRequest) validate() string {
+	if req.MinAmount.IsNegative() {
+		return "minAmount must not be negative"
+	}
+	if req.ApproverRole != models.RoleAdmin && req.ApproverRole != models.RoleStaff {
+		return "approverRole must be admin or staff"
+	}
+	if req.RequiredApprovals < 1 {
+		return "requiredApprovals must be at least 1"
+	}
+	return ""
+}
+
+// GetAll retrieves all approval rules
+func (ac *ApprovalRuleController) GetAll(c *fiber.Ctx) error {
+	rules, err := ac.ruleRepo.GetAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve approval rules",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Approval rules retrieved successfully",
+		"data":    rules,
+	})
+}
+
+// Create creates a new approval rule
+func (ac *ApprovalRuleController) Create(c *fiber.Ctx) error {
+	var req ApprovalRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	rule := models.ApprovalRule{
+		MinAmount:         req.MinAmount,
+		ApproverRole:      req.ApproverRole,
+		RequiredApprovals: req.RequiredApprovals,
+	}
+
+	if err := ac.ruleRepo.Create(&rule); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create approval rule",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Approval rule created successfully",
+		"data":    rule,
+	})
+}
+
+// Update updates an existing approval rule
+// Purchasings already submitted keep the requirements they were submitted with
+func (ac *ApprovalRuleController) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid approval rule ID",
+		})
+	}
+
+	rule, err := ac.ruleRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Approval rule not found",
+		})
+	}
+
+	var req ApprovalRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	rule.MinAmount = req.MinAmount
+	rule.ApproverRole = req.ApproverRole
+	rule.RequiredApprovals = req.RequiredApprovals
+
+	if err := ac.ruleRepo.Update(rule); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update approval rule",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Approval rule updated successfully",
+		"data":    rule,
+	})
+}
+
+// Delete deletes an approval rule by ID
+func (ac *ApprovalRuleController) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid approval rule ID",
+		})
+	}
+
+	if _, err := ac.ruleRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Approval rule not found",
+		})
+	}
+
+	if err := ac.ruleRepo.Delete(uint(id)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete approval rule",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Approval rule deleted successfully",
+	})
+}
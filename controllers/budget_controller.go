@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+	"procurement-system/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// BudgetController handles department budget HTTP requests
+type BudgetController struct {
+	budgetRepo     *repository.BudgetRepository
+	departmentRepo *repository.DepartmentRepository
+}
+
+// NewBudgetController creates a new BudgetController instance
+func NewBudgetController() *BudgetController {
+	return &BudgetController{
+		budgetRepo:     repository.NewBudgetRepository(),
+		departmentRepo: repository.NewDepartmentRepository(),
+	}
+}
+
+// CreateBudgetRequest represents the request body for creating a budget
+// Dates are YYYY-MM-DD and periodEnd is inclusive; amount is in the base currency.
+// control is hard (purchasings beyond the budget are refused, the default) or soft (they are
+// allowed and flagged).
+type CreateBudgetRequest struct {
+	DepartmentID uint            `json:"departmentId" validate:"required"`
+	PeriodStart  string          `json:"periodStart" validate:"required"`
+	PeriodEnd    string          `json:"periodEnd" validate:"required"`
+	Amount       decimal.Decimal `json:"amount" validate:"required"`
+	Control      string          `json:"control" validate:"omitempty,oneof=hard soft"`
+	Note         string          `json:"note"`
+}
+
+// validate checks the fields the struct tags describe; control defaults to hard
+func (req *CreateBudgetRequest) validate() string {
+	if req.DepartmentID == 0 {
+		return "departmentId is required"
+	}
+	if req.Amount.IsNegative() {
+		return "amount cannot be negative"
+	}
+	req.Control = strings.ToLower(strings.TrimSpace(req.Control))
+	if req.Control == "" {
+		req.Control = models.BudgetControlHard
+	}
+	if !models.IsValidBudgetControl(req.Control) {
+		return "control must be hard or soft"
+	}
+	return ""
+}
+
+// UpdateBudgetRequest represents the request body for updating a budget
+// The department and period of a budget cannot be changed.
+type UpdateBudgetRequest struct {
+	Amount  decimal.Decimal `json:"amount" validate:"required"`
+	Control string          `json:"control" validate:"required,oneof=hard soft"`
+	Note    string          `json:"note"`
+}
+
+// GetAll retrieves budgets, latest period first
+// Supported filters: departmentId, activeOn (YYYY-MM-DD, budgets whose period contains the day)
+func (bc *BudgetController) GetAll(c *fiber.Ctx) error {
+	var filter repository.BudgetFilter
+
+	if v := c.Query("departmentId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid department ID",
+			})
+		}
+		filter.DepartmentID = uint(id)
+	}
+
+	if v := c.Query("activeOn"); v != "" {
+		day, err := utils.ParseDate(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid activeOn, expected YYYY-MM-DD",
+			})
+		}
+		filter.ActiveOn = &day
+	}
+
+	budgets, err := bc.budgetRepo.GetAll(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve budgets",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Budgets retrieved successfully",
+		"data":    budgets,
+	})
+}
+
+// GetByID retrieves a budget with what is encumbered, spent and remaining
+func (bc *BudgetController) GetByID(c *fiber.Ctx) error {
+	budget, err := bc.findBudget(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message":   "Budget retrieved successfully",
+		"data":      budget,
+		"remaining": budget.Remaining(),
+	})
+}
+
+// Create creates a budget for a department and period
+// A department has at most one budget for any day.
+func (bc *BudgetController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateBudgetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	periodStart, err := utils.ParseDate(req.PeriodStart)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid periodStart, expected YYYY-MM-DD",
+		})
+	}
+	periodEnd, err := utils.ParseDate(req.PeriodEnd)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid periodEnd, expected YYYY-MM-DD",
+		})
+	}
+	if periodEnd.Before(periodStart) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "periodEnd cannot be before periodStart",
+		})
+	}
+
+	if _, err := bc.departmentRepo.FindByID(req.DepartmentID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Department not found",
+		})
+	}
+
+	now := time.Now()
+	budget := models.Budget{
+		DepartmentID: req.DepartmentID,
+		PeriodStart:  periodStart,
+		PeriodEnd:    periodEnd,
+		Amount:       req.Amount,
+		Control:      req.Control,
+		Note:         req.Note,
+		CreatedBy:    userID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := bc.budgetRepo.Create(&budget); err != nil {
+		if errors.Is(err, repository.ErrBudgetPeriodOverlap) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot create budget: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create budget",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Budget created successfully",
+		"data":    budget,
+	})
+}
+
+// Update changes a budget's amount, control and note
+// The amount cannot drop below what is already encumbered and spent.
+func (bc *BudgetController) Update(c *fiber.Ctx) error {
+	existing, err := bc.findBudget(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateBudgetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Amount.IsNegative() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "amount cannot be negative",
+		})
+	}
+	if !models.IsValidBudgetControl(req.Control) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "control must be hard or soft",
+		})
+	}
+
+	budget, err := bc.budgetRepo.UpdateTransaction(existing.ID, req.Amount, req.Control, req.Note)
+	if err != nil {
+		if errors.Is(err, repository.ErrBudgetBelowUsed) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Cannot update budget: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update budget",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Budget updated successfully",
+		"data":    budget,
+	})
+}
+
+// Delete deletes a budget no purchasing is charged to
+func (bc *BudgetController) Delete(c *fiber.Ctx) error {
+	budget, err := bc.findBudget(c)
+	if err != nil {
+		return err
+	}
+
+	if err := bc.budgetRepo.Delete(budget.ID); err != nil {
+		if errors.Is(err, repository.ErrBudgetInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot delete budget: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete budget",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Budget deleted successfully",
+	})
+}
+
+// findBudget loads the budget in the route, returning a 400 or 404 fiber error
+func (bc *BudgetController) findBudget(c *fiber.Ctx) (*models.Budget, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid budget ID")
+	}
+
+	budget, err := bc.budgetRepo.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Budget not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve budget")
+	}
+	return budget, nil
+}
@@ -0,0 +1,450 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+	"procurement-system/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ContractController handles supplier contract HTTP requests
+type ContractController struct {
+	contractRepo *repository.ContractRepository
+	supplierRepo *repository.SupplierRepository
+	itemRepo     *repository.ItemRepository
+}
+
+// NewContractController creates a new ContractController instance
+func NewContractController() *ContractController {
+	return &ContractController{
+		contractRepo: repository.NewContractRepository(),
+		supplierRepo: repository.NewSupplierRepository(),
+		itemRepo:     repository.NewItemRepository(),
+	}
+}
+
+// ContractItemRequest is a price agreed for an item, per base unit in the supplier's currency
+type ContractItemRequest struct {
+	ItemID    uint            `json:"itemId"`
+	UnitPrice decimal.Decimal `json:"unitPrice"`
+}
+
+// CreateContractRequest represents the request body for creating a contract
+// Dates are YYYY-MM-DD and validTo is inclusive. enforcement is warn (deviating purchasing lines
+// are allowed and flagged, the default) or block (they are refused). alertDays is how many days
+// before validTo the expiry alert goes out, 30 by default. committedSpend is in the base currency.
+type CreateContractRequest struct {
+	SupplierID     uint                  `json:"supplierId"`
+	Reference      string                `json:"reference"`
+	Title          string                `json:"title"`
+	ValidFrom      string                `json:"validFrom"`
+	ValidTo        string                `json:"validTo"`
+	CommittedSpend decimal.Decimal       `json:"committedSpend"`
+	Enforcement    string                `json:"enforcement"`
+	Terms          string                `json:"terms"`
+	AlertDays      *int                  `json:"alertDays"`
+	Items          []ContractItemRequest `json:"items"`
+}
+
+// validate checks the request; enforcement defaults to warn
+func (req *CreateContractRequest) validate() string {
+	if req.SupplierID == 0 {
+		return "supplierId is required"
+	}
+	req.Reference = strings.TrimSpace(req.Reference)
+	if req.Reference == "" || len(req.Reference) > 50 {
+		return "reference is required and at most 50 characters"
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" || len(req.Title) > 150 {
+		return "title is required and at most 150 characters"
+	}
+	req.Enforcement = strings.ToLower(strings.TrimSpace(req.Enforcement))
+	if req.Enforcement == "" {
+		req.Enforcement = models.ContractEnforcementWarn
+	}
+	return validateContractTerms(req.CommittedSpend, req.Enforcement, req.AlertDays, req.Items)
+}
+
+// UpdateContractRequest represents the request body for updating a contract
+// The supplier, reference and start of a contract cannot be changed; items replaces the agreed prices.
+type UpdateContractRequest struct {
+	Title          string                `json:"title"`
+	ValidTo        string                `json:"validTo"`
+	CommittedSpend decimal.Decimal       `json:"committedSpend"`
+	Enforcement    string                `json:"enforcement"`
+	Terms          string                `json:"terms"`
+	AlertDays      *int                  `json:"alertDays"`
+	Items          []ContractItemRequest `json:"items"`
+}
+
+// validate checks the request
+func (req *UpdateContractRequest) validate() string {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" || len(req.Title) > 150 {
+		return "title is required and at most 150 characters"
+	}
+	req.Enforcement = strings.ToLower(strings.TrimSpace(req.Enforcement))
+	return validateContractTerms(req.CommittedSpend, req.Enforcement, req.AlertDays, req.Items)
+}
+
+// GetAll retrieves contracts, latest first
+// Supported filters: supplierId, itemId (contracts agreeing a price for the item),
+// activeOn (YYYY-MM-DD, contracts in force on the day)
+func (cc *ContractController) GetAll(c *fiber.Ctx) error {
+	var filter repository.ContractFilter
+
+	if v := c.Query("supplierId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid supplier ID",
+			})
+		}
+		filter.SupplierID = uint(id)
+	}
+
+	if v := c.Query("itemId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid item ID",
+			})
+		}
+		filter.ItemID = uint(id)
+	}
+
+	if v := c.Query("activeOn"); v != "" {
+		day, err := utils.ParseDate(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid activeOn, expected YYYY-MM-DD",
+			})
+		}
+		filter.ActiveOn = &day
+	}
+
+	contracts, err := cc.contractRepo.GetAll(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve contracts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Contracts retrieved successfully",
+		"data":    contracts,
+	})
+}
+
+// GetExpiring retrieves the contracts in force that are within their expiry alert period, soonest first
+// Query parameter: asOf (YYYY-MM-DD, default today)
+func (cc *ContractController) GetExpiring(c *fiber.Ctx) error {
+	asOf, err := parseDateOrToday(c.Query("asOf"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid asOf, use YYYY-MM-DD",
+		})
+	}
+
+	expiring, err := cc.contractRepo.Expiring(asOf)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve expiring contracts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Expiring contracts retrieved successfully",
+		"asOf":    asOf.Format("2006-01-02"),
+		"data":    expiring,
+	})
+}
+
+// GetByID retrieves a contract with what was spent with the supplier during it and what remains
+// of the committed spend
+func (cc *ContractController) GetByID(c *fiber.Ctx) error {
+	contract, err := cc.findContract(c)
+	if err != nil {
+		return err
+	}
+
+	spent, err := cc.contractRepo.Spend(contract)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve contract spend",
+		})
+	}
+
+	remaining := contract.CommittedSpend.Sub(spent)
+	if remaining.IsNegative() {
+		remaining = decimal.Zero
+	}
+
+	return c.JSON(fiber.Map{
+		"message":             "Contract retrieved successfully",
+		"data":                contract,
+		"spent":               spent,
+		"remainingCommitment": remaining,
+	})
+}
+
+// Create creates a contract with a supplier and its agreed prices
+// A supplier has at most one contract in force on any day.
+func (cc *ContractController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateContractRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	validFrom, err := utils.ParseDate(req.ValidFrom)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid validFrom, expected YYYY-MM-DD",
+		})
+	}
+	validTo, err := parseContractValidTo(req.ValidTo, validFrom)
+	if err != nil {
+		return err
+	}
+
+	if _, err := cc.supplierRepo.FindByID(req.SupplierID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+	items, err := cc.contractItems(req.Items)
+	if err != nil {
+		return err
+	}
+
+	alertDays := 30
+	if req.AlertDays != nil {
+		alertDays = *req.AlertDays
+	}
+
+	now := time.Now()
+	contract := models.Contract{
+		SupplierID:     req.SupplierID,
+		Reference:      req.Reference,
+		Title:          req.Title,
+		ValidFrom:      validFrom,
+		ValidTo:        validTo,
+		CommittedSpend: req.CommittedSpend,
+		Enforcement:    req.Enforcement,
+		Terms:          req.Terms,
+		AlertDays:      alertDays,
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := cc.contractRepo.Create(&contract, items); err != nil {
+		if errors.Is(err, repository.ErrContractPeriodOverlap) || errors.Is(err, repository.ErrContractReferenceTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot create contract: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create contract",
+		})
+	}
+
+	created, err := cc.contractRepo.FindByID(contract.ID)
+	if err != nil {
+		created = &contract
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Contract created successfully",
+		"data":    created,
+	})
+}
+
+// Update changes a contract's title, end, committed spend, enforcement, terms, alert period and
+// agreed prices
+// Moving the end re-arms the expiry alert. Purchasings already created keep the check they had.
+func (cc *ContractController) Update(c *fiber.Ctx) error {
+	existing, err := cc.findContract(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateContractRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	validTo, err := parseContractValidTo(req.ValidTo, existing.ValidFrom)
+	if err != nil {
+		return err
+	}
+	items, err := cc.contractItems(req.Items)
+	if err != nil {
+		return err
+	}
+
+	alertDays := existing.AlertDays
+	if req.AlertDays != nil {
+		alertDays = *req.AlertDays
+	}
+
+	_, err = cc.contractRepo.UpdateTransaction(&models.Contract{
+		ID:             existing.ID,
+		SupplierID:     existing.SupplierID,
+		Title:          req.Title,
+		ValidTo:        validTo,
+		CommittedSpend: req.CommittedSpend,
+		Enforcement:    req.Enforcement,
+		Terms:          req.Terms,
+		AlertDays:      alertDays,
+	}, items)
+	if err != nil {
+		if errors.Is(err, repository.ErrContractPeriodOverlap) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot update contract: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update contract",
+		})
+	}
+
+	contract, err := cc.contractRepo.FindByID(existing.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve contract",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Contract updated successfully",
+		"data":    contract,
+	})
+}
+
+// Delete deletes a contract no purchasing line was checked against
+func (cc *ContractController) Delete(c *fiber.Ctx) error {
+	contract, err := cc.findContract(c)
+	if err != nil {
+		return err
+	}
+
+	if err := cc.contractRepo.Delete(contract.ID); err != nil {
+		if errors.Is(err, repository.ErrContractInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot delete contract: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete contract",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Contract deleted successfully",
+	})
+}
+
+// findContract loads the contract in the route, returning a 400 or 404 fiber error
+func (cc *ContractController) findContract(c *fiber.Ctx) (*models.Contract, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid contract ID")
+	}
+
+	contract, err := cc.contractRepo.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Contract not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve contract")
+	}
+	return contract, nil
+}
+
+// contractItems turns the requested agreed prices into contract items, returning a 404 fiber
+// error for an unknown item
+func (cc *ContractController) contractItems(reqItems []ContractItemRequest) ([]models.ContractItem, error) {
+	items := make([]models.ContractItem, 0, len(reqItems))
+	for _, reqItem := range reqItems {
+		if _, err := cc.itemRepo.FindByID(reqItem.ItemID); err != nil {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Item "+strconv.FormatUint(uint64(reqItem.ItemID), 10)+" not found")
+		}
+		items = append(items, models.ContractItem{
+			ItemID:    reqItem.ItemID,
+			UnitPrice: reqItem.UnitPrice,
+		})
+	}
+	return items, nil
+}
+
+// validateContractTerms checks the fields shared by contract create and update requests
+func validateContractTerms(committedSpend decimal.Decimal, enforcement string, alertDays *int, items []ContractItemRequest) string {
+	if committedSpend.IsNegative() {
+		return "committedSpend cannot be negative"
+	}
+	if !models.IsValidContractEnforcement(enforcement) {
+		return "enforcement must be warn or block"
+	}
+	if alertDays != nil && *alertDays < 0 {
+		return "alertDays cannot be negative"
+	}
+	seen := make(map[uint]bool, len(items))
+	for _, item := range items {
+		if item.ItemID == 0 {
+			return "itemId is required for every item"
+		}
+		if seen[item.ItemID] {
+			return "each item can be priced only once per contract"
+		}
+		seen[item.ItemID] = true
+		if !item.UnitPrice.IsPositive() {
+			return "unitPrice must be greater than zero"
+		}
+	}
+	return ""
+}
+
+// parseContractValidTo parses a contract's inclusive end, which cannot be before its start
+func parseContractValidTo(value string, validFrom time.Time) (time.Time, error) {
+	validTo, err := utils.ParseDate(value)
+	if err != nil {
+		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "Invalid validTo, expected YYYY-MM-DD")
+	}
+	if validTo.Before(validFrom) {
+		return time.Time{}, fiber.NewError(fiber.StatusBadRequest, "validTo cannot be before validFrom")
+	}
+	return validTo, nil
+}
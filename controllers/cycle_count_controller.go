@@ -0,0 +1,285 @@
+package controllers
+
+import (
+	"strconv"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CycleCountController handles cycle count HTTP requests
+type CycleCountController struct {
+	countRepo     *repository.CycleCountRepository
+	itemRepo      *repository.ItemRepository
+	warehouseRepo *repository.WarehouseRepository
+}
+
+// NewCycleCountController creates a new CycleCountController instance
+func NewCycleCountController() *CycleCountController {
+	return &CycleCountController{
+		countRepo:     repository.NewCycleCountRepository(),
+		itemRepo:      repository.NewItemRepository(),
+		warehouseRepo: repository.NewWarehouseRepository(),
+	}
+}
+
+// CreateCycleCountRequest represents the request body for opening a cycle count
+// Items are counted in WarehouseID, or the default warehouse when it is omitted.
+type CreateCycleCountRequest struct {
+	WarehouseID *uint  `json:"warehouseId"`
+	ItemIDs     []uint `json:"itemIds" validate:"required,min=1"`
+	Note        string `json:"note"`
+}
+
+// RecordCountsRequest represents the counted quantities entered on an open cycle count
+type RecordCountsRequest struct {
+	Counts []CycleCountInput `json:"counts" validate:"required,min=1"`
+}
+
+// CycleCountInput is the counted quantity of one item
+type CycleCountInput struct {
+	ItemID     uint `json:"itemId" validate:"required"`
+	CountedQty int  `json:"countedQty" validate:"min=0"`
+}
+
+// GetAll retrieves a page of cycle counts, newest first
+// Supported filters: status, warehouseId
+func (cc *CycleCountController) GetAll(c *fiber.Ctx) error {
+	var warehouseID uint
+	if v := c.Query("warehouseId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid warehouse ID",
+			})
+		}
+		warehouseID = uint(id)
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	counts, pageInfo, err := cc.countRepo.List(c.Query("status"), warehouseID, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve cycle counts")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Cycle counts retrieved successfully",
+		"data":       counts,
+		"pagination": pageInfo,
+	})
+}
+
+// GetByID retrieves a cycle count with its lines
+func (cc *CycleCountController) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid cycle count ID",
+		})
+	}
+
+	count, err := cc.countRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Cycle count not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Cycle count retrieved successfully",
+		"data":    count,
+	})
+}
+
+// Create opens a cycle count for the given items in one warehouse
+func (cc *CycleCountController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateCycleCountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.ItemIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one item is required",
+		})
+	}
+
+	seen := make(map[uint]bool, len(req.ItemIDs))
+	itemIDs := make([]uint, 0, len(req.ItemIDs))
+	for _, itemID := range req.ItemIDs {
+		if seen[itemID] {
+			continue
+		}
+		seen[itemID] = true
+
+		if _, err := cc.itemRepo.FindByID(itemID); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":  "Item not found",
+				"itemId": itemID,
+			})
+		}
+		itemIDs = append(itemIDs, itemID)
+	}
+
+	warehouse, err := findWarehouseOrDefault(cc.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return err
+	}
+
+	count := models.CycleCount{
+		WarehouseID: &warehouse.ID,
+		Note:        req.Note,
+		CreatedBy:   userID,
+	}
+
+	if err := cc.countRepo.Create(&count, itemIDs); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create cycle count",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Cycle count opened",
+		"data":    count,
+	})
+}
+
+// RecordCounts stores counted quantities on an open cycle count
+func (cc *CycleCountController) RecordCounts(c *fiber.Ctx) error {
+	id, _, err := parseStockDocumentParams(c, "cycle count")
+	if err != nil {
+		return err
+	}
+
+	var req RecordCountsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.Counts) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one count is required",
+		})
+	}
+
+	counts := make(map[uint]int, len(req.Counts))
+	for _, input := range req.Counts {
+		counts[input.ItemID] = input.CountedQty
+	}
+
+	if err := cc.countRepo.RecordCountsTransaction(id, counts); err != nil {
+		return stockErrorResponse(c, err, "Cycle count not found", "Failed to record counts")
+	}
+
+	count, err := cc.countRepo.FindByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reload cycle count",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Counts recorded",
+		"data":    count,
+	})
+}
+
+// Submit computes variances against the warehouse's current stock and sends them for approval
+func (cc *CycleCountController) Submit(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "cycle count")
+	if err != nil {
+		return err
+	}
+
+	if _, err := cc.countRepo.SubmitTransaction(id, userID); err != nil {
+		return stockErrorResponse(c, err, "Cycle count not found", "Failed to submit cycle count")
+	}
+
+	return cc.respondWithCount(c, id, "Cycle count submitted, variances awaiting approval")
+}
+
+// Approve posts the variances of a submitted count to stock
+func (cc *CycleCountController) Approve(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "cycle count")
+	if err != nil {
+		return err
+	}
+
+	var req ApprovalDecisionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	if _, err := cc.countRepo.ApproveTransaction(id, userID, req.Comment, cc.itemRepo.UpdateStockWithTx); err != nil {
+		return stockErrorResponse(c, err, "Cycle count not found", "Failed to approve cycle count")
+	}
+
+	return cc.respondWithCount(c, id, "Cycle count approved and variances posted to stock")
+}
+
+// Reject rejects the variances of a submitted count and reopens it; a comment is required
+func (cc *CycleCountController) Reject(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "cycle count")
+	if err != nil {
+		return err
+	}
+
+	var req ApprovalDecisionRequest
+	if err := c.BodyParser(&req); err != nil || req.Comment == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A comment explaining the rejection is required",
+		})
+	}
+
+	if _, err := cc.countRepo.RejectTransaction(id, userID, req.Comment); err != nil {
+		return stockErrorResponse(c, err, "Cycle count not found", "Failed to reject cycle count")
+	}
+
+	return cc.respondWithCount(c, id, "Cycle count rejected and reopened for recounting")
+}
+
+// Cancel cancels an open or submitted cycle count without touching stock
+func (cc *CycleCountController) Cancel(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "cycle count")
+	if err != nil {
+		return err
+	}
+
+	if _, err := cc.countRepo.CancelTransaction(id, userID); err != nil {
+		return stockErrorResponse(c, err, "Cycle count not found", "Failed to cancel cycle count")
+	}
+
+	return cc.respondWithCount(c, id, "Cycle count cancelled")
+}
+
+// respondWithCount reloads the count with its lines for the response
+func (cc *CycleCountController) respondWithCount(c *fiber.Ctx, id uint, message string) error {
+	count, err := cc.countRepo.FindByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reload cycle count",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    count,
+	})
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// DepartmentController handles department (cost center) HTTP requests
+type DepartmentController struct {
+	departmentRepo *repository.DepartmentRepository
+}
+
+// NewDepartmentController creates a new DepartmentController instance
+func NewDepartmentController() *DepartmentController {
+	return &DepartmentController{
+		departmentRepo: repository.NewDepartmentRepository(),
+	}
+}
+
+// DepartmentRequest represents the request body for creating or updating a department
+// active defaults to true; purchasings can no longer be charged to inactive departments.
+type DepartmentRequest struct {
+	Code   string `json:"code" validate:"required,max=20"`
+	Name   string `json:"name" validate:"required,max=100"`
+	Active *bool  `json:"active"`
+}
+
+// validate checks the fields the struct tags describe and normalizes the code to upper case
+func (req *DepartmentRequest) validate() string {
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Code == "" || len(req.Code) > 20 {
+		return "code is required and must be at most 20 characters"
+	}
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required and must be at most 100 characters"
+	}
+	return ""
+}
+
+// GetAll retrieves all departments
+// Supported filters: active (true for only the departments purchasings can be charged to)
+func (dc *DepartmentController) GetAll(c *fiber.Ctx) error {
+	departments, err := dc.departmentRepo.GetAll(c.Query("active") == "true")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve departments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Departments retrieved successfully",
+		"data":    departments,
+	})
+}
+
+// Create creates a new department
+func (dc *DepartmentController) Create(c *fiber.Ctx) error {
+	var req DepartmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	// Check if code already exists
+	if existing, err := dc.departmentRepo.FindByCode(req.Code); err == nil && existing != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Department code already exists",
+		})
+	}
+
+	now := time.Now()
+	department := models.Department{
+		Code:      req.Code,
+		Name:      req.Name,
+		Active:    req.Active == nil || *req.Active,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := dc.departmentRepo.Create(&department); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create department",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Department created successfully",
+		"data":    department,
+	})
+}
+
+// Update updates an existing department
+func (dc *DepartmentController) Update(c *fiber.Ctx) error {
+	department, err := dc.findDepartment(c)
+	if err != nil {
+		return err
+	}
+
+	var req DepartmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if existing, err := dc.departmentRepo.FindByCode(req.Code); err == nil && existing.ID != department.ID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Department code already exists",
+		})
+	}
+
+	department.Code = req.Code
+	department.Name = req.Name
+	if req.Active != nil {
+		department.Active = *req.Active
+	}
+	department.UpdatedAt = time.Now()
+
+	if err := dc.departmentRepo.Update(department); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update department",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Department updated successfully",
+		"data":    department,
+	})
+}
+
+// Delete deletes a department that has no budgets or purchasings
+func (dc *DepartmentController) Delete(c *fiber.Ctx) error {
+	department, err := dc.findDepartment(c)
+	if err != nil {
+		return err
+	}
+
+	if err := dc.departmentRepo.Delete(department.ID); err != nil {
+		if errors.Is(err, repository.ErrDepartmentInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot delete department: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete department",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Department deleted successfully",
+	})
+}
+
+// findDepartment loads the department in the route, returning a 400 or 404 fiber error
+func (dc *DepartmentController) findDepartment(c *fiber.Ctx) (*models.Department, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid department ID")
+	}
+
+	department, err := dc.departmentRepo.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Department not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve department")
+	}
+	return department, nil
+}
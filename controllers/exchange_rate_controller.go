@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/repository"
+	"procurement-system/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeRateController handles exchange rate HTTP requests
+type ExchangeRateController struct {
+	rateRepo *repository.ExchangeRateRepository
+}
+
+// NewExchangeRateController creates a new ExchangeRateController instance
+func NewExchangeRateController() *ExchangeRateController {
+	return &ExchangeRateController{
+		rateRepo: repository.NewExchangeRateRepository(),
+	}
+}
+
+// ExchangeRateRequest represents the request body for entering a rate
+// rate is the value of one unit of currency in the base currency from effectiveDate (YYYY-MM-DD) on.
+type ExchangeRateRequest struct {
+	Currency      string          `json:"currency" validate:"required,len=3"`
+	EffectiveDate string          `json:"effectiveDate" validate:"required"`
+	Rate          decimal.Decimal `json:"rate" validate:"required"`
+}
+
+// GetAll retrieves a page of exchange rates, latest first
+// Supported filters: currency, dateFrom, dateTo (YYYY-MM-DD, inclusive)
+func (ec *ExchangeRateController) GetAll(c *fiber.Ctx) error {
+	filter := repository.ExchangeRateFilter{
+		Currency: strings.ToUpper(c.Query("currency")),
+	}
+
+	if v := c.Query("dateFrom"); v != "" {
+		from, err := utils.ParseDate(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid dateFrom, use YYYY-MM-DD",
+			})
+		}
+		filter.DateFrom = &from
+	}
+
+	if v := c.Query("dateTo"); v != "" {
+		to, err := utils.ParseDate(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid dateTo, use YYYY-MM-DD",
+			})
+		}
+		filter.DateTo = &to
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	rates, pageInfo, err := ec.rateRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve exchange rates")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Exchange rates retrieved successfully",
+		"baseCurrency": config.BaseCurrency,
+		"data":         rates,
+		"pagination":   pageInfo,
+	})
+}
+
+// Create enters the rate of a currency for a day
+func (ec *ExchangeRateController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req ExchangeRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rate, err := newExchangeRate(req.Currency, req.EffectiveDate, req.Rate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	rate.CreatedBy = userID
+
+	// Check if the day already has a rate
+	if existing, err := ec.rateRepo.FindByDay(rate.Currency, rate.EffectiveDate); err == nil && existing != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":      "An exchange rate for this currency and day already exists",
+			"existingId": existing.ID,
+		})
+	}
+
+	if err := ec.rateRepo.Create(rate); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create exchange rate",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Exchange rate created successfully",
+		"data":    rate,
+	})
+}
+
+// Import stores the rates of a CSV file with the columns currency, date (YYYY-MM-DD) and rate
+// The file is sent as the multipart field "file" or as a text/csv body; a header row is
+// optional. A rate already set for the same currency and day is replaced. Nothing is stored
+// if any row is invalid.
+func (ec *ExchangeRateController) Import(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var source io.Reader
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to read uploaded file",
+			})
+		}
+		defer file.Close()
+		source = file
+	} else {
+		source = strings.NewReader(string(c.Body()))
+	}
+
+	rates, err := parseExchangeRateCSV(source)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if len(rates) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "The file contains no rates",
+		})
+	}
+	for i := range rates {
+		rates[i].CreatedBy = userID
+	}
+
+	if err := ec.rateRepo.ImportTransaction(rates); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import exchange rates",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Exchange rates imported successfully",
+		"imported": len(rates),
+	})
+}
+
+// Delete deletes an exchange rate; purchasings already converted with it keep their rate
+func (ec *ExchangeRateController) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid exchange rate ID",
+		})
+	}
+
+	if _, err := ec.rateRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Exchange rate not found",
+		})
+	}
+
+	if err := ec.rateRepo.Delete(uint(id)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete exchange rate",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Exchange rate deleted successfully",
+	})
+}
+
+// parseExchangeRateCSV reads currency,date,rate rows, skipping a leading header row
+// Errors name the line of the file that is invalid.
+func parseExchangeRateCSV(source io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	var rates []models.ExchangeRate
+	seen := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("line %d: expected the columns currency,date,rate", parseErr.Line)
+			}
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "currency") {
+			continue
+		}
+
+		value, err := decimal.NewFromString(strings.TrimSpace(record[2]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: rate must be a positive number", line)
+		}
+		rate, err := newExchangeRate(record[0], strings.TrimSpace(record[1]), value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		// A later row for the same currency and day wins, as the upsert would do
+		key := rate.Currency + " " + rate.EffectiveDate.Format("2006-01-02")
+		if i, ok := seen[key]; ok {
+			rates[i] = *rate
+			continue
+		}
+		seen[key] = len(rates)
+		rates = append(rates, *rate)
+	}
+	return rates, nil
+}
+
+// newExchangeRate validates and normalizes the fields of a rate
+func newExchangeRate(currency, effectiveDate string, rate decimal.Decimal) (*models.ExchangeRate, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !models.IsValidCurrencyCode(currency) {
+		return nil, errors.New("currency must be a three-letter code such as USD")
+	}
+	if currency == config.BaseCurrency {
+		return nil, fmt.Errorf("%s is the base currency, its rate is always 1", currency)
+	}
+
+	date, err := utils.ParseDate(effectiveDate)
+	if err != nil {
+		return nil, errors.New("effective date must be YYYY-MM-DD")
+	}
+	if !rate.IsPositive() {
+		return nil, errors.New("rate must be a positive number")
+	}
+
+	return &models.ExchangeRate{
+		Currency:      currency,
+		EffectiveDate: date,
+		Rate:          rate,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// GoodsReceiptController handles goods receipt HTTP requests
+type GoodsReceiptController struct {
+	receiptRepo    *repository.GoodsReceiptRepository
+	purchasingRepo *repository.PurchasingRepository
+	itemRepo       *repository.ItemRepository
+	warehouseRepo  *repository.WarehouseRepository
+}
+
+// NewGoodsReceiptController creates a new GoodsReceiptController instance
+func NewGoodsReceiptController() *GoodsReceiptController {
+	return &GoodsReceiptController{
+		receiptRepo:    repository.NewGoodsReceiptRepository(),
+		purchasingRepo: repository.NewPurchasingRepository(),
+		itemRepo:       repository.NewItemRepository(),
+		warehouseRepo:  repository.NewWarehouseRepository(),
+	}
+}
+
+// CreateGoodsReceiptRequest represents the request body for recording a goods receipt
+// WarehouseID is where the goods are put; it defaults to the purchasing's target warehouse.
+type CreateGoodsReceiptRequest struct {
+	WarehouseID *uint                   `json:"warehouseId"`
+	Note        string                  `json:"note"`
+	Lines       []GoodsReceiptLineInput `json:"lines" validate:"required,min=1,dive"`
+}
+
+// GoodsReceiptLineInput represents a received quantity for one purchasing detail
+// Quantities are in the detail's unit; stock moves by the equivalent in the item's base unit.
+type GoodsReceiptLineInput struct {
+	PurchasingDetailID uint `json:"purchasingDetailId" validate:"required"`
+	ReceivedQty        int  `json:"receivedQty" validate:"min=0"`
+	RejectedQty        int  `json:"rejectedQty" validate:"min=0"`
+}
+
+// Create records goods received against a purchasing and moves stock for accepted quantities
+func (gc *GoodsReceiptController) Create(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid purchasing ID",
+		})
+	}
+
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateGoodsReceiptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if len(req.Lines) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one receipt line is required",
+		})
+	}
+
+	lines := make([]models.GoodsReceiptLine, 0, len(req.Lines))
+	for _, input := range req.Lines {
+		if input.ReceivedQty < 0 || input.RejectedQty < 0 || input.ReceivedQty+input.RejectedQty == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Each line needs a positive receivedQty or rejectedQty",
+			})
+		}
+
+		lines = append(lines, models.GoodsReceiptLine{
+			PurchasingDetailID: input.PurchasingDetailID,
+			ReceivedQty:        input.ReceivedQty,
+			RejectedQty:        input.RejectedQty,
+		})
+	}
+
+	if req.WarehouseID != nil {
+		if _, err := gc.warehouseRepo.FindByID(*req.WarehouseID); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Warehouse not found",
+			})
+		}
+	}
+
+	receipt := models.GoodsReceipt{
+		PurchasingID: uint(id),
+		UserID:       userID,
+		WarehouseID:  req.WarehouseID,
+		ReceivedAt:   time.Now(),
+		Note:         req.Note,
+	}
+
+	purchasing, err := gc.receiptRepo.CreateReceiptTransaction(&receipt, lines, gc.itemRepo.UpdateStockWithTx)
+	if err != nil {
+		var quantityErr *repository.ReceiptQuantityError
+		if errors.As(err, &quantityErr) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":              quantityErr.Error(),
+				"purchasingDetailId": quantityErr.PurchasingDetailID,
+			})
+		}
+		var transitionErr *repository.InvalidTransitionError
+		if errors.As(err, &transitionErr) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return transitionErrorResponse(c, err)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to record goods receipt: " + err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":    "Goods receipt recorded successfully",
+		"data":       receipt,
+		"purchasing": purchasing,
+	})
+}
+
+// GetByPurchasing retrieves all goods receipts for a purchasing
+func (gc *GoodsReceiptController) GetByPurchasing(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid purchasing ID",
+		})
+	}
+
+	if _, err := gc.purchasingRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchasing not found",
+		})
+	}
+
+	receipts, err := gc.receiptRepo.GetByPurchasing(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve goods receipts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Goods receipts retrieved successfully",
+		"data":    receipts,
+	})
+}
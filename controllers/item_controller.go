@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 
 	"procurement-system/models"
@@ -8,47 +10,127 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 // ItemController handles item-related HTTP requests
 type ItemController struct {
-	itemRepo     *repository.ItemRepository
-	supplierRepo *repository.SupplierRepository
+	itemRepo      *repository.ItemRepository
+	supplierRepo  *repository.SupplierRepository
+	movementRepo  *repository.StockMovementRepository
+	warehouseRepo *repository.WarehouseRepository
+	taxCodeRepo   *repository.TaxCodeRepository
+	unitRepo      *repository.UnitRepository
 }
 
 // NewItemController creates a new ItemController instance
 func NewItemController(db interface{}) *ItemController {
 	return &ItemController{
-		itemRepo:     repository.NewItemRepository(),
-		supplierRepo: repository.NewSupplierRepository(),
+		itemRepo:      repository.NewItemRepository(),
+		supplierRepo:  repository.NewSupplierRepository(),
+		movementRepo:  repository.NewStockMovementRepository(),
+		warehouseRepo: repository.NewWarehouseRepository(),
+		taxCodeRepo:   repository.NewTaxCodeRepository(),
+		unitRepo:      repository.NewUnitRepository(),
 	}
 }
 
 // CreateItemRequest represents the request body for creating an item
+// Initial stock goes into WarehouseID, or the default warehouse when it is omitted.
+// Stock and price are in BaseUnitID, the PCS unit when it is omitted.
 type CreateItemRequest struct {
-	Name       string          `json:"name" validate:"required"`
-	Stock      int             `json:"stock" validate:"min=0"`
-	Price      decimal.Decimal `json:"price" validate:"required,min=0"`
-	SupplierID uint            `json:"supplierId" validate:"required"`
+	Name        string          `json:"name" validate:"required"`
+	Stock       int             `json:"stock" validate:"min=0"`
+	Price       decimal.Decimal `json:"price" validate:"required,min=0"`
+	SupplierID  uint            `json:"supplierId" validate:"required"`
+	WarehouseID *uint           `json:"warehouseId"`
+	BaseUnitID  *uint           `json:"baseUnitId"`
 }
 
 // UpdateItemRequest represents the request body for updating an item
+// Stock may be omitted; if given it must equal the current stock, since stock is
+// corrected through stock adjustments.
 type UpdateItemRequest struct {
 	Name       string          `json:"name" validate:"required"`
-	Stock      int             `json:"stock" validate:"min=0"`
+	Stock      *int            `json:"stock"`
 	Price      decimal.Decimal `json:"price" validate:"required,min=0"`
 	SupplierID uint            `json:"supplierId" validate:"required"`
 }
 
-// GetAll retrieves all items
+// ReorderSettingsRequest represents the reorder settings of an item
+// A null reorderPoint turns reordering off. Otherwise exactly one of reorderQty (a fixed
+// quantity) or maxStock (order up to this level) must be set.
+type ReorderSettingsRequest struct {
+	ReorderPoint *int `json:"reorderPoint" validate:"omitempty,min=0"`
+	ReorderQty   int  `json:"reorderQty" validate:"min=0"`
+	MaxStock     int  `json:"maxStock" validate:"min=0"`
+}
+
+// validate checks the combination of settings the struct tags cannot express
+func (req *ReorderSettingsRequest) validate() string {
+	if req.ReorderPoint == nil {
+		return ""
+	}
+	if *req.ReorderPoint < 0 || req.ReorderQty < 0 || req.MaxStock < 0 {
+		return "reorderPoint, reorderQty and maxStock cannot be negative"
+	}
+	if (req.ReorderQty > 0) == (req.MaxStock > 0) {
+		return "set either reorderQty or maxStock"
+	}
+	if req.MaxStock > 0 && req.MaxStock <= *req.ReorderPoint {
+		return "maxStock must be greater than reorderPoint"
+	}
+	return ""
+}
+
+// ItemTaxesRequest represents the taxes charged by default on purchasing lines of an item
+// A null code means no tax of that kind.
+type ItemTaxesRequest struct {
+	TaxCodeID         *uint `json:"taxCodeId"`
+	WithholdingCodeID *uint `json:"withholdingCodeId"`
+}
+
+// ItemUnitsRequest represents an item's base unit and the other units it is purchased in
+// factor is how many base units one purchase unit holds and must be at least 2.
+type ItemUnitsRequest struct {
+	BaseUnitID uint            `json:"baseUnitId" validate:"required"`
+	Units      []ItemUnitInput `json:"units" validate:"dive"`
+}
+
+// ItemUnitInput represents one purchase unit of an item
+type ItemUnitInput struct {
+	UnitID uint `json:"unitId" validate:"required"`
+	Factor int  `json:"factor" validate:"required,min=2"`
+}
+
+// validate checks the combination of units the struct tags cannot express
+func (req *ItemUnitsRequest) validate() string {
+	if req.BaseUnitID == 0 {
+		return "baseUnitId is required"
+	}
+	seen := map[uint]bool{req.BaseUnitID: true}
+	for _, u := range req.Units {
+		if u.UnitID == 0 || u.Factor < 2 {
+			return "every unit needs a unitId and a factor of at least 2"
+		}
+		if seen[u.UnitID] {
+			return "each unit may appear only once and not be the base unit"
+		}
+		seen[u.UnitID] = true
+	}
+	return ""
+}
+
+// GetAll retrieves a page of items
+// Supported filters: name (contains), supplierId, minPrice, maxPrice, stockBelow (total stock),
+// warehouseId (items stocked there); see parseListParams for pagination and sorting.
+// stock is always the total over all warehouses; stockBy=location adds the per-warehouse
+// balances as stocks.
 func (ic *ItemController) GetAll(c *fiber.Ctx) error {
-	supplierIDParam := c.Query("supplierId")
-	var (
-		items []models.Item
-		err   error
-	)
+	var filter repository.ItemFilter
+	filter.Name = c.Query("name")
 
-	if supplierIDParam != "" {
+	if supplierIDParam := c.Query("supplierId"); supplierIDParam != "" {
 		supplierID, err := strconv.ParseUint(supplierIDParam, 10, 32)
 		if err != nil {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -62,30 +144,92 @@ func (ic *ItemController) GetAll(c *fiber.Ctx) error {
 				"error": "Supplier not found",
 			})
 		}
+		filter.SupplierID = uint(supplierID)
+	}
 
-		items, err = ic.itemRepo.GetAllBySupplier(uint(supplierID))
+	if v := c.Query("minPrice"); v != "" {
+		minPrice, err := decimal.NewFromString(v)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to retrieve items",
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid minPrice",
 			})
 		}
-	} else {
-		items, err = ic.itemRepo.GetAll()
+		filter.MinPrice = &minPrice
+	}
+
+	if v := c.Query("maxPrice"); v != "" {
+		maxPrice, err := decimal.NewFromString(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid maxPrice",
+			})
+		}
+		filter.MaxPrice = &maxPrice
+	}
+
+	if v := c.Query("stockBelow"); v != "" {
+		stockBelow, err := strconv.Atoi(v)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to retrieve items",
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid stockBelow",
 			})
 		}
+		filter.StockBelow = &stockBelow
+	}
+
+	if v := c.Query("warehouseId"); v != "" {
+		warehouseID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid warehouse ID",
+			})
+		}
+
+		// Ensure warehouse exists
+		if _, err := ic.warehouseRepo.FindByID(uint(warehouseID)); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Warehouse not found",
+			})
+		}
+		filter.WarehouseID = uint(warehouseID)
+	}
+
+	switch c.Query("stockBy", "total") {
+	case "total":
+	case "location":
+		filter.ByLocation = true
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "stockBy must be total or location",
+		})
+	}
+
+	params, err := parseListParams(c, false)
+	if err != nil {
+		return err
+	}
+
+	items, pageInfo, err := ic.itemRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve items")
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Items retrieved successfully",
-		"data":    items,
+		"message":    "Items retrieved successfully",
+		"data":       items,
+		"pagination": pageInfo,
 	})
 }
 
 // Create creates a new item
 func (ic *ItemController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
 	var req CreateItemRequest
 
 	if err := c.BodyParser(&req); err != nil {
@@ -101,14 +245,42 @@ func (ic *ItemController) Create(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Stock < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "stock must not be negative",
+		})
+	}
+
+	if req.WarehouseID != nil {
+		if _, err := ic.warehouseRepo.FindByID(*req.WarehouseID); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Warehouse not found",
+			})
+		}
+	}
+
+	var baseUnit *models.UnitOfMeasure
+	var err error
+	if req.BaseUnitID != nil {
+		baseUnit, err = ic.unitRepo.FindByID(*req.BaseUnitID)
+	} else {
+		baseUnit, err = ic.unitRepo.FindByCode(models.DefaultUnitCode)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Base unit not found",
+		})
+	}
+
 	item := models.Item{
 		Name:       req.Name,
 		Stock:      req.Stock,
 		Price:      req.Price,
+		BaseUnitID: &baseUnit.ID,
 		SupplierID: req.SupplierID,
 	}
 
-	if err := ic.itemRepo.Create(&item); err != nil {
+	if err := ic.itemRepo.Create(&item, req.WarehouseID, userID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create item",
 		})
@@ -144,8 +316,14 @@ func (ic *ItemController) Update(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Stock != nil && *req.Stock != item.Stock {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":        "Stock cannot be changed directly; create a stock adjustment instead",
+			"currentStock": item.Stock,
+		})
+	}
+
 	item.Name = req.Name
-	item.Stock = req.Stock
 	item.Price = req.Price
 	item.SupplierID = req.SupplierID
 
@@ -195,3 +373,303 @@ func (ic *ItemController) Delete(c *fiber.Ctx) error {
 		"message": "Item deleted successfully",
 	})
 }
+
+// UpdateReorderSettings sets or clears the reorder point of an item
+func (ic *ItemController) UpdateReorderSettings(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid item ID",
+		})
+	}
+
+	item, err := ic.itemRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+
+	var req ReorderSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	item.ReorderPoint = req.ReorderPoint
+	item.ReorderQty = req.ReorderQty
+	item.MaxStock = req.MaxStock
+	if item.ReorderPoint == nil {
+		item.ReorderQty = 0
+		item.MaxStock = 0
+	}
+
+	if err := ic.itemRepo.UpdateReorderSettings(item); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update reorder settings",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Reorder settings updated successfully",
+		"data":    item,
+	})
+}
+
+// UpdateTaxes sets the tax and withholding codes charged by default on purchasing lines of an item
+func (ic *ItemController) UpdateTaxes(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid item ID",
+		})
+	}
+
+	item, err := ic.itemRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+
+	var req ItemTaxesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	for _, field := range []struct {
+		id   *uint
+		kind string
+	}{
+		{req.TaxCodeID, models.TaxKindVAT},
+		{req.WithholdingCodeID, models.TaxKindWithholding},
+	} {
+		if field.id == nil {
+			continue
+		}
+		code, err := ic.taxCodeRepo.FindByID(*field.id)
+		if err != nil || !code.Active || code.Kind != field.kind {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Tax code %d is not an active %s code", *field.id, field.kind),
+			})
+		}
+	}
+
+	item.TaxCodeID = req.TaxCodeID
+	item.WithholdingCodeID = req.WithholdingCodeID
+
+	if err := ic.itemRepo.UpdateTaxes(item); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update item taxes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Item taxes updated successfully",
+		"data":    item,
+	})
+}
+
+// GetUnits retrieves an item's base unit and the units it can be purchased in
+func (ic *ItemController) GetUnits(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid item ID",
+		})
+	}
+
+	item, err := ic.unitRepo.FindItemWithUnits(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Item units retrieved successfully",
+		"data": fiber.Map{
+			"itemId":   item.ID,
+			"baseUnit": item.BaseUnit,
+			"units":    item.Units,
+		},
+	})
+}
+
+// UpdateUnits sets an item's base unit and replaces the units it can be purchased in
+// The base unit only changes while the item has no stock and no open purchasings.
+func (ic *ItemController) UpdateUnits(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid item ID",
+		})
+	}
+
+	if _, err := ic.itemRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+
+	var req ItemUnitsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if _, err := ic.unitRepo.FindByID(req.BaseUnitID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Base unit not found",
+		})
+	}
+
+	units := make([]models.ItemUnit, 0, len(req.Units))
+	for _, u := range req.Units {
+		if _, err := ic.unitRepo.FindByID(u.UnitID); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": fmt.Sprintf("Unit %d not found", u.UnitID),
+			})
+		}
+		units = append(units, models.ItemUnit{UnitID: u.UnitID, Factor: u.Factor})
+	}
+
+	if err := ic.unitRepo.ReplaceItemUnitsTransaction(uint(id), req.BaseUnitID, units); err != nil {
+		if errors.Is(err, repository.ErrBaseUnitImmutable) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot change base unit: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update item units",
+		})
+	}
+
+	item, err := ic.unitRepo.FindItemWithUnits(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reload item units",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Item units updated successfully",
+		"data": fiber.Map{
+			"itemId":   item.ID,
+			"baseUnit": item.BaseUnit,
+			"units":    item.Units,
+		},
+	})
+}
+
+// GetStock retrieves an item's stock per warehouse, in total and in transit between warehouses
+func (ic *ItemController) GetStock(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid item ID",
+		})
+	}
+
+	summary, err := ic.itemRepo.FindStockSummary(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve item stock",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Item stock retrieved successfully",
+		"data":    summary,
+	})
+}
+
+// GetMovements retrieves a page of an item's stock ledger, newest first
+// Supported filters: reason, warehouseId
+func (ic *ItemController) GetMovements(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid item ID",
+		})
+	}
+
+	if _, err := ic.itemRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+
+	var warehouseID uint
+	if v := c.Query("warehouseId"); v != "" {
+		parsed, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid warehouse ID",
+			})
+		}
+		warehouseID = uint(parsed)
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	movements, pageInfo, err := ic.movementRepo.ListByItem(uint(id), c.Query("reason"), warehouseID, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve stock movements")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Stock movements retrieved successfully",
+		"data":       movements,
+		"pagination": pageInfo,
+	})
+}
+
+// GetReconciliation lists items whose stock does not match the sum of their stock ledger, and
+// warehouse balances that do not match the ledger of their warehouse
+func (ic *ItemController) GetReconciliation(c *fiber.Ctx) error {
+	discrepancies, err := ic.movementRepo.FindDiscrepancies()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reconcile stock",
+		})
+	}
+
+	warehouseDiscrepancies, err := ic.movementRepo.FindWarehouseDiscrepancies()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reconcile warehouse stock",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Stock reconciled",
+		"consistent": len(discrepancies) == 0 && len(warehouseDiscrepancies) == 0,
+		"data":       discrepancies,
+		"warehouses": warehouseDiscrepancies,
+	})
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseListParams reads the shared listing query parameters:
+// page and limit (offset pagination), cursor (keyset pagination, takes precedence over page),
+// sort (a column key accepted by the listing) and order (asc or desc)
+func parseListParams(c *fiber.Ctx, defaultDesc bool) (repository.ListParams, error) {
+	params := repository.ListParams{
+		Pagination: repository.Pagination{
+			Page:  c.QueryInt("page", 1),
+			Limit: c.QueryInt("limit", repository.DefaultPageLimit),
+		},
+		Cursor: c.Query("cursor"),
+		SortBy: c.Query("sort"),
+		Desc:   defaultDesc,
+	}
+	params.Normalize()
+
+	switch strings.ToLower(c.Query("order")) {
+	case "":
+	case "asc":
+		params.Desc = false
+	case "desc":
+		params.Desc = true
+	default:
+		return params, fiber.NewError(fiber.StatusBadRequest, "Invalid order, use asc or desc")
+	}
+
+	return params, nil
+}
+
+// listErrorResponse maps a listing error to an HTTP response
+// Invalid sort keys and cursors are client errors; anything else is reported with fallback
+func listErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	if errors.Is(err, repository.ErrInvalidSort) || errors.Is(err, repository.ErrInvalidCursor) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
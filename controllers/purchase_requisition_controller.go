@@ -0,0 +1,476 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/repository"
+	"procurement-system/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// PurchaseRequisitionController handles purchase requisition HTTP requests
+type PurchaseRequisitionController struct {
+	requisitionRepo *repository.PurchaseRequisitionRepository
+	departmentRepo  *repository.DepartmentRepository
+	itemRepo        *repository.ItemRepository
+	warehouseRepo   *repository.WarehouseRepository
+	webhookRepo     *repository.WebhookEventRepository
+}
+
+// NewPurchaseRequisitionController creates a new PurchaseRequisitionController instance
+func NewPurchaseRequisitionController() *PurchaseRequisitionController {
+	return &PurchaseRequisitionController{
+		requisitionRepo: repository.NewPurchaseRequisitionRepository(),
+		departmentRepo:  repository.NewDepartmentRepository(),
+		itemRepo:        repository.NewItemRepository(),
+		warehouseRepo:   repository.NewWarehouseRepository(),
+		webhookRepo:     repository.NewWebhookEventRepository(),
+	}
+}
+
+// CreateRequisitionRequest represents the request body for raising a purchase requisition
+// neededBy is YYYY-MM-DD and cannot be in the past.
+type CreateRequisitionRequest struct {
+	DepartmentID  uint                   `json:"departmentId" validate:"required"`
+	NeededBy      string                 `json:"neededBy" validate:"required"`
+	Justification string                 `json:"justification" validate:"required"`
+	Lines         []RequisitionLineInput `json:"lines" validate:"required,min=1,dive"`
+}
+
+// RequisitionLineInput represents one requested line: a catalog item (itemId) or a free-text description
+// qty is in the item's base unit; estimatedPrice is an optional guess per unit in the base currency.
+type RequisitionLineInput struct {
+	ItemID         *uint           `json:"itemId"`
+	Description    string          `json:"description" validate:"max=255"`
+	Qty            int             `json:"qty" validate:"required,min=1"`
+	EstimatedPrice decimal.Decimal `json:"estimatedPrice" validate:"min=0"`
+}
+
+// validate checks the fields the struct tags describe and trims the texts
+func (req *CreateRequisitionRequest) validate() string {
+	req.Justification = strings.TrimSpace(req.Justification)
+	if req.DepartmentID == 0 {
+		return "departmentId is required"
+	}
+	if req.Justification == "" {
+		return "justification is required"
+	}
+	if len(req.Lines) == 0 {
+		return "At least one requisition line is required"
+	}
+	for i := range req.Lines {
+		line := &req.Lines[i]
+		line.Description = strings.TrimSpace(line.Description)
+		if line.ItemID == nil && line.Description == "" {
+			return "Each line needs an itemId or a description"
+		}
+		if len(line.Description) > 255 {
+			return "description must be at most 255 characters"
+		}
+		if line.Qty <= 0 || line.EstimatedPrice.IsNegative() {
+			return "Each line needs a positive qty and an estimatedPrice of at least 0"
+		}
+	}
+	return ""
+}
+
+// RequisitionApprovalRequest represents the request body for approving a requisition
+// rejectedLineIds are lines the reviewer turns down; the other lines are approved.
+type RequisitionApprovalRequest struct {
+	Comment         string `json:"comment"`
+	RejectedLineIDs []uint `json:"rejectedLineIds"`
+}
+
+// ConvertRequisitionRequest represents the request body for converting a requisition into purchasings
+// lineIds limits the conversion to some approved lines (all when omitted); items gives the catalog
+// item of each free-text line; warehouseId defaults to the default warehouse.
+type ConvertRequisitionRequest struct {
+	WarehouseID *uint                      `json:"warehouseId"`
+	LineIDs     []uint                     `json:"lineIds"`
+	Items       []RequisitionLineItemInput `json:"items" validate:"dive"`
+}
+
+// RequisitionLineItemInput assigns a catalog item to a free-text requisition line
+type RequisitionLineItemInput struct {
+	LineID uint `json:"lineId" validate:"required"`
+	ItemID uint `json:"itemId" validate:"required"`
+}
+
+// GetAll retrieves a page of requisitions, newest first
+// Supported filters: requesterId, departmentId, status
+func (rc *PurchaseRequisitionController) GetAll(c *fiber.Ctx) error {
+	filter := repository.PurchaseRequisitionFilter{
+		Status: c.Query("status"),
+	}
+
+	if filter.Status != "" && !models.IsValidRequisitionStatus(filter.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid status",
+		})
+	}
+
+	if v := c.Query("requesterId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid requester ID",
+			})
+		}
+		filter.RequesterID = uint(id)
+	}
+
+	if v := c.Query("departmentId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid department ID",
+			})
+		}
+		filter.DepartmentID = uint(id)
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	requisitions, pageInfo, err := rc.requisitionRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve requisitions")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Requisitions retrieved successfully",
+		"data":       requisitions,
+		"pagination": pageInfo,
+	})
+}
+
+// GetByID retrieves a requisition with its lines and the purchasings they were converted into
+func (rc *PurchaseRequisitionController) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid requisition ID",
+		})
+	}
+
+	requisition, err := rc.requisitionRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Requisition not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Requisition retrieved successfully",
+		"data":    requisition,
+	})
+}
+
+// Create raises a draft requisition for the calling user
+func (rc *PurchaseRequisitionController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateRequisitionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	neededBy, err := utils.ParseDate(req.NeededBy)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid neededBy, expected YYYY-MM-DD",
+		})
+	}
+	if neededBy.Before(utils.Today()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "neededBy cannot be in the past",
+		})
+	}
+
+	department, err := rc.departmentRepo.FindByID(req.DepartmentID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Department not found",
+		})
+	}
+	if !department.Active {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Department is inactive",
+		})
+	}
+
+	lines := make([]models.PurchaseRequisitionLine, 0, len(req.Lines))
+	for _, input := range req.Lines {
+		if input.ItemID != nil {
+			if _, err := rc.itemRepo.FindByID(*input.ItemID); err != nil {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": fmt.Sprintf("Item with ID %d not found", *input.ItemID),
+				})
+			}
+		}
+		lines = append(lines, models.PurchaseRequisitionLine{
+			ItemID:         input.ItemID,
+			Description:    input.Description,
+			Qty:            input.Qty,
+			EstimatedPrice: input.EstimatedPrice.Round(2),
+		})
+	}
+
+	now := time.Now()
+	requisition := models.PurchaseRequisition{
+		RequesterID:   userID,
+		DepartmentID:  department.ID,
+		NeededBy:      neededBy,
+		Justification: req.Justification,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := rc.requisitionRepo.Create(&requisition, lines); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create requisition",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Requisition created as draft",
+		"data":    requisition,
+	})
+}
+
+// Submit sends a draft requisition for review
+func (rc *PurchaseRequisitionController) Submit(c *fiber.Ctx) error {
+	id, userID, err := parseRequisitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	requisition, err := rc.requisitionRepo.SubmitTransaction(id, userID)
+	if err != nil {
+		return requisitionErrorResponse(c, err, "Failed to submit requisition")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Requisition submitted for review",
+		"data":    requisition,
+	})
+}
+
+// Approve approves a submitted requisition, optionally turning down some of its lines
+func (rc *PurchaseRequisitionController) Approve(c *fiber.Ctx) error {
+	id, userID, err := parseRequisitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req RequisitionApprovalRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	requisition, err := rc.requisitionRepo.ApproveTransaction(id, userID, strings.TrimSpace(req.Comment), req.RejectedLineIDs)
+	if err != nil {
+		return requisitionErrorResponse(c, err, "Failed to approve requisition")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Requisition approved",
+		"data":    requisition,
+	})
+}
+
+// Reject rejects a submitted requisition; a comment is required
+func (rc *PurchaseRequisitionController) Reject(c *fiber.Ctx) error {
+	id, userID, err := parseRequisitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req ApprovalDecisionRequest
+	if err := c.BodyParser(&req); err != nil || req.Comment == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A comment explaining the rejection is required",
+		})
+	}
+
+	requisition, err := rc.requisitionRepo.RejectTransaction(id, userID, req.Comment)
+	if err != nil {
+		return requisitionErrorResponse(c, err, "Failed to reject requisition")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Requisition rejected",
+		"data":    requisition,
+	})
+}
+
+// Cancel cancels a requisition none of whose lines was converted yet
+func (rc *PurchaseRequisitionController) Cancel(c *fiber.Ctx) error {
+	id, userID, err := parseRequisitionParams(c)
+	if err != nil {
+		return err
+	}
+	role, _ := c.Locals("role").(string)
+
+	var req ApprovalDecisionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	requisition, err := rc.requisitionRepo.CancelTransaction(id, userID, role, strings.TrimSpace(req.Comment))
+	if err != nil {
+		return requisitionErrorResponse(c, err, "Failed to cancel requisition")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Requisition cancelled",
+		"data":    requisition,
+	})
+}
+
+// Convert turns approved lines of a requisition into draft purchasings, one per supplier
+// The purchasings are created by the calling user and charged to the requisition's department.
+func (rc *PurchaseRequisitionController) Convert(c *fiber.Ctx) error {
+	id, userID, err := parseRequisitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req ConvertRequisitionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	conversion := repository.RequisitionConversion{
+		LineIDs: req.LineIDs,
+		ItemIDs: make(map[uint]uint, len(req.Items)),
+	}
+	for _, input := range req.Items {
+		if input.LineID == 0 || input.ItemID == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Each entry of items needs a lineId and an itemId",
+			})
+		}
+		conversion.ItemIDs[input.LineID] = input.ItemID
+	}
+
+	warehouse, err := findWarehouseOrDefault(rc.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return err
+	}
+	conversion.WarehouseID = warehouse.ID
+
+	purchasings, err := rc.requisitionRepo.ConvertTransaction(id, userID, conversion, func(tx *gorm.DB, purchasing *models.Purchasing) error {
+		return rc.webhookRepo.PublishPurchasingWithTx(tx, models.WebhookEventPurchasingCreated, config.WebhookURL, purchasing.ID)
+	})
+	if err != nil {
+		return requisitionErrorResponse(c, err, "Failed to convert requisition")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": fmt.Sprintf("Requisition converted into %d draft purchasing(s)", len(purchasings)),
+		"data":    purchasings,
+	})
+}
+
+// parseRequisitionParams reads the requisition ID route parameter and the acting user
+func parseRequisitionParams(c *fiber.Ctx) (uint, uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid requisition ID")
+	}
+
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return 0, 0, fiber.NewError(fiber.StatusUnauthorized, "User ID not found in token")
+	}
+
+	return uint(id), userID, nil
+}
+
+// requisitionErrorResponse maps purchase requisition errors to HTTP responses
+func requisitionErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	var statusErr *repository.RequisitionStatusError
+	if errors.As(err, &statusErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":         statusErr.Error(),
+			"currentStatus": statusErr.CurrentStatus,
+		})
+	}
+	var lineErr *repository.RequisitionLineError
+	if errors.As(err, &lineErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  lineErr.Error(),
+			"lineId": lineErr.LineID,
+		})
+	}
+	var deniedErr *repository.ApprovalDeniedError
+	if errors.As(err, &deniedErr) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": deniedErr.Error(),
+		})
+	}
+	var contractErr *repository.ContractViolationError
+	if errors.As(err, &contractErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":      contractErr.Error(),
+			"itemId":     contractErr.ItemID,
+			"contractId": contractErr.ContractID,
+			"violation":  contractErr.Violation,
+		})
+	}
+	var budgetErr *repository.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":     budgetErr.Error(),
+			"budgetId":  budgetErr.BudgetID,
+			"remaining": budgetErr.Remaining,
+		})
+	}
+	var rateErr *repository.MissingExchangeRateError
+	if errors.As(err, &rateErr) || errors.Is(err, repository.ErrInvalidTaxCode) ||
+		errors.Is(err, repository.ErrRequisitionNoApprovedLines) || errors.Is(err, repository.ErrRequisitionNothingToConvert) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, repository.ErrRequisitionPartlyConverted) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Requisition not found",
+		})
+	}
+	log.Printf("%s: %v", fallback, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
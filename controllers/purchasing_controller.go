@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"procurement-system/config"
@@ -12,48 +14,92 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type PurchasingController struct {
 	purchasingRepo *repository.PurchasingRepository
+	approvalRepo   *repository.PurchasingApprovalRepository
+	webhookRepo    *repository.WebhookEventRepository
 	itemRepo       *repository.ItemRepository
 	supplierRepo   *repository.SupplierRepository
+	warehouseRepo  *repository.WarehouseRepository
+	priceRepo      *repository.SupplierPriceRepository
+	unitRepo       *repository.UnitRepository
+	departmentRepo *repository.DepartmentRepository
 }
 
 // NewPurchasingController creates a new PurchasingController instance
 func NewPurchasingController() *PurchasingController {
 	return &PurchasingController{
 		purchasingRepo: repository.NewPurchasingRepository(),
+		approvalRepo:   repository.NewPurchasingApprovalRepository(),
+		webhookRepo:    repository.NewWebhookEventRepository(),
 		itemRepo:       repository.NewItemRepository(),
 		supplierRepo:   repository.NewSupplierRepository(),
+		warehouseRepo:  repository.NewWarehouseRepository(),
+		priceRepo:      repository.NewSupplierPriceRepository(),
+		unitRepo:       repository.NewUnitRepository(),
+		departmentRepo: repository.NewDepartmentRepository(),
 	}
 }
 
 // CreatePurchasingRequest represents the request body for creating a purchasing transaction
+// WarehouseID is where the goods will be received; the default warehouse when omitted.
+// DepartmentID charges the purchasing to the department's budget for the order date.
+// Discount applies to the whole purchasing after the line discounts; shipping is not taxed.
+// ExpectedDeliveryDate is the optional YYYY-MM-DD day the supplier is expected to deliver.
 type CreatePurchasingRequest struct {
-	SupplierID uint                    `json:"supplierId" validate:"required"`
-	Details    []PurchasingDetailInput `json:"details" validate:"required,min=1,dive"`
+	SupplierID           uint                    `json:"supplierId" validate:"required"`
+	WarehouseID          *uint                   `json:"warehouseId"`
+	DepartmentID         *uint                   `json:"departmentId"`
+	Discount             *DiscountInput          `json:"discount"`
+	ShippingAmount       decimal.Decimal         `json:"shippingAmount" validate:"min=0"`
+	Details              []PurchasingDetailInput `json:"details" validate:"required,min=1,dive"`
+	ExpectedDeliveryDate string                  `json:"expectedDeliveryDate"`
 }
 
 // PurchasingDetailInput represents a purchasing detail item in the request
+// qty is in unitId, the item's base unit when omitted or one of its purchase units.
+// taxCodeId and withholdingCodeId default to the item's codes when omitted; 0 charges none.
 type PurchasingDetailInput struct {
-	ItemID uint `json:"itemId" validate:"required"`
-	Qty    int  `json:"qty" validate:"required,min=1"`
+	ItemID            uint           `json:"itemId" validate:"required"`
+	Qty               int            `json:"qty" validate:"required,min=1"`
+	UnitID            *uint          `json:"unitId"`
+	Discount          *DiscountInput `json:"discount"`
+	TaxCodeID         *uint          `json:"taxCodeId"`
+	WithholdingCodeID *uint          `json:"withholdingCodeId"`
 	// Note: Price and SubTotal are NOT accepted from client - calculated server-side
 }
 
+// DiscountInput represents a discount: a percentage (0-100) or an amount in the supplier's currency
+type DiscountInput struct {
+	Type  string          `json:"type" validate:"required,oneof=percent amount"`
+	Value decimal.Decimal `json:"value" validate:"required"`
+}
+
 // PurchasingResponse represents the response after creating a purchasing transaction
+// BudgetWarning is set when a soft budget let the purchasing through although it does not cover it;
+// ContractWarnings lists the lines a warning-only supplier contract let through.
 type PurchasingResponse struct {
-	Message    string                    `json:"message"`
-	Purchasing models.Purchasing         `json:"purchasing"`
-	Details    []models.PurchasingDetail `json:"details"`
+	Message          string                    `json:"message"`
+	Purchasing       models.Purchasing         `json:"purchasing"`
+	Details          []models.PurchasingDetail `json:"details"`
+	BudgetWarning    string                    `json:"budgetWarning,omitempty"`
+	ContractWarnings []string                  `json:"contractWarnings,omitempty"`
 }
 
 // Create handles creating a new purchasing transaction
-// - Server-side calculation of prices from Items table
+// - New purchasings start in draft status
+// - Server-side calculation of prices from the supplier's price list valid on the order date,
+// tiered by quantity, falling back to the item's price; both are per base unit and are
+// multiplied up for lines in a larger purchase unit
+// - Discounts, taxes and shipping are worked into the cost breakdown server-side as well
+// - Purchasings charged to a department are checked against its budget: a hard budget refuses
+// one it cannot cover, a soft budget lets it through with a warning
 // - Database transaction (ACID) with automatic rollback on error
-// - Automatic stock update
-// - Webhook notification after successful commit
+// - Stock is updated later, when goods are received
+// - Webhook notification queued in the outbox within the same transaction
 func (pc *PurchasingController) Create(c *fiber.Ctx) error {
 	var req CreatePurchasingRequest
 
@@ -79,9 +125,41 @@ func (pc *PurchasingController) Create(c *fiber.Ctx) error {
 		})
 	}
 
+	// Resolve the target warehouse
+	warehouse, err := findWarehouseOrDefault(pc.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return err
+	}
+
+	// Validate the department the purchasing is charged to
+	if req.DepartmentID != nil {
+		department, err := pc.departmentRepo.FindByID(*req.DepartmentID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Department not found",
+			})
+		}
+		if !department.Active {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Department is inactive",
+			})
+		}
+	}
+
+	if req.ShippingAmount.IsNegative() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "shippingAmount cannot be negative",
+		})
+	}
+
+	expectedDelivery, err := parseExpectedDeliveryDate(req.ExpectedDeliveryDate)
+	if err != nil {
+		return err
+	}
+
 	// Prepare purchasing details with server-side price calculation
+	orderDate := time.Now()
 	var details []models.PurchasingDetail
-	var grandTotal decimal.Decimal = decimal.Zero
 
 	for _, detailInput := range req.Details {
 		// Get item from database to fetch current price
@@ -99,38 +177,112 @@ func (pc *PurchasingController) Create(c *fiber.Ctx) error {
 			})
 		}
 
-		// Server-side calculation: SubTotal = Price * Qty
-		subTotal := item.Price.Mul(decimal.NewFromInt(int64(detailInput.Qty)))
+		unitID, factor, err := pc.unitRepo.ResolveUnit(item, detailInput.UnitID)
+		if errors.Is(err, repository.ErrUnitNotForItem) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Unit %d is not a unit of item %d", *detailInput.UnitID, detailInput.ItemID),
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to look up item unit",
+			})
+		}
 
-		// Add to grand total
-		grandTotal = grandTotal.Add(subTotal)
+		// Server-side price lookup in the base unit; subtotals, discounts and taxes are computed on creation
+		basePrice, priceID, err := pc.priceRepo.ResolveUnitPrice(req.SupplierID, item, detailInput.Qty*factor, orderDate)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to look up item price",
+			})
+		}
+		unitPrice := basePrice.Mul(decimal.NewFromInt(int64(factor)))
 
 		// Create purchasing detail
 		detail := models.PurchasingDetail{
-			ItemID:   detailInput.ItemID,
-			Qty:      detailInput.Qty,
-			SubTotal: subTotal,
+			ItemID:            detailInput.ItemID,
+			Qty:               detailInput.Qty,
+			UnitID:            unitID,
+			UnitFactor:        factor,
+			UnitPrice:         unitPrice,
+			SupplierPriceID:   priceID,
+			TaxCodeID:         taxCodeOrDefault(detailInput.TaxCodeID, item.TaxCodeID),
+			WithholdingCodeID: taxCodeOrDefault(detailInput.WithholdingCodeID, item.WithholdingCodeID),
+		}
+		if detailInput.Discount != nil {
+			detail.DiscountType = detailInput.Discount.Type
+			detail.DiscountValue = detailInput.Discount.Value
 		}
 		details = append(details, detail)
 	}
 
 	// Create purchasing header
 	purchasing := models.Purchasing{
-		Date:       time.Now(),
-		SupplierID: req.SupplierID,
-		UserID:     userID,
-		GrandTotal: grandTotal,
+		Date:                 orderDate,
+		SupplierID:           req.SupplierID,
+		UserID:               userID,
+		WarehouseID:          &warehouse.ID,
+		DepartmentID:         req.DepartmentID,
+		ShippingAmount:       req.ShippingAmount,
+		Status:               models.PurchasingStatusDraft,
+		Origin:               models.PurchasingOriginManual,
+		ExpectedDeliveryDate: expectedDelivery,
+	}
+	if req.Discount != nil {
+		purchasing.DiscountType = req.Discount.Type
+		purchasing.DiscountValue = req.Discount.Value
+	}
+
+	// External Integration: webhook notification through the outbox
+	// The event is written in the same transaction as the purchasing and delivered by the
+	// background dispatcher after commit, with retries, so it is never lost.
+	// Webhook URL priority:
+	// 1. HTTP Header: X-Webhook-URL (optional override, unless disabled with WEBHOOK_ALLOW_HEADER_OVERRIDE)
+	// 2. Environment Variable: WEBHOOK_URL (default from config)
+	webhookURL := config.WebhookURL
+	if override := c.Get("X-Webhook-URL"); override != "" {
+		if !config.WebhookAllowHeaderOverride {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "X-Webhook-URL override is disabled",
+			})
+		}
+		// Refuse internal and non-allowlisted targets before anything is written
+		if err := utils.ValidateWebhookURL(override); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid X-Webhook-URL: " + err.Error(),
+			})
+		}
+		webhookURL = override
 	}
 
 	// Create transaction with ACID properties
-	// This ensures atomicity: Insert Header + Insert Details + Update Stock
+	// This ensures atomicity: Insert Header + Insert Details + Webhook Outbox Events
 	// If any step fails, all changes are rolled back automatically
 	err = pc.purchasingRepo.CreatePurchasingTransaction(
 		&purchasing,
 		details,
-		pc.itemRepo.UpdateStockWithTx,
+		func(tx *gorm.DB, purchasing *models.Purchasing) error {
+			return pc.webhookRepo.PublishPurchasingWithTx(tx, models.WebhookEventPurchasingCreated, webhookURL, purchasing.ID)
+		},
 	)
 
+	var contractErr *repository.ContractViolationError
+	if errors.As(err, &contractErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":      "Cannot create purchasing: " + contractErr.Error(),
+			"itemId":     contractErr.ItemID,
+			"contractId": contractErr.ContractID,
+			"violation":  contractErr.Violation,
+		})
+	}
+	var rateErr *repository.MissingExchangeRateError
+	var budgetErr *repository.BudgetExceededError
+	if errors.As(err, &rateErr) || errors.As(err, &budgetErr) ||
+		errors.Is(err, repository.ErrInvalidDiscount) || errors.Is(err, repository.ErrInvalidTaxCode) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Cannot create purchasing: " + err.Error(),
+		})
+	}
 	if err != nil {
 		// Transaction was rolled back automatically by GORM
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -139,38 +291,557 @@ func (pc *PurchasingController) Create(c *fiber.Ctx) error {
 	}
 
 	// Transaction committed successfully at this point
-	// All three operations (Header, Details, Stock Update) are now permanent in database
+	// All operations (Header, Details, Outbox Event) are now permanent in database
 
 	// Reload purchasing with relationships for response
 	var purchasingWithRelations models.Purchasing
 	var detailsWithRelations []models.PurchasingDetail
 
-	config.DB.Preload("Supplier").Preload("User").First(&purchasingWithRelations, purchasing.ID)
-	config.DB.Where("purchasing_id = ?", purchasing.ID).Preload("Item").Find(&detailsWithRelations)
+	config.DB.Preload("Supplier").Preload("User").Preload("Warehouse").Preload("Department").First(&purchasingWithRelations, purchasing.ID)
+	config.DB.Where("purchasing_id = ?", purchasing.ID).Preload("Item").Preload("Unit").Preload("TaxCode").Preload("WithholdingCode").Find(&detailsWithRelations)
 
-	// External Integration: Send webhook notification AFTER successful database commit
-	// Webhook is sent asynchronously (non-blocking) so it doesn't delay the HTTP response
-	// Webhook URL priority:
-	// 1. HTTP Header: X-Webhook-URL (optional override)
-	// 2. Environment Variable: WEBHOOK_URL (default from config)
-	webhookURL := c.Get("X-Webhook-URL") // Optional: webhook URL from header (override)
-	if webhookURL == "" {
-		webhookURL = config.WebhookURL // Use default from environment variable
+	return c.Status(fiber.StatusCreated).JSON(PurchasingResponse{
+		Message:          "Purchasing transaction created successfully",
+		Purchasing:       purchasingWithRelations,
+		Details:          detailsWithRelations,
+		BudgetWarning:    budgetWarning(&purchasingWithRelations),
+		ContractWarnings: contractWarnings(detailsWithRelations),
+	})
+}
+
+// GetAll retrieves a page of purchasings
+// Supported filters: supplierId, userId, warehouseId, departmentId, status, origin (manual|reorder|requisition|rfq), currency,
+// dateFrom, dateTo (YYYY-MM-DD, inclusive), minTotal, maxTotal (base currency); see
+// parseListParams for pagination and sorting (newest first by default)
+func (pc *PurchasingController) GetAll(c *fiber.Ctx) error {
+	filter, err := parsePurchasingFilter(c)
+	if err != nil {
+		return err
 	}
 
-	if webhookURL != "" {
-		// Send webhook in goroutine (fire and forget)
-		// This ensures webhook failure doesn't affect the transaction response
-		go func() {
-			if err := utils.SendWebhook(webhookURL, &purchasingWithRelations, detailsWithRelations); err != nil {
-				log.Printf("Webhook error (non-blocking): %v", err)
-			}
-		}()
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(PurchasingResponse{
-		Message:    "Purchasing transaction created successfully",
-		Purchasing: purchasingWithRelations,
-		Details:    detailsWithRelations,
+	purchasings, pageInfo, err := pc.purchasingRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve purchasings")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Purchasings retrieved successfully",
+		"data":       purchasings,
+		"pagination": pageInfo,
+	})
+}
+
+// GetByID retrieves a purchasing with its details, items, supplier and user
+func (pc *PurchasingController) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid purchasing ID",
+		})
+	}
+
+	purchasing, err := pc.purchasingRepo.FindByIDWithDetails(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchasing not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Purchasing retrieved successfully",
+		"data":    purchasing,
+	})
+}
+
+// parsePurchasingFilter reads the purchasing listing filters from the query string
+func parsePurchasingFilter(c *fiber.Ctx) (repository.PurchasingFilter, error) {
+	var filter repository.PurchasingFilter
+
+	if v := c.Query("supplierId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid supplier ID")
+		}
+		filter.SupplierID = uint(id)
+	}
+
+	if v := c.Query("userId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+		}
+		filter.UserID = uint(id)
+	}
+
+	if v := c.Query("warehouseId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid warehouse ID")
+		}
+		filter.WarehouseID = uint(id)
+	}
+
+	if v := c.Query("departmentId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid department ID")
+		}
+		filter.DepartmentID = uint(id)
+	}
+
+	if v := c.Query("status"); v != "" {
+		if !models.IsValidPurchasingStatus(v) {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid status")
+		}
+		filter.Status = v
+	}
+
+	if v := c.Query("origin"); v != "" {
+		if v != models.PurchasingOriginManual && v != models.PurchasingOriginReorder && v != models.PurchasingOriginRequisition &&
+			v != models.PurchasingOriginRFQ {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid origin")
+		}
+		filter.Origin = v
+	}
+
+	if v := c.Query("currency"); v != "" {
+		v = strings.ToUpper(v)
+		if !models.IsValidCurrencyCode(v) {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid currency")
+		}
+		filter.Currency = v
+	}
+
+	if v := c.Query("dateFrom"); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid dateFrom, use YYYY-MM-DD")
+		}
+		filter.DateFrom = &from
+	}
+
+	if v := c.Query("dateTo"); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid dateTo, use YYYY-MM-DD")
+		}
+		// dateTo is inclusive, so filter up to the start of the following day
+		to = to.AddDate(0, 0, 1)
+		filter.DateTo = &to
+	}
+
+	if v := c.Query("minTotal"); v != "" {
+		minTotal, err := decimal.NewFromString(v)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid minTotal")
+		}
+		filter.MinTotal = &minTotal
+	}
+
+	if v := c.Query("maxTotal"); v != "" {
+		maxTotal, err := decimal.NewFromString(v)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid maxTotal")
+		}
+		filter.MaxTotal = &maxTotal
+	}
+
+	if v := c.Query("offContract"); v != "" {
+		offContract, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid offContract, use true or false")
+		}
+		filter.OffContract = offContract
+	}
+
+	return filter, nil
+}
+
+// taxCodeOrDefault returns the tax code given on a line, or the item's default when none was given
+// A code of 0 means the line is charged no tax of that kind.
+func taxCodeOrDefault(lineCodeID, itemCodeID *uint) *uint {
+	if lineCodeID == nil {
+		return itemCodeID
+	}
+	if *lineCodeID == 0 {
+		return nil
+	}
+	return lineCodeID
+}
+
+// TransitionRequest represents the optional request body for a status transition
+type TransitionRequest struct {
+	Note string `json:"note"`
+}
+
+// ApprovalDecisionRequest represents the request body for approving or rejecting a purchasing
+type ApprovalDecisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// Submit submits a draft purchasing for approval
+// The approval rules are evaluated against BaseGrandTotal; when none applies the purchasing is approved right away
+func (pc *PurchasingController) Submit(c *fiber.Ctx) error {
+	id, userID, err := parseTransitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req TransitionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	purchasing, err := pc.approvalRepo.SubmitTransaction(id, userID, req.Note)
+	if err != nil {
+		return transitionErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Purchasing status changed to " + purchasing.Status,
+		"data":    purchasing,
+	})
+}
+
+// Approve records the current user's approval of a submitted purchasing
+// The purchasing becomes approved once the number of approvals required by its rule is reached
+func (pc *PurchasingController) Approve(c *fiber.Ctx) error {
+	id, userID, err := parseTransitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req ApprovalDecisionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	role, _ := c.Locals("role").(string)
+	purchasing, err := pc.approvalRepo.ApproveTransaction(id, userID, role, req.Comment)
+	if err != nil {
+		return approvalErrorResponse(c, err)
+	}
+
+	message := "Approval recorded, waiting for further approvals"
+	if purchasing.Status == models.PurchasingStatusApproved {
+		message = "Purchasing approved"
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    purchasing,
+	})
+}
+
+// Reject rejects a submitted purchasing and sends it back to draft
+func (pc *PurchasingController) Reject(c *fiber.Ctx) error {
+	id, userID, err := parseTransitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req ApprovalDecisionRequest
+	if err := c.BodyParser(&req); err != nil || req.Comment == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A comment explaining the rejection is required",
+		})
+	}
+
+	role, _ := c.Locals("role").(string)
+	purchasing, err := pc.approvalRepo.RejectTransaction(id, userID, role, req.Comment)
+	if err != nil {
+		return approvalErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Purchasing rejected and returned to draft",
+		"data":    purchasing,
+	})
+}
+
+// GetApprovals retrieves all approval decisions on a purchasing
+func (pc *PurchasingController) GetApprovals(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid purchasing ID",
+		})
+	}
+
+	if _, err := pc.purchasingRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchasing not found",
+		})
+	}
+
+	approvals, err := pc.approvalRepo.GetByPurchasing(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve approvals",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Approvals retrieved successfully",
+		"data":    approvals,
+	})
+}
+
+// GetPendingApprovals lists submitted purchasings waiting for the current user's decision
+func (pc *PurchasingController) GetPendingApprovals(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+	role, _ := c.Locals("role").(string)
+
+	purchasings, err := pc.approvalRepo.GetPendingForApprover(userID, role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve pending approvals",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Pending approvals retrieved successfully",
+		"data":    purchasings,
+	})
+}
+
+// OrderRequest represents the optional request body for ordering a purchasing
+// expectedDeliveryDate (YYYY-MM-DD) replaces the day the supplier is expected to deliver.
+type OrderRequest struct {
+	Note                 string `json:"note"`
+	ExpectedDeliveryDate string `json:"expectedDeliveryDate"`
+}
+
+// Order marks an approved purchasing as ordered from the supplier, optionally with the delivery
+// date the supplier confirmed
+func (pc *PurchasingController) Order(c *fiber.Ctx) error {
+	id, userID, err := parseTransitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req OrderRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	expectedDelivery, err := parseExpectedDeliveryDate(req.ExpectedDeliveryDate)
+	if err != nil {
+		return err
+	}
+
+	purchasing, err := pc.purchasingRepo.OrderTransaction(id, userID, req.Note, expectedDelivery)
+	if err != nil {
+		return transitionErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Purchasing status changed to " + purchasing.Status,
+		"data":    purchasing,
+	})
+}
+
+// Cancel cancels a purchasing and takes any goods already received back out of stock
+func (pc *PurchasingController) Cancel(c *fiber.Ctx) error {
+	id, userID, err := parseTransitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req TransitionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	purchasing, err := pc.purchasingRepo.CancelTransaction(id, userID, req.Note, pc.itemRepo.UpdateStockWithTx)
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot reverse received goods: " + err.Error(),
+			})
+		}
+		if errors.Is(err, repository.ErrPurchasingInvoiced) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot cancel purchasing: " + err.Error(),
+			})
+		}
+		return transitionErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Purchasing cancelled successfully",
+		"data":    purchasing,
+	})
+}
+
+// Close closes a received or partially received purchasing
+func (pc *PurchasingController) Close(c *fiber.Ctx) error {
+	return pc.transition(c, models.PurchasingStatusClosed)
+}
+
+// GetHistory retrieves the status history of a purchasing
+func (pc *PurchasingController) GetHistory(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid purchasing ID",
+		})
+	}
+
+	if _, err := pc.purchasingRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchasing not found",
+		})
+	}
+
+	history, err := pc.purchasingRepo.GetStatusHistory(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve purchasing history",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Purchasing history retrieved successfully",
+		"data":    history,
+	})
+}
+
+// transition applies a status change to the purchasing in the :id route parameter
+// Invalid transitions are answered with 409 Conflict and the purchasing's current status
+func (pc *PurchasingController) transition(c *fiber.Ctx, status string) error {
+	id, userID, err := parseTransitionParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req TransitionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	purchasing, err := pc.purchasingRepo.TransitionStatus(id, status, userID, req.Note)
+	if err != nil {
+		return transitionErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Purchasing status changed to " + purchasing.Status,
+		"data":    purchasing,
+	})
+}
+
+// parseTransitionParams reads the purchasing ID route parameter and the acting user
+func parseTransitionParams(c *fiber.Ctx) (uint, uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid purchasing ID")
+	}
+
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return 0, 0, fiber.NewError(fiber.StatusUnauthorized, "User ID not found in token")
+	}
+
+	return uint(id), userID, nil
+}
+
+// parseExpectedDeliveryDate parses an optional YYYY-MM-DD delivery date, which cannot be in the past
+func parseExpectedDeliveryDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := utils.ParseDate(value)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid expectedDeliveryDate, expected YYYY-MM-DD")
+	}
+	if date.Before(utils.Today()) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "expectedDeliveryDate cannot be in the past")
+	}
+	return &date, nil
+}
+
+// parseOptionalBody parses the request body into out when one was sent
+func parseOptionalBody(c *fiber.Ctx, out interface{}) error {
+	if len(c.Body()) == 0 {
+		return nil
+	}
+	if err := c.BodyParser(out); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	return nil
+}
+
+// approvalErrorResponse maps an approval decision error to an HTTP response
+func approvalErrorResponse(c *fiber.Ctx, err error) error {
+	var deniedErr *repository.ApprovalDeniedError
+	if errors.As(err, &deniedErr) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": deniedErr.Error(),
+		})
+	}
+	return transitionErrorResponse(c, err)
+}
+
+// transitionErrorResponse maps a status transition error to an HTTP response
+func transitionErrorResponse(c *fiber.Ctx, err error) error {
+	var transitionErr *repository.InvalidTransitionError
+	if errors.As(err, &transitionErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":           transitionErr.Error(),
+			"currentStatus":   transitionErr.CurrentStatus,
+			"requestedStatus": transitionErr.RequestedStatus,
+		})
+	}
+	var budgetErr *repository.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":     budgetErr.Error(),
+			"budgetId":  budgetErr.BudgetID,
+			"remaining": budgetErr.Remaining,
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchasing not found",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to change purchasing status: " + err.Error(),
 	})
 }
+
+// budgetWarning explains why a purchasing is marked over budget, or is empty when it is not
+func budgetWarning(purchasing *models.Purchasing) string {
+	if !purchasing.OverBudget {
+		return ""
+	}
+	return "purchasing exceeds the remaining budget of its department; allowed because the budget control is soft"
+}
+
+// contractWarnings explains each line that deviates from a supplier contract, or is empty when none does
+func contractWarnings(details []models.PurchasingDetail) []string {
+	var warnings []string
+	for _, detail := range details {
+		if detail.ContractViolation == "" || detail.ContractID == nil {
+			continue
+		}
+		violation := repository.ContractViolationError{
+			ItemID:     detail.ItemID,
+			ContractID: *detail.ContractID,
+			Violation:  detail.ContractViolation,
+		}
+		warnings = append(warnings, violation.Error())
+	}
+	return warnings
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"strconv"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// ReorderController handles reorder suggestion HTTP requests
+type ReorderController struct {
+	reorderRepo *repository.ReorderRepository
+	webhookRepo *repository.WebhookEventRepository
+}
+
+// NewReorderController creates a new ReorderController instance
+func NewReorderController() *ReorderController {
+	return &ReorderController{
+		reorderRepo: repository.NewReorderRepository(),
+		webhookRepo: repository.NewWebhookEventRepository(),
+	}
+}
+
+// GetSuggestions lists the items at or below their reorder point with the suggested quantities
+// Supported filters: supplierId
+func (rc *ReorderController) GetSuggestions(c *fiber.Ctx) error {
+	var supplierID uint
+	if v := c.Query("supplierId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid supplier ID",
+			})
+		}
+		supplierID = uint(id)
+	}
+
+	suggestions, err := rc.reorderRepo.FindSuggestions(supplierID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve reorder suggestions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Reorder suggestions retrieved successfully",
+		"data":    suggestions,
+	})
+}
+
+// Run drafts the suggested purchasings now, one per supplier, created by the calling user
+// This is what the reorder planner does on its schedule.
+func (rc *ReorderController) Run(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	run, err := rc.reorderRepo.CreateDraftsTransaction(userID, func(tx *gorm.DB, purchasing *models.Purchasing) error {
+		return rc.webhookRepo.PublishPurchasingWithTx(tx, models.WebhookEventPurchasingCreated, config.WebhookURL, purchasing.ID)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to draft purchasings: " + err.Error(),
+		})
+	}
+
+	if len(run.Drafts) == 0 && len(run.Skipped) == 0 {
+		return c.JSON(fiber.Map{
+			"message": "No items need reordering",
+			"data":    run,
+		})
+	}
+	if len(run.Drafts) == 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "No purchasing could be drafted",
+			"data":  run,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Draft purchasings created for review",
+		"data":    run,
+	})
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// ReportController handles report HTTP requests
+type ReportController struct {
+	reportRepo *repository.ReportRepository
+}
+
+// NewReportController creates a new ReportController instance
+func NewReportController() *ReportController {
+	return &ReportController{
+		reportRepo: repository.NewReportRepository(),
+	}
+}
+
+// GetPurchasingSummary totals purchasings per supplier, currency or month in the base currency
+// Query parameters: groupBy (supplier|currency|month, default supplier), dateFrom, dateTo
+// (YYYY-MM-DD, inclusive), status (comma separated; default everything but draft and cancelled)
+func (rc *ReportController) GetPurchasingSummary(c *fiber.Ctx) error {
+	filter := repository.PurchasingReportFilter{
+		GroupBy: c.Query("groupBy", "supplier"),
+	}
+
+	if v := c.Query("dateFrom"); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid dateFrom, use YYYY-MM-DD",
+			})
+		}
+		filter.DateFrom = &from
+	}
+
+	if v := c.Query("dateTo"); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid dateTo, use YYYY-MM-DD",
+			})
+		}
+		// dateTo is inclusive, so filter up to the start of the following day
+		to = to.AddDate(0, 0, 1)
+		filter.DateTo = &to
+	}
+
+	if v := c.Query("status"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			status = strings.TrimSpace(status)
+			if !models.IsValidPurchasingStatus(status) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid status: " + status,
+				})
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	rows, err := rc.reportRepo.PurchasingSummary(filter)
+	if errors.Is(err, repository.ErrInvalidGroupBy) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid groupBy, use supplier, currency or month",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build purchasing report",
+		})
+	}
+
+	var count int64
+	baseTotal := decimal.Zero
+	for _, row := range rows {
+		count += row.Count
+		baseTotal = baseTotal.Add(row.BaseTotal)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Purchasing report generated successfully",
+		"baseCurrency": config.BaseCurrency,
+		"groupBy":      filter.GroupBy,
+		"data":         rows,
+		"summary": fiber.Map{
+			"count":     count,
+			"baseTotal": baseTotal,
+		},
+	})
+}
+
+// GetAgedPayables ages what is owed on approved invoices per supplier, in the base currency
+// Query parameters: asOf (YYYY-MM-DD, default today), supplierId
+func (rc *ReportController) GetAgedPayables(c *fiber.Ctx) error {
+	asOf, err := parseDateOrToday(c.Query("asOf"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid asOf, use YYYY-MM-DD",
+		})
+	}
+
+	var supplierID uint
+	if v := c.Query("supplierId"); v != "" {
+		parsed, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid supplier ID",
+			})
+		}
+		supplierID = uint(parsed)
+	}
+
+	rows, err := rc.reportRepo.AgedPayables(asOf, supplierID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build aged payables report",
+		})
+	}
+
+	summary := repository.AgedPayablesRow{
+		Current:    decimal.Zero,
+		Days1To30:  decimal.Zero,
+		Days31To60: decimal.Zero,
+		Days61To90: decimal.Zero,
+		Over90:     decimal.Zero,
+		BaseTotal:  decimal.Zero,
+	}
+	for _, row := range rows {
+		summary.InvoiceCount += row.InvoiceCount
+		summary.Current = summary.Current.Add(row.Current)
+		summary.Days1To30 = summary.Days1To30.Add(row.Days1To30)
+		summary.Days31To60 = summary.Days31To60.Add(row.Days31To60)
+		summary.Days61To90 = summary.Days61To90.Add(row.Days61To90)
+		summary.Over90 = summary.Over90.Add(row.Over90)
+		summary.BaseTotal = summary.BaseTotal.Add(row.BaseTotal)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Aged payables report generated successfully",
+		"baseCurrency": config.BaseCurrency,
+		"asOf":         asOf.Format("2006-01-02"),
+		"data":         rows,
+		"summary": fiber.Map{
+			"invoiceCount": summary.InvoiceCount,
+			"current":      summary.Current,
+			"days1To30":    summary.Days1To30,
+			"days31To60":   summary.Days31To60,
+			"days61To90":   summary.Days61To90,
+			"over90":       summary.Over90,
+			"baseTotal":    summary.BaseTotal,
+		},
+	})
+}
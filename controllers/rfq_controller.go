@@ -0,0 +1,548 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/repository"
+	"procurement-system/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// RFQController handles request for quotation HTTP requests
+type RFQController struct {
+	rfqRepo        *repository.RFQRepository
+	departmentRepo *repository.DepartmentRepository
+	itemRepo       *repository.ItemRepository
+	supplierRepo   *repository.SupplierRepository
+	warehouseRepo  *repository.WarehouseRepository
+	webhookRepo    *repository.WebhookEventRepository
+}
+
+// NewRFQController creates a new RFQController instance
+func NewRFQController() *RFQController {
+	return &RFQController{
+		rfqRepo:        repository.NewRFQRepository(),
+		departmentRepo: repository.NewDepartmentRepository(),
+		itemRepo:       repository.NewItemRepository(),
+		supplierRepo:   repository.NewSupplierRepository(),
+		warehouseRepo:  repository.NewWarehouseRepository(),
+		webhookRepo:    repository.NewWebhookEventRepository(),
+	}
+}
+
+// CreateRFQRequest represents the request body for opening an RFQ
+// dueDate is an optional YYYY-MM-DD, the last day quotes are taken; departmentId is charged on award.
+type CreateRFQRequest struct {
+	Title        string         `json:"title" validate:"required,max=150"`
+	DueDate      string         `json:"dueDate"`
+	DepartmentID *uint          `json:"departmentId"`
+	Note         string         `json:"note"`
+	SupplierIDs  []uint         `json:"supplierIds" validate:"required,min=1"`
+	Lines        []RFQLineInput `json:"lines" validate:"required,min=1,dive"`
+}
+
+// RFQLineInput represents one item suppliers are asked to quote for; qty is in the item's base unit
+type RFQLineInput struct {
+	ItemID uint   `json:"itemId" validate:"required"`
+	Qty    int    `json:"qty" validate:"required,min=1"`
+	Note   string `json:"note" validate:"max=255"`
+}
+
+// validate checks the fields the struct tags describe and trims the texts
+func (req *CreateRFQRequest) validate() string {
+	req.Title = strings.TrimSpace(req.Title)
+	req.Note = strings.TrimSpace(req.Note)
+	if req.Title == "" || len(req.Title) > 150 {
+		return "title is required and must be at most 150 characters"
+	}
+	if len(req.SupplierIDs) == 0 {
+		return "At least one supplier must be invited"
+	}
+	if len(req.Lines) == 0 {
+		return "At least one RFQ line is required"
+	}
+	for i := range req.Lines {
+		line := &req.Lines[i]
+		line.Note = strings.TrimSpace(line.Note)
+		if line.ItemID == 0 || line.Qty <= 0 {
+			return "Each line needs an itemId and a positive qty"
+		}
+		if len(line.Note) > 255 {
+			return "note must be at most 255 characters"
+		}
+	}
+	return ""
+}
+
+// InviteSuppliersRequest represents the request body for inviting more suppliers to an RFQ
+type InviteSuppliersRequest struct {
+	SupplierIDs []uint `json:"supplierIds" validate:"required,min=1"`
+}
+
+// SubmitQuoteRequest represents the request body for entering a supplier's quote
+// Prices are per base unit in the supplier's currency; validUntil is YYYY-MM-DD.
+type SubmitQuoteRequest struct {
+	SupplierID     uint                `json:"supplierId" validate:"required"`
+	ShippingAmount decimal.Decimal     `json:"shippingAmount" validate:"min=0"`
+	Note           string              `json:"note"`
+	Lines          []RFQQuoteLineInput `json:"lines" validate:"required,min=1,dive"`
+}
+
+// RFQQuoteLineInput represents the supplier's price for one RFQ line
+type RFQQuoteLineInput struct {
+	RFQLineID    uint            `json:"rfqLineId" validate:"required"`
+	UnitPrice    decimal.Decimal `json:"unitPrice" validate:"required,gt=0"`
+	LeadTimeDays int             `json:"leadTimeDays" validate:"min=0"`
+	ValidUntil   string          `json:"validUntil" validate:"required"`
+}
+
+// validate checks the fields the struct tags describe and trims the note
+func (req *SubmitQuoteRequest) validate() string {
+	req.Note = strings.TrimSpace(req.Note)
+	if req.SupplierID == 0 {
+		return "supplierId is required"
+	}
+	if req.ShippingAmount.IsNegative() {
+		return "shippingAmount cannot be negative"
+	}
+	if len(req.Lines) == 0 {
+		return "At least one quote line is required"
+	}
+	for _, line := range req.Lines {
+		if line.RFQLineID == 0 || !line.UnitPrice.IsPositive() || line.LeadTimeDays < 0 {
+			return "Each line needs an rfqLineId, a positive unitPrice and a leadTimeDays of at least 0"
+		}
+		if line.ValidUntil == "" {
+			return "Each line needs a validUntil date"
+		}
+	}
+	return ""
+}
+
+// AwardRFQRequest represents the request body for awarding an RFQ to a supplier
+// warehouseId defaults to the default warehouse.
+type AwardRFQRequest struct {
+	SupplierID  uint  `json:"supplierId" validate:"required"`
+	WarehouseID *uint `json:"warehouseId"`
+}
+
+// GetAll retrieves a page of RFQs, newest first
+// Supported filters: status, supplierId (RFQs the supplier was invited to)
+func (rc *RFQController) GetAll(c *fiber.Ctx) error {
+	filter := repository.RFQFilter{
+		Status: c.Query("status"),
+	}
+
+	if filter.Status != "" && !models.IsValidRFQStatus(filter.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid status",
+		})
+	}
+
+	if v := c.Query("supplierId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid supplier ID",
+			})
+		}
+		filter.SupplierID = uint(id)
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	rfqs, pageInfo, err := rc.rfqRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve RFQs")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "RFQs retrieved successfully",
+		"data":       rfqs,
+		"pagination": pageInfo,
+	})
+}
+
+// GetByID retrieves an RFQ with its lines, invited suppliers and quotes
+func (rc *RFQController) GetByID(c *fiber.Ctx) error {
+	id, err := parseRFQID(c)
+	if err != nil {
+		return err
+	}
+
+	rfq, err := rc.rfqRepo.FindByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "RFQ not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "RFQ retrieved successfully",
+		"data":    rfq,
+	})
+}
+
+// Create opens an RFQ and invites its suppliers
+func (rc *RFQController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateRFQRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var dueDate *time.Time
+	if req.DueDate != "" {
+		date, err := utils.ParseDate(req.DueDate)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid dueDate, expected YYYY-MM-DD",
+			})
+		}
+		if date.Before(utils.Today()) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "dueDate cannot be in the past",
+			})
+		}
+		dueDate = &date
+	}
+
+	if req.DepartmentID != nil {
+		department, err := rc.departmentRepo.FindByID(*req.DepartmentID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Department not found",
+			})
+		}
+		if !department.Active {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": "Department is inactive",
+			})
+		}
+	}
+
+	if err := rc.checkSuppliers(req.SupplierIDs); err != nil {
+		return err
+	}
+
+	lines := make([]models.RFQLine, 0, len(req.Lines))
+	for _, input := range req.Lines {
+		if _, err := rc.itemRepo.FindByID(input.ItemID); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": fmt.Sprintf("Item with ID %d not found", input.ItemID),
+			})
+		}
+		lines = append(lines, models.RFQLine{
+			ItemID: input.ItemID,
+			Qty:    input.Qty,
+			Note:   input.Note,
+		})
+	}
+
+	now := time.Now()
+	rfq := models.RFQ{
+		Title:        req.Title,
+		DueDate:      dueDate,
+		DepartmentID: req.DepartmentID,
+		Note:         req.Note,
+		CreatedBy:    userID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := rc.rfqRepo.CreateTransaction(&rfq, lines, req.SupplierIDs); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create RFQ",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "RFQ opened",
+		"data":    rfq,
+	})
+}
+
+// InviteSuppliers invites more suppliers to an open RFQ
+func (rc *RFQController) InviteSuppliers(c *fiber.Ctx) error {
+	id, err := parseRFQID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req InviteSuppliersRequest
+	if err := c.BodyParser(&req); err != nil || len(req.SupplierIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "supplierIds must list at least one supplier",
+		})
+	}
+
+	if err := rc.checkSuppliers(req.SupplierIDs); err != nil {
+		return err
+	}
+
+	invited, err := rc.rfqRepo.InviteTransaction(id, req.SupplierIDs, userID)
+	if err != nil {
+		return rfqErrorResponse(c, err, "Failed to invite suppliers")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": fmt.Sprintf("%d supplier(s) invited", len(invited)),
+		"data":    invited,
+	})
+}
+
+// SubmitQuote enters an invited supplier's quote for an open RFQ, replacing its earlier quote
+func (rc *RFQController) SubmitQuote(c *fiber.Ctx) error {
+	id, err := parseRFQID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req SubmitQuoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	lines := make([]models.RFQQuoteLine, 0, len(req.Lines))
+	for _, input := range req.Lines {
+		validUntil, err := utils.ParseDate(input.ValidUntil)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid validUntil, expected YYYY-MM-DD",
+			})
+		}
+		lines = append(lines, models.RFQQuoteLine{
+			RFQLineID:    input.RFQLineID,
+			UnitPrice:    input.UnitPrice.Round(2),
+			LeadTimeDays: input.LeadTimeDays,
+			ValidUntil:   validUntil,
+		})
+	}
+
+	now := time.Now()
+	quote := models.RFQQuote{
+		SupplierID:     req.SupplierID,
+		ShippingAmount: req.ShippingAmount.Round(2),
+		Note:           req.Note,
+		CreatedBy:      userID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	if err := rc.rfqRepo.SaveQuoteTransaction(id, &quote, lines); err != nil {
+		return rfqErrorResponse(c, err, "Failed to save quote")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Quote saved",
+		"data":    quote,
+	})
+}
+
+// Compare sets the quotes of an RFQ side by side, ranked by landed cost and lead time
+// Supported query: date (YYYY-MM-DD, defaults to today) for exchange rates and price validity
+func (rc *RFQController) Compare(c *fiber.Ctx) error {
+	id, err := parseRFQID(c)
+	if err != nil {
+		return err
+	}
+
+	date, err := parseDateOrToday(c.Query("date"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid date, expected YYYY-MM-DD",
+		})
+	}
+
+	comparison, err := rc.rfqRepo.Compare(id, date)
+	if err != nil {
+		return rfqErrorResponse(c, err, "Failed to compare quotes")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Quotes compared successfully",
+		"data":    comparison,
+	})
+}
+
+// Award awards an open RFQ to a supplier and creates a draft purchasing at its quoted prices
+// The purchasing is created by the calling user and charged to the RFQ's department. The supplier
+// need not be the items' default supplier.
+func (rc *RFQController) Award(c *fiber.Ctx) error {
+	id, err := parseRFQID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req AwardRFQRequest
+	if err := c.BodyParser(&req); err != nil || req.SupplierID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "supplierId is required",
+		})
+	}
+
+	warehouse, err := findWarehouseOrDefault(rc.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return err
+	}
+
+	rfq, purchasing, err := rc.rfqRepo.AwardTransaction(id, req.SupplierID, userID, warehouse.ID, func(tx *gorm.DB, purchasing *models.Purchasing) error {
+		return rc.webhookRepo.PublishPurchasingWithTx(tx, models.WebhookEventPurchasingCreated, config.WebhookURL, purchasing.ID)
+	})
+	if err != nil {
+		return rfqErrorResponse(c, err, "Failed to award RFQ")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "RFQ awarded and draft purchasing created",
+		"data": fiber.Map{
+			"rfq":        rfq,
+			"purchasing": purchasing,
+		},
+	})
+}
+
+// Cancel cancels an open RFQ
+func (rc *RFQController) Cancel(c *fiber.Ctx) error {
+	id, err := parseRFQID(c)
+	if err != nil {
+		return err
+	}
+
+	rfq, err := rc.rfqRepo.CancelTransaction(id)
+	if err != nil {
+		return rfqErrorResponse(c, err, "Failed to cancel RFQ")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "RFQ cancelled",
+		"data":    rfq,
+	})
+}
+
+// checkSuppliers returns a 404 error for the first supplier that does not exist
+func (rc *RFQController) checkSuppliers(supplierIDs []uint) error {
+	for _, supplierID := range supplierIDs {
+		if _, err := rc.supplierRepo.FindByID(supplierID); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("Supplier with ID %d not found", supplierID))
+		}
+	}
+	return nil
+}
+
+// parseRFQID reads the RFQ ID route parameter
+func parseRFQID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid RFQ ID")
+	}
+	return uint(id), nil
+}
+
+// rfqErrorResponse maps RFQ errors to HTTP responses
+func rfqErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	var statusErr *repository.RFQStatusError
+	if errors.As(err, &statusErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":         statusErr.Error(),
+			"currentStatus": statusErr.CurrentStatus,
+		})
+	}
+	var lineErr *repository.RFQLineError
+	if errors.As(err, &lineErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":     lineErr.Error(),
+			"rfqLineId": lineErr.RFQLineID,
+		})
+	}
+	var contractErr *repository.ContractViolationError
+	if errors.As(err, &contractErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":      contractErr.Error(),
+			"itemId":     contractErr.ItemID,
+			"contractId": contractErr.ContractID,
+			"violation":  contractErr.Violation,
+		})
+	}
+	var budgetErr *repository.BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":     budgetErr.Error(),
+			"budgetId":  budgetErr.BudgetID,
+			"remaining": budgetErr.Remaining,
+		})
+	}
+	var rateErr *repository.MissingExchangeRateError
+	if errors.As(err, &rateErr) || errors.Is(err, repository.ErrInvalidTaxCode) ||
+		errors.Is(err, repository.ErrSupplierNotInvited) || errors.Is(err, repository.ErrRFQPastDue) ||
+		errors.Is(err, repository.ErrQuoteIncomplete) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "RFQ not found",
+		})
+	}
+	log.Printf("%s: %v", fallback, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
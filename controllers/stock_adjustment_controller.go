@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// StockAdjustmentController handles stock adjustment HTTP requests
+type StockAdjustmentController struct {
+	adjustmentRepo *repository.StockAdjustmentRepository
+	itemRepo       *repository.ItemRepository
+	warehouseRepo  *repository.WarehouseRepository
+}
+
+// NewStockAdjustmentController creates a new StockAdjustmentController instance
+func NewStockAdjustmentController() *StockAdjustmentController {
+	return &StockAdjustmentController{
+		adjustmentRepo: repository.NewStockAdjustmentRepository(),
+		itemRepo:       repository.NewItemRepository(),
+		warehouseRepo:  repository.NewWarehouseRepository(),
+	}
+}
+
+// CreateStockAdjustmentRequest represents the request body for requesting a stock adjustment
+// Quantity is signed: negative removes stock, positive adds it. WarehouseID defaults to the
+// default warehouse.
+type CreateStockAdjustmentRequest struct {
+	ItemID      uint   `json:"itemId" validate:"required"`
+	WarehouseID *uint  `json:"warehouseId"`
+	Quantity    int    `json:"quantity" validate:"required"`
+	Reason      string `json:"reason" validate:"required,oneof=damage loss expired found count_correction"`
+	Note        string `json:"note"`
+}
+
+// GetAll retrieves a page of stock adjustments, newest first
+// Supported filters: status, reason, itemId, warehouseId, cycleCountId
+func (sc *StockAdjustmentController) GetAll(c *fiber.Ctx) error {
+	filter := repository.StockAdjustmentFilter{
+		Status: c.Query("status"),
+		Reason: c.Query("reason"),
+	}
+
+	if v := c.Query("itemId"); v != "" {
+		itemID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid item ID",
+			})
+		}
+		filter.ItemID = uint(itemID)
+	}
+
+	if v := c.Query("warehouseId"); v != "" {
+		warehouseID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid warehouse ID",
+			})
+		}
+		filter.WarehouseID = uint(warehouseID)
+	}
+
+	if v := c.Query("cycleCountId"); v != "" {
+		cycleCountID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid cycle count ID",
+			})
+		}
+		filter.CycleCountID = uint(cycleCountID)
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	adjustments, pageInfo, err := sc.adjustmentRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve stock adjustments")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Stock adjustments retrieved successfully",
+		"data":       adjustments,
+		"pagination": pageInfo,
+	})
+}
+
+// GetByID retrieves a stock adjustment by ID
+func (sc *StockAdjustmentController) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid stock adjustment ID",
+		})
+	}
+
+	adjustment, err := sc.adjustmentRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Stock adjustment not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Stock adjustment retrieved successfully",
+		"data":    adjustment,
+	})
+}
+
+// Create requests a stock adjustment; stock changes only once an admin approves it
+func (sc *StockAdjustmentController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateStockAdjustmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if !models.IsValidAdjustmentReason(req.Reason) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "reason must be one of damage, loss, expired, found, count_correction",
+		})
+	}
+	if !models.AdjustmentReasonAllowsQuantity(req.Reason, req.Quantity) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "quantity does not fit reason " + req.Reason + " (damage, loss and expired remove stock, found adds it, neither may be zero)",
+		})
+	}
+
+	if _, err := sc.itemRepo.FindByID(req.ItemID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+
+	warehouse, err := findWarehouseOrDefault(sc.warehouseRepo, req.WarehouseID)
+	if err != nil {
+		return err
+	}
+
+	adjustment := models.StockAdjustment{
+		ItemID:      req.ItemID,
+		WarehouseID: &warehouse.ID,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		Note:        req.Note,
+		RequestedBy: userID,
+	}
+
+	if err := sc.adjustmentRepo.Create(&adjustment); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create stock adjustment",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Stock adjustment requested, awaiting approval",
+		"data":    adjustment,
+	})
+}
+
+// Approve approves a pending adjustment and posts it to stock
+func (sc *StockAdjustmentController) Approve(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "stock adjustment")
+	if err != nil {
+		return err
+	}
+
+	var req ApprovalDecisionRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	adjustment, err := sc.adjustmentRepo.ApproveTransaction(id, userID, req.Comment, sc.itemRepo.UpdateStockWithTx)
+	if err != nil {
+		return stockErrorResponse(c, err, "Stock adjustment not found", "Failed to approve stock adjustment")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Stock adjustment approved and posted to stock",
+		"data":    adjustment,
+	})
+}
+
+// Reject rejects a pending adjustment; a comment is required
+func (sc *StockAdjustmentController) Reject(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "stock adjustment")
+	if err != nil {
+		return err
+	}
+
+	var req ApprovalDecisionRequest
+	if err := c.BodyParser(&req); err != nil || req.Comment == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A comment explaining the rejection is required",
+		})
+	}
+
+	adjustment, err := sc.adjustmentRepo.RejectTransaction(id, userID, req.Comment)
+	if err != nil {
+		return stockErrorResponse(c, err, "Stock adjustment not found", "Failed to reject stock adjustment")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Stock adjustment rejected",
+		"data":    adjustment,
+	})
+}
+
+// findWarehouseOrDefault loads the requested warehouse, or the default one when none was requested
+func findWarehouseOrDefault(warehouseRepo *repository.WarehouseRepository, warehouseID *uint) (*models.Warehouse, error) {
+	var warehouse *models.Warehouse
+	var err error
+	if warehouseID != nil {
+		warehouse, err = warehouseRepo.FindByID(*warehouseID)
+	} else {
+		warehouse, err = warehouseRepo.FindDefault()
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Warehouse not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve warehouse")
+	}
+	return warehouse, nil
+}
+
+// parseStockDocumentParams reads the adjustment, cycle count or transfer ID from the route and the user from the token
+func parseStockDocumentParams(c *fiber.Ctx, document string) (uint, uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid "+document+" ID")
+	}
+
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return 0, 0, fiber.NewError(fiber.StatusUnauthorized, "User ID not found in token")
+	}
+
+	return uint(id), userID, nil
+}
+
+// stockErrorResponse maps stock adjustment, cycle count and transfer errors to HTTP responses
+func stockErrorResponse(c *fiber.Ctx, err error, notFound, fallback string) error {
+	var statusErr *repository.StockStatusError
+	if errors.As(err, &statusErr) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":         statusErr.Error(),
+			"currentStatus": statusErr.CurrentStatus,
+		})
+	}
+	var deniedErr *repository.ApprovalDeniedError
+	if errors.As(err, &deniedErr) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": deniedErr.Error(),
+		})
+	}
+	var lineErr *repository.CycleCountLineError
+	if errors.As(err, &lineErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":  lineErr.Error(),
+			"itemId": lineErr.ItemID,
+		})
+	}
+	if errors.Is(err, repository.ErrInsufficientStock) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Cannot move stock: " + err.Error(),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": notFound,
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback + ": " + err.Error(),
+	})
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"strconv"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StockTransferController handles stock transfer HTTP requests
+type StockTransferController struct {
+	transferRepo  *repository.StockTransferRepository
+	itemRepo      *repository.ItemRepository
+	warehouseRepo *repository.WarehouseRepository
+}
+
+// NewStockTransferController creates a new StockTransferController instance
+func NewStockTransferController() *StockTransferController {
+	return &StockTransferController{
+		transferRepo:  repository.NewStockTransferRepository(),
+		itemRepo:      repository.NewItemRepository(),
+		warehouseRepo: repository.NewWarehouseRepository(),
+	}
+}
+
+// CreateStockTransferRequest represents the request body for drafting a transfer between warehouses
+type CreateStockTransferRequest struct {
+	FromWarehouseID uint                 `json:"fromWarehouseId" validate:"required"`
+	ToWarehouseID   uint                 `json:"toWarehouseId" validate:"required"`
+	Note            string               `json:"note"`
+	Lines           []StockTransferInput `json:"lines" validate:"required,min=1,dive"`
+}
+
+// StockTransferInput is the quantity of one item to transfer
+type StockTransferInput struct {
+	ItemID   uint `json:"itemId" validate:"required"`
+	Quantity int  `json:"quantity" validate:"required,min=1"`
+}
+
+// GetAll retrieves a page of transfers, newest first
+// Supported filters: status, warehouseId (source or destination), itemId
+func (tc *StockTransferController) GetAll(c *fiber.Ctx) error {
+	filter := repository.StockTransferFilter{
+		Status: c.Query("status"),
+	}
+
+	if v := c.Query("warehouseId"); v != "" {
+		warehouseID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid warehouse ID",
+			})
+		}
+		filter.WarehouseID = uint(warehouseID)
+	}
+
+	if v := c.Query("itemId"); v != "" {
+		itemID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid item ID",
+			})
+		}
+		filter.ItemID = uint(itemID)
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	transfers, pageInfo, err := tc.transferRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve stock transfers")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Stock transfers retrieved successfully",
+		"data":       transfers,
+		"pagination": pageInfo,
+	})
+}
+
+// GetByID retrieves a transfer with its lines
+func (tc *StockTransferController) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid stock transfer ID",
+		})
+	}
+
+	transfer, err := tc.transferRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Stock transfer not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Stock transfer retrieved successfully",
+		"data":    transfer,
+	})
+}
+
+// Create drafts a transfer; stock moves when it is shipped and received
+func (tc *StockTransferController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateStockTransferRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.FromWarehouseID == req.ToWarehouseID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "fromWarehouseId and toWarehouseId must be different warehouses",
+		})
+	}
+	for _, warehouseID := range []uint{req.FromWarehouseID, req.ToWarehouseID} {
+		if _, err := tc.warehouseRepo.FindByID(warehouseID); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":       "Warehouse not found",
+				"warehouseId": warehouseID,
+			})
+		}
+	}
+
+	if len(req.Lines) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "At least one line is required",
+		})
+	}
+
+	// Quantities of the same item are combined into one line
+	lines := make([]models.StockTransferLine, 0, len(req.Lines))
+	lineByItem := make(map[uint]int, len(req.Lines))
+	for _, input := range req.Lines {
+		if input.Quantity < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":  "quantity must be at least 1",
+				"itemId": input.ItemID,
+			})
+		}
+		if i, ok := lineByItem[input.ItemID]; ok {
+			lines[i].Quantity += input.Quantity
+			continue
+		}
+
+		if _, err := tc.itemRepo.FindByID(input.ItemID); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":  "Item not found",
+				"itemId": input.ItemID,
+			})
+		}
+		lineByItem[input.ItemID] = len(lines)
+		lines = append(lines, models.StockTransferLine{
+			ItemID:   input.ItemID,
+			Quantity: input.Quantity,
+		})
+	}
+
+	transfer := models.StockTransfer{
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Note:            req.Note,
+		CreatedBy:       userID,
+		Lines:           lines,
+	}
+
+	if err := tc.transferRepo.Create(&transfer); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create stock transfer",
+		})
+	}
+
+	return tc.respondWithTransfer(c, transfer.ID, fiber.StatusCreated, "Stock transfer drafted")
+}
+
+// Ship takes the goods out of the source warehouse and puts the transfer in transit
+func (tc *StockTransferController) Ship(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "stock transfer")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tc.transferRepo.ShipTransaction(id, userID, tc.itemRepo.UpdateStockWithTx); err != nil {
+		return stockErrorResponse(c, err, "Stock transfer not found", "Failed to ship stock transfer")
+	}
+
+	return tc.respondWithTransfer(c, id, fiber.StatusOK, "Stock transfer shipped and in transit")
+}
+
+// Receive adds the goods of a transfer in transit to the destination warehouse
+func (tc *StockTransferController) Receive(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "stock transfer")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tc.transferRepo.ReceiveTransaction(id, userID, tc.itemRepo.UpdateStockWithTx); err != nil {
+		return stockErrorResponse(c, err, "Stock transfer not found", "Failed to receive stock transfer")
+	}
+
+	return tc.respondWithTransfer(c, id, fiber.StatusOK, "Stock transfer received")
+}
+
+// Cancel cancels a draft transfer, or returns the goods of a transfer in transit to the source warehouse
+func (tc *StockTransferController) Cancel(c *fiber.Ctx) error {
+	id, userID, err := parseStockDocumentParams(c, "stock transfer")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tc.transferRepo.CancelTransaction(id, userID, tc.itemRepo.UpdateStockWithTx); err != nil {
+		return stockErrorResponse(c, err, "Stock transfer not found", "Failed to cancel stock transfer")
+	}
+
+	return tc.respondWithTransfer(c, id, fiber.StatusOK, "Stock transfer cancelled")
+}
+
+// respondWithTransfer reloads the transfer with its warehouses and lines for the response
+func (tc *StockTransferController) respondWithTransfer(c *fiber.Ctx, id uint, status int, message string) error {
+	transfer, err := tc.transferRepo.FindByID(id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reload stock transfer",
+		})
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"message": message,
+		"data":    transfer,
+	})
+}
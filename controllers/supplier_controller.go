@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 
+	"procurement-system/config"
 	"procurement-system/models"
 	"procurement-system/repository"
 
@@ -22,31 +25,61 @@ func NewSupplierController(db interface{}) *SupplierController {
 }
 
 // CreateSupplierRequest represents the request body for creating a supplier
+// Currency defaults to the base currency and PaymentTerms ("Net 30" or "2/10 Net 30") to Net 30.
 type CreateSupplierRequest struct {
-	Name    string `json:"name" validate:"required"`
-	Email   string `json:"email" validate:"required,email"`
-	Address string `json:"address"`
+	Name         string `json:"name" validate:"required"`
+	Email        string `json:"email" validate:"required,email"`
+	Address      string `json:"address"`
+	Currency     string `json:"currency"`
+	PaymentTerms string `json:"paymentTerms"`
 }
 
 // UpdateSupplierRequest represents the request body for updating a supplier
+// Currency and PaymentTerms are kept when omitted; purchasings and invoices already created keep
+// their own currency and due dates. The currency can only change while nothing is priced in it.
 type UpdateSupplierRequest struct {
-	Name    string `json:"name" validate:"required"`
-	Email   string `json:"email" validate:"required,email"`
-	Address string `json:"address"`
+	Name         string `json:"name" validate:"required"`
+	Email        string `json:"email" validate:"required,email"`
+	Address      string `json:"address"`
+	Currency     string `json:"currency"`
+	PaymentTerms string `json:"paymentTerms"`
 }
 
-// GetAll retrieves all suppliers
+// normalizeSupplierCurrency upper-cases the currency, using def when it is empty
+// It returns "" when the currency is not a valid code.
+func normalizeSupplierCurrency(currency, def string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		currency = def
+	}
+	if !models.IsValidCurrencyCode(currency) {
+		return ""
+	}
+	return currency
+}
+
+// GetAll retrieves a page of suppliers
+// Supported filters: name and email (contains); see parseListParams for pagination and sorting
 func (sc *SupplierController) GetAll(c *fiber.Ctx) error {
-	suppliers, err := sc.supplierRepo.GetAll()
+	filter := repository.SupplierFilter{
+		Name:  c.Query("name"),
+		Email: c.Query("email"),
+	}
+
+	params, err := parseListParams(c, false)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve suppliers",
-		})
+		return err
+	}
+
+	suppliers, pageInfo, err := sc.supplierRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve suppliers")
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Suppliers retrieved successfully",
-		"data":    suppliers,
+		"message":    "Suppliers retrieved successfully",
+		"data":       suppliers,
+		"pagination": pageInfo,
 	})
 }
 
@@ -60,10 +93,27 @@ func (sc *SupplierController) Create(c *fiber.Ctx) error {
 		})
 	}
 
+	currency := normalizeSupplierCurrency(req.Currency, config.BaseCurrency)
+	if currency == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "currency must be a three-letter code such as USD",
+		})
+	}
+
 	supplier := models.Supplier{
-		Name:    req.Name,
-		Email:   req.Email,
-		Address: req.Address,
+		Name:     req.Name,
+		Email:    req.Email,
+		Address:  req.Address,
+		Currency: currency,
+	}
+
+	if req.PaymentTerms == "" {
+		req.PaymentTerms = models.DefaultPaymentTerms
+	}
+	if err := supplier.SetPaymentTerms(req.PaymentTerms); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
 	if err := sc.supplierRepo.Create(&supplier); err != nil {
@@ -102,11 +152,32 @@ func (sc *SupplierController) Update(c *fiber.Ctx) error {
 		})
 	}
 
+	currency := normalizeSupplierCurrency(req.Currency, supplier.Currency)
+	if currency == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "currency must be a three-letter code such as USD",
+		})
+	}
+
+	if req.PaymentTerms != "" {
+		if err := supplier.SetPaymentTerms(req.PaymentTerms); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	supplier.Name = req.Name
 	supplier.Email = req.Email
 	supplier.Address = req.Address
+	supplier.Currency = currency
 
 	if err := sc.supplierRepo.Update(supplier); err != nil {
+		if errors.Is(err, repository.ErrSupplierCurrencyInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot update supplier: " + err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update supplier",
 		})
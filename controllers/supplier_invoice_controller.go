@@ -0,0 +1,492 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// SupplierInvoiceController handles supplier invoice HTTP requests
+type SupplierInvoiceController struct {
+	invoiceRepo    *repository.SupplierInvoiceRepository
+	purchasingRepo *repository.PurchasingRepository
+}
+
+// NewSupplierInvoiceController creates a new SupplierInvoiceController instance
+func NewSupplierInvoiceController() *SupplierInvoiceController {
+	return &SupplierInvoiceController{
+		invoiceRepo:    repository.NewSupplierInvoiceRepository(),
+		purchasingRepo: repository.NewPurchasingRepository(),
+	}
+}
+
+// CreateSupplierInvoiceRequest represents the request body for entering a supplier invoice
+// invoiceDate is YYYY-MM-DD and defaults to today. taxAmount is the tax the supplier billed on
+// top of the lines; it is added to the total but not matched.
+type CreateSupplierInvoiceRequest struct {
+	PurchasingID  uint                       `json:"purchasingId" validate:"required"`
+	InvoiceNumber string                     `json:"invoiceNumber" validate:"required,max=50"`
+	InvoiceDate   string                     `json:"invoiceDate"`
+	TaxAmount     decimal.Decimal            `json:"taxAmount" validate:"min=0"`
+	Note          string                     `json:"note"`
+	Lines         []SupplierInvoiceLineInput `json:"lines" validate:"required,min=1,dive"`
+}
+
+// SupplierInvoiceLineInput represents the quantity and price billed for one purchasing detail
+// Both are in the detail's unit; unitPrice excludes tax.
+type SupplierInvoiceLineInput struct {
+	PurchasingDetailID uint            `json:"purchasingDetailId" validate:"required"`
+	Qty                int             `json:"qty" validate:"required,gt=0"`
+	UnitPrice          decimal.Decimal `json:"unitPrice" validate:"min=0"`
+}
+
+// validate checks the fields the struct tags describe and trims the invoice number
+func (req *CreateSupplierInvoiceRequest) validate() string {
+	req.InvoiceNumber = strings.TrimSpace(req.InvoiceNumber)
+	if req.PurchasingID == 0 {
+		return "purchasingId is required"
+	}
+	if req.InvoiceNumber == "" || len(req.InvoiceNumber) > 50 {
+		return "invoiceNumber is required and must be at most 50 characters"
+	}
+	if req.TaxAmount.IsNegative() {
+		return "taxAmount cannot be negative"
+	}
+	if len(req.Lines) == 0 {
+		return "At least one invoice line is required"
+	}
+	for _, line := range req.Lines {
+		if line.PurchasingDetailID == 0 || line.Qty <= 0 || line.UnitPrice.IsNegative() {
+			return "Each line needs a purchasingDetailId, a positive qty and a unitPrice of at least 0"
+		}
+	}
+	return ""
+}
+
+// InvoiceApprovalRequest represents the request body for approving a supplier invoice
+// acceptVariance must be true, with a comment, to approve an invoice whose prices are a variance.
+type InvoiceApprovalRequest struct {
+	Comment        string `json:"comment"`
+	AcceptVariance bool   `json:"acceptVariance"`
+}
+
+// SupplierPaymentRequest represents the request body for paying a supplier invoice
+// paymentDate is YYYY-MM-DD and defaults to today. With takeDiscount the early payment discount
+// is deducted and amount must be the balance less the discount.
+type SupplierPaymentRequest struct {
+	Amount       decimal.Decimal `json:"amount" validate:"required,gt=0"`
+	PaymentDate  string          `json:"paymentDate"`
+	TakeDiscount bool            `json:"takeDiscount"`
+	Reference    string          `json:"reference" validate:"max=100"`
+	Note         string          `json:"note"`
+}
+
+// GetAll retrieves a page of supplier invoices, newest first
+// Supported filters: supplierId, purchasingId, status, matchStatus, paymentStatus
+func (ic *SupplierInvoiceController) GetAll(c *fiber.Ctx) error {
+	filter := repository.SupplierInvoiceFilter{
+		Status:        c.Query("status"),
+		MatchStatus:   c.Query("matchStatus"),
+		PaymentStatus: c.Query("paymentStatus"),
+	}
+
+	if filter.MatchStatus != "" && !models.IsValidInvoiceMatchStatus(filter.MatchStatus) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "matchStatus must be one of matched, variance, blocked",
+		})
+	}
+
+	if filter.PaymentStatus != "" && !models.IsValidInvoicePaymentStatus(filter.PaymentStatus) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "paymentStatus must be one of unpaid, partially_paid, paid",
+		})
+	}
+
+	if v := c.Query("supplierId"); v != "" {
+		supplierID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid supplier ID",
+			})
+		}
+		filter.SupplierID = uint(supplierID)
+	}
+
+	if v := c.Query("purchasingId"); v != "" {
+		purchasingID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid purchasing ID",
+			})
+		}
+		filter.PurchasingID = uint(purchasingID)
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	invoices, pageInfo, err := ic.invoiceRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve invoices")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Invoices retrieved successfully",
+		"data":       invoices,
+		"pagination": pageInfo,
+	})
+}
+
+// GetByID retrieves a supplier invoice with its lines and their match results
+func (ic *SupplierInvoiceController) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid invoice ID",
+		})
+	}
+
+	invoice, err := ic.invoiceRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Invoice not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invoice retrieved successfully",
+		"data":    invoice,
+	})
+}
+
+// GetByPurchasing retrieves all invoices billed against a purchasing
+func (ic *SupplierInvoiceController) GetByPurchasing(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid purchasing ID",
+		})
+	}
+
+	if _, err := ic.purchasingRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchasing not found",
+		})
+	}
+
+	invoices, err := ic.invoiceRepo.GetByPurchasing(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve invoices",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invoices retrieved successfully",
+		"data":    invoices,
+	})
+}
+
+// Create enters a supplier invoice against a purchasing and matches it
+func (ic *SupplierInvoiceController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req CreateSupplierInvoiceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	invoiceDate, err := parseDateOrToday(req.InvoiceDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid invoiceDate, expected YYYY-MM-DD",
+		})
+	}
+
+	purchasing, err := ic.purchasingRepo.FindByID(req.PurchasingID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Purchasing not found",
+		})
+	}
+
+	lines := make([]models.SupplierInvoiceLine, 0, len(req.Lines))
+	for _, input := range req.Lines {
+		lines = append(lines, models.SupplierInvoiceLine{
+			PurchasingDetailID: input.PurchasingDetailID,
+			Qty:                input.Qty,
+			UnitPrice:          input.UnitPrice.Round(2),
+		})
+	}
+
+	invoice := models.SupplierInvoice{
+		PurchasingID:  purchasing.ID,
+		InvoiceNumber: req.InvoiceNumber,
+		InvoiceDate:   invoiceDate,
+		TaxAmount:     req.TaxAmount.Round(2),
+		Note:          req.Note,
+		CreatedBy:     userID,
+	}
+
+	if err := ic.invoiceRepo.CreateTransaction(&invoice, lines); err != nil {
+		return invoiceErrorResponse(c, err, "Failed to create invoice")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Invoice recorded with match status " + invoice.MatchStatus,
+		"data":    invoice,
+	})
+}
+
+// Match matches a pending invoice again against the purchasing and what has been received since
+func (ic *SupplierInvoiceController) Match(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid invoice ID",
+		})
+	}
+
+	invoice, err := ic.invoiceRepo.MatchTransaction(uint(id))
+	if err != nil {
+		return invoiceErrorResponse(c, err, "Failed to match invoice")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invoice matched with status " + invoice.MatchStatus,
+		"data":    invoice,
+	})
+}
+
+// Approve approves a pending invoice for payment
+// The invoice is matched again first; blocked invoices are refused, and variances must be accepted explicitly.
+func (ic *SupplierInvoiceController) Approve(c *fiber.Ctx) error {
+	id, userID, err := parseInvoiceParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req InvoiceApprovalRequest
+	if err := parseOptionalBody(c, &req); err != nil {
+		return err
+	}
+
+	invoice, err := ic.invoiceRepo.ApproveTransaction(id, userID, strings.TrimSpace(req.Comment), req.AcceptVariance)
+	if err != nil {
+		return invoiceErrorResponse(c, err, "Failed to approve invoice")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invoice approved for payment",
+		"data":    invoice,
+	})
+}
+
+// Reject rejects a pending invoice; a comment is required
+func (ic *SupplierInvoiceController) Reject(c *fiber.Ctx) error {
+	id, userID, err := parseInvoiceParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req ApprovalDecisionRequest
+	if err := c.BodyParser(&req); err != nil || req.Comment == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "A comment explaining the rejection is required",
+		})
+	}
+
+	invoice, err := ic.invoiceRepo.RejectTransaction(id, userID, req.Comment)
+	if err != nil {
+		return invoiceErrorResponse(c, err, "Failed to reject invoice")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invoice rejected",
+		"data":    invoice,
+	})
+}
+
+// RecordPayment records a full or partial payment of an approved invoice
+func (ic *SupplierInvoiceController) RecordPayment(c *fiber.Ctx) error {
+	id, userID, err := parseInvoiceParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req SupplierPaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if !req.Amount.IsPositive() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "amount must be greater than 0",
+		})
+	}
+	if len(req.Reference) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "reference must be at most 100 characters",
+		})
+	}
+
+	paymentDate, err := parseDateOrToday(req.PaymentDate)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid paymentDate, expected YYYY-MM-DD",
+		})
+	}
+	if paymentDate.After(time.Now()) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "paymentDate cannot be in the future",
+		})
+	}
+
+	payment := models.SupplierPayment{
+		InvoiceID:   id,
+		PaymentDate: paymentDate,
+		Amount:      req.Amount.Round(2),
+		Reference:   strings.TrimSpace(req.Reference),
+		Note:        req.Note,
+		CreatedBy:   userID,
+	}
+
+	invoice, err := ic.invoiceRepo.RecordPaymentTransaction(&payment, req.TakeDiscount)
+	if err != nil {
+		return invoiceErrorResponse(c, err, "Failed to record payment")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Payment recorded, invoice is " + invoice.PaymentStatus,
+		"data":    payment,
+		"invoice": invoice,
+	})
+}
+
+// GetPayments retrieves the payments made on an invoice
+func (ic *SupplierInvoiceController) GetPayments(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid invoice ID",
+		})
+	}
+
+	if _, err := ic.invoiceRepo.FindByID(uint(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Invoice not found",
+		})
+	}
+
+	payments, err := ic.invoiceRepo.GetPayments(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve payments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Payments retrieved successfully",
+		"data":    payments,
+	})
+}
+
+// parseDateOrToday parses a YYYY-MM-DD date, returning the start of today when it is empty
+func parseDateOrToday(value string) (time.Time, error) {
+	if value == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local), nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// parseInvoiceParams reads the invoice ID route parameter and the acting user
+func parseInvoiceParams(c *fiber.Ctx) (uint, uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return 0, 0, fiber.NewError(fiber.StatusBadRequest, "Invalid invoice ID")
+	}
+
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return 0, 0, fiber.NewError(fiber.StatusUnauthorized, "User ID not found in token")
+	}
+
+	return uint(id), userID, nil
+}
+
+// invoiceErrorResponse maps supplier invoice errors to HTTP responses
+func invoiceErrorResponse(c *fiber.Ctx, err error, fallback string) error {
+	var statusErr *repository.InvoiceStatusError
+	if errors.As(err, &statusErr) {
+		response := fiber.Map{
+			"error":         statusErr.Error(),
+			"currentStatus": statusErr.CurrentStatus,
+		}
+		if statusErr.PurchasingStatus != "" {
+			response["purchasingStatus"] = statusErr.PurchasingStatus
+		}
+		return c.Status(fiber.StatusConflict).JSON(response)
+	}
+	var lineErr *repository.InvoiceLineError
+	if errors.As(err, &lineErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":              lineErr.Error(),
+			"purchasingDetailId": lineErr.PurchasingDetailID,
+		})
+	}
+	var deniedErr *repository.ApprovalDeniedError
+	if errors.As(err, &deniedErr) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": deniedErr.Error(),
+		})
+	}
+	if errors.Is(err, repository.ErrInvoiceBlocked) || errors.Is(err, repository.ErrInvoiceVariance) ||
+		errors.Is(err, repository.ErrPaymentExceedsBalance) || errors.Is(err, repository.ErrEarlyDiscountUnavailable) ||
+		errors.Is(err, repository.ErrEarlyDiscountAmount) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, repository.ErrPurchasingNotInvoiceable) || errors.Is(err, repository.ErrInvoicePaid) ||
+		errors.Is(err, repository.ErrInvoiceNumberTaken) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Invoice not found",
+		})
+	}
+	log.Printf("%s: %v", fallback, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
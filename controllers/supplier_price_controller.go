@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+	"procurement-system/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// SupplierPriceController handles supplier price list HTTP requests
+type SupplierPriceController struct {
+	priceRepo    *repository.SupplierPriceRepository
+	supplierRepo *repository.SupplierRepository
+	itemRepo     *repository.ItemRepository
+}
+
+// NewSupplierPriceController creates a new SupplierPriceController instance
+func NewSupplierPriceController() *SupplierPriceController {
+	return &SupplierPriceController{
+		priceRepo:    repository.NewSupplierPriceRepository(),
+		supplierRepo: repository.NewSupplierRepository(),
+		itemRepo:     repository.NewItemRepository(),
+	}
+}
+
+// CreateSupplierPriceRequest represents the request body for adding a price list entry
+// Dates are YYYY-MM-DD; validTo is inclusive and may be omitted for a price without end.
+// minQty is the quantity break the price starts at (1 when omitted).
+type CreateSupplierPriceRequest struct {
+	ItemID    uint            `json:"itemId" validate:"required"`
+	MinQty    int             `json:"minQty" validate:"min=0"`
+	Price     decimal.Decimal `json:"price" validate:"required"`
+	ValidFrom string          `json:"validFrom" validate:"required"`
+	ValidTo   *string         `json:"validTo"`
+	Note      string          `json:"note"`
+}
+
+// GetBySupplier retrieves a page of a supplier's price list, latest first
+// Supported filters: itemId, activeOn (YYYY-MM-DD, entries valid that day)
+func (pc *SupplierPriceController) GetBySupplier(c *fiber.Ctx) error {
+	supplierID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid supplier ID",
+		})
+	}
+
+	if _, err := pc.supplierRepo.FindByID(uint(supplierID)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	filter, err := parseSupplierPriceFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.SupplierID = uint(supplierID)
+
+	return pc.respondWithList(c, filter)
+}
+
+// GetByItem retrieves a page of an item's price history over all suppliers, latest first
+// Supported filters: supplierId, activeOn (YYYY-MM-DD, entries valid that day)
+func (pc *SupplierPriceController) GetByItem(c *fiber.Ctx) error {
+	itemID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid item ID",
+		})
+	}
+
+	if _, err := pc.itemRepo.FindByID(uint(itemID)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+
+	filter, err := parseSupplierPriceFilter(c)
+	if err != nil {
+		return err
+	}
+	filter.ItemID = uint(itemID)
+
+	return pc.respondWithList(c, filter)
+}
+
+// Create adds an entry to a supplier's price list
+// A price without end replaces the supplier's current price of the item from validFrom on.
+func (pc *SupplierPriceController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	supplierID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid supplier ID",
+		})
+	}
+
+	if _, err := pc.supplierRepo.FindByID(uint(supplierID)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	var req CreateSupplierPriceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.MinQty == 0 {
+		req.MinQty = 1
+	}
+	if req.MinQty < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "minQty must be at least 1",
+		})
+	}
+	if req.Price.IsNegative() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "price cannot be negative",
+		})
+	}
+
+	validFrom, err := utils.ParseDate(req.ValidFrom)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid validFrom, use YYYY-MM-DD",
+		})
+	}
+
+	var validTo *time.Time
+	if req.ValidTo != nil && *req.ValidTo != "" {
+		to, err := utils.ParseDate(*req.ValidTo)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid validTo, use YYYY-MM-DD",
+			})
+		}
+		if to.Before(validFrom) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "validTo cannot be before validFrom",
+			})
+		}
+		validTo = &to
+	}
+
+	if _, err := pc.itemRepo.FindByID(req.ItemID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Item not found",
+		})
+	}
+
+	price := models.SupplierPrice{
+		SupplierID: uint(supplierID),
+		ItemID:     req.ItemID,
+		MinQty:     req.MinQty,
+		Price:      req.Price,
+		ValidFrom:  validFrom,
+		ValidTo:    validTo,
+		Note:       req.Note,
+		CreatedBy:  userID,
+	}
+
+	if err := pc.priceRepo.Create(&price); err != nil {
+		if errors.Is(err, repository.ErrSupplierPriceOverlap) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot add price: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create price",
+		})
+	}
+
+	created, err := pc.priceRepo.FindByID(price.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to reload price",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Price created successfully",
+		"data":    created,
+	})
+}
+
+// Delete removes a price list entry that has not taken effect yet
+func (pc *SupplierPriceController) Delete(c *fiber.Ctx) error {
+	supplierID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid supplier ID",
+		})
+	}
+	priceID, err := strconv.ParseUint(c.Params("priceId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid price ID",
+		})
+	}
+
+	price, err := pc.priceRepo.FindByID(uint(priceID))
+	if err != nil || price.SupplierID != uint(supplierID) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Price not found",
+		})
+	}
+
+	if err := pc.priceRepo.Delete(price.ID); err != nil {
+		if errors.Is(err, repository.ErrSupplierPriceInEffect) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot delete price: " + err.Error(),
+			})
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Price not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete price",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Price deleted successfully",
+	})
+}
+
+// respondWithList writes one page of price list entries matching the filter
+func (pc *SupplierPriceController) respondWithList(c *fiber.Ctx, filter repository.SupplierPriceFilter) error {
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	prices, pageInfo, err := pc.priceRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve prices")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Prices retrieved successfully",
+		"data":       prices,
+		"pagination": pageInfo,
+	})
+}
+
+// parseSupplierPriceFilter reads the price list filters shared by the supplier and item listings
+func parseSupplierPriceFilter(c *fiber.Ctx) (repository.SupplierPriceFilter, error) {
+	var filter repository.SupplierPriceFilter
+
+	if v := c.Query("itemId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid item ID")
+		}
+		filter.ItemID = uint(id)
+	}
+
+	if v := c.Query("supplierId"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid supplier ID")
+		}
+		filter.SupplierID = uint(id)
+	}
+
+	if v := c.Query("activeOn"); v != "" {
+		day, err := utils.ParseDate(v)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "Invalid activeOn, use YYYY-MM-DD")
+		}
+		filter.ActiveOn = &day
+	}
+
+	return filter, nil
+}
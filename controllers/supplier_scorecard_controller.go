@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"strconv"
+
+	"procurement-system/config"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxScorecardWindowDays caps how far back a scorecard may look
+const maxScorecardWindowDays = 730
+
+// SupplierScorecardController handles supplier performance scorecard HTTP requests
+type SupplierScorecardController struct {
+	scorecardRepo *repository.SupplierScorecardRepository
+	supplierRepo  *repository.SupplierRepository
+}
+
+// NewSupplierScorecardController creates a new SupplierScorecardController instance
+func NewSupplierScorecardController() *SupplierScorecardController {
+	return &SupplierScorecardController{
+		scorecardRepo: repository.NewSupplierScorecardRepository(),
+		supplierRepo:  repository.NewSupplierRepository(),
+	}
+}
+
+// GetAll ranks the suppliers active in the window by their overall score, best first
+// Query parameters: asOf (YYYY-MM-DD, default today), days (window length, default SCORECARD_WINDOW_DAYS)
+func (sc *SupplierScorecardController) GetAll(c *fiber.Ctx) error {
+	window, err := parseScorecardWindow(c)
+	if err != nil {
+		return err
+	}
+
+	scorecards, err := sc.scorecardRepo.Scorecards(window)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build supplier scorecards",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Supplier scorecards generated successfully",
+		"window":  scorecardWindowResponse(window),
+		"data":    scorecards,
+	})
+}
+
+// GetBySupplier retrieves one supplier's scorecard, with its rank among all suppliers
+// Query parameters: asOf (YYYY-MM-DD, default today), days (window length, default SCORECARD_WINDOW_DAYS)
+func (sc *SupplierScorecardController) GetBySupplier(c *fiber.Ctx) error {
+	supplierID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid supplier ID",
+		})
+	}
+
+	supplier, err := sc.supplierRepo.FindByID(uint(supplierID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Supplier not found",
+		})
+	}
+
+	window, err := parseScorecardWindow(c)
+	if err != nil {
+		return err
+	}
+
+	scorecard, err := sc.scorecardRepo.Scorecard(supplier, window)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build supplier scorecard",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Supplier scorecard generated successfully",
+		"window":  scorecardWindowResponse(window),
+		"data":    scorecard,
+	})
+}
+
+// parseScorecardWindow reads the asOf and days query parameters
+func parseScorecardWindow(c *fiber.Ctx) (repository.ScorecardWindow, error) {
+	asOf, err := parseDateOrToday(c.Query("asOf"))
+	if err != nil {
+		return repository.ScorecardWindow{}, fiber.NewError(fiber.StatusBadRequest, "Invalid asOf, use YYYY-MM-DD")
+	}
+
+	days := config.ScorecardWindowDays
+	if v := c.Query("days"); v != "" {
+		days, err = strconv.Atoi(v)
+		if err != nil || days < 1 || days > maxScorecardWindowDays {
+			return repository.ScorecardWindow{}, fiber.NewError(fiber.StatusBadRequest,
+				"Invalid days, use a number from 1 to "+strconv.Itoa(maxScorecardWindowDays))
+		}
+	}
+
+	return repository.ScorecardWindow{AsOf: asOf, Days: days}, nil
+}
+
+// scorecardWindowResponse describes the window of a scorecard response
+func scorecardWindowResponse(window repository.ScorecardWindow) fiber.Map {
+	return fiber.Map{
+		"from": window.AsOf.AddDate(0, 0, 1-window.Days).Format("2006-01-02"),
+		"to":   window.AsOf.Format("2006-01-02"),
+		"days": window.Days,
+	}
+}
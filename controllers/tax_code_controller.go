@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// TaxCodeController handles tax code HTTP requests
+type TaxCodeController struct {
+	taxCodeRepo *repository.TaxCodeRepository
+}
+
+// NewTaxCodeController creates a new TaxCodeController instance
+func NewTaxCodeController() *TaxCodeController {
+	return &TaxCodeController{
+		taxCodeRepo: repository.NewTaxCodeRepository(),
+	}
+}
+
+// TaxCodeRequest represents the request body for creating or updating a tax code
+// kind is vat (added to the amount payable) or withholding (withheld from it); rate is a percentage.
+// active defaults to true; inactive codes can no longer be put on purchasing lines.
+type TaxCodeRequest struct {
+	Code   string          `json:"code" validate:"required,max=20"`
+	Name   string          `json:"name" validate:"required,max=100"`
+	Kind   string          `json:"kind" validate:"required,oneof=vat withholding"`
+	Rate   decimal.Decimal `json:"rate" validate:"required"`
+	Active *bool           `json:"active"`
+}
+
+// validate checks the fields the struct tags describe and normalizes the code to upper case
+func (req *TaxCodeRequest) validate() string {
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Code == "" || len(req.Code) > 20 {
+		return "code is required and must be at most 20 characters"
+	}
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required and must be at most 100 characters"
+	}
+	if !models.IsValidTaxKind(req.Kind) {
+		return "kind must be vat or withholding"
+	}
+	if req.Rate.IsNegative() || req.Rate.GreaterThan(decimal.NewFromInt(100)) {
+		return "rate must be a percentage from 0 to 100"
+	}
+	return ""
+}
+
+// GetAll retrieves all tax codes
+// Supported filters: active (true for only the codes that can be used)
+func (tc *TaxCodeController) GetAll(c *fiber.Ctx) error {
+	codes, err := tc.taxCodeRepo.GetAll(c.Query("active") == "true")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve tax codes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Tax codes retrieved successfully",
+		"data":    codes,
+	})
+}
+
+// Create creates a new tax code
+func (tc *TaxCodeController) Create(c *fiber.Ctx) error {
+	var req TaxCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	// Check if code already exists
+	if existing, err := tc.taxCodeRepo.FindByCode(req.Code); err == nil && existing != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Tax code already exists",
+		})
+	}
+
+	now := time.Now()
+	code := models.TaxCode{
+		Code:      req.Code,
+		Name:      req.Name,
+		Kind:      req.Kind,
+		Rate:      req.Rate,
+		Active:    req.Active == nil || *req.Active,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := tc.taxCodeRepo.Create(&code); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create tax code",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Tax code created successfully",
+		"data":    code,
+	})
+}
+
+// Update updates an existing tax code
+// A new rate applies to purchasings created from now on; existing lines keep theirs.
+func (tc *TaxCodeController) Update(c *fiber.Ctx) error {
+	code, err := tc.findTaxCode(c)
+	if err != nil {
+		return err
+	}
+
+	var req TaxCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if existing, err := tc.taxCodeRepo.FindByCode(req.Code); err == nil && existing.ID != code.ID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Tax code already exists",
+		})
+	}
+
+	code.Code = req.Code
+	code.Name = req.Name
+	code.Kind = req.Kind
+	code.Rate = req.Rate
+	if req.Active != nil {
+		code.Active = *req.Active
+	}
+	code.UpdatedAt = time.Now()
+
+	if err := tc.taxCodeRepo.Update(code); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update tax code",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Tax code updated successfully",
+		"data":    code,
+	})
+}
+
+// Delete deletes a tax code that no item or purchasing uses
+func (tc *TaxCodeController) Delete(c *fiber.Ctx) error {
+	code, err := tc.findTaxCode(c)
+	if err != nil {
+		return err
+	}
+
+	if err := tc.taxCodeRepo.Delete(code.ID); err != nil {
+		if errors.Is(err, repository.ErrTaxCodeInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot delete tax code: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete tax code",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Tax code deleted successfully",
+	})
+}
+
+// findTaxCode loads the tax code in the route, returning a 400 or 404 fiber error
+func (tc *TaxCodeController) findTaxCode(c *fiber.Ctx) (*models.TaxCode, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid tax code ID")
+	}
+
+	code, err := tc.taxCodeRepo.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Tax code not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve tax code")
+	}
+	return code, nil
+}
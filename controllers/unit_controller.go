@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// UnitController handles unit of measure HTTP requests
+type UnitController struct {
+	unitRepo *repository.UnitRepository
+}
+
+// NewUnitController creates a new UnitController instance
+func NewUnitController() *UnitController {
+	return &UnitController{
+		unitRepo: repository.NewUnitRepository(),
+	}
+}
+
+// UnitRequest represents the request body for creating or updating a unit of measure
+type UnitRequest struct {
+	Code string `json:"code" validate:"required,max=20"`
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// validate checks the fields the struct tags describe and normalizes the code to upper case
+func (req *UnitRequest) validate() string {
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Code == "" || len(req.Code) > 20 {
+		return "code is required and must be at most 20 characters"
+	}
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required and must be at most 100 characters"
+	}
+	return ""
+}
+
+// GetAll retrieves all units of measure
+func (uc *UnitController) GetAll(c *fiber.Ctx) error {
+	units, err := uc.unitRepo.GetAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve units",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Units retrieved successfully",
+		"data":    units,
+	})
+}
+
+// Create creates a new unit of measure
+func (uc *UnitController) Create(c *fiber.Ctx) error {
+	var req UnitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	// Check if code already exists
+	if existing, err := uc.unitRepo.FindByCode(req.Code); err == nil && existing != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Unit code already exists",
+		})
+	}
+
+	now := time.Now()
+	unit := models.UnitOfMeasure{
+		Code:      req.Code,
+		Name:      req.Name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := uc.unitRepo.Create(&unit); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create unit",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Unit created successfully",
+		"data":    unit,
+	})
+}
+
+// Update updates an existing unit of measure
+func (uc *UnitController) Update(c *fiber.Ctx) error {
+	unit, err := uc.findUnit(c)
+	if err != nil {
+		return err
+	}
+
+	var req UnitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if existing, err := uc.unitRepo.FindByCode(req.Code); err == nil && existing.ID != unit.ID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Unit code already exists",
+		})
+	}
+
+	unit.Code = req.Code
+	unit.Name = req.Name
+	unit.UpdatedAt = time.Now()
+
+	if err := uc.unitRepo.Update(unit); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update unit",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Unit updated successfully",
+		"data":    unit,
+	})
+}
+
+// Delete deletes a unit of measure that no item or purchasing uses
+func (uc *UnitController) Delete(c *fiber.Ctx) error {
+	unit, err := uc.findUnit(c)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.unitRepo.Delete(unit.ID); err != nil {
+		if errors.Is(err, repository.ErrUnitInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot delete unit: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete unit",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Unit deleted successfully",
+	})
+}
+
+// findUnit loads the unit in the route, returning a 400 or 404 fiber error
+func (uc *UnitController) findUnit(c *fiber.Ctx) (*models.UnitOfMeasure, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid unit ID")
+	}
+
+	unit, err := uc.unitRepo.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Unit not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve unit")
+	}
+	return unit, nil
+}
@@ -53,6 +53,7 @@ type LoginResponse struct {
 }
 
 // Register handles user registration
+// Used both by the public /register route and by admins creating accounts via /users
 func (uc *UserController) Register(c *fiber.Ctx) error {
 	var req RegisterRequest
 
@@ -62,6 +63,29 @@ func (uc *UserController) Register(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.Role != models.RoleAdmin && req.Role != models.RoleStaff {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Role must be admin or staff",
+		})
+	}
+
+	// Only the very first admin may self-register; after that admins are created by an admin
+	if req.Role == models.RoleAdmin {
+		if callerRole, _ := c.Locals("role").(string); callerRole != models.RoleAdmin {
+			admins, err := uc.userRepo.CountByRole(models.RoleAdmin)
+			if err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to create user",
+				})
+			}
+			if admins > 0 {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Admin accounts can only be created by an existing admin",
+				})
+			}
+		}
+	}
+
 	// Check if username already exists
 	existingUser, err := uc.userRepo.FindByUsername(req.Username)
 	if err == nil && existingUser != nil {
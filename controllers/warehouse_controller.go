@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// WarehouseController handles warehouse HTTP requests
+type WarehouseController struct {
+	warehouseRepo *repository.WarehouseRepository
+}
+
+// NewWarehouseController creates a new WarehouseController instance
+func NewWarehouseController() *WarehouseController {
+	return &WarehouseController{
+		warehouseRepo: repository.NewWarehouseRepository(),
+	}
+}
+
+// WarehouseRequest represents the request body for creating or updating a warehouse
+// Setting isDefault moves the default to this warehouse.
+type WarehouseRequest struct {
+	Code      string `json:"code" validate:"required,max=20"`
+	Name      string `json:"name" validate:"required,max=100"`
+	Address   string `json:"address"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// validate checks the fields the struct tags describe and normalizes the code to upper case
+func (req *WarehouseRequest) validate() string {
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Code == "" || len(req.Code) > 20 {
+		return "code is required and must be at most 20 characters"
+	}
+	if req.Name == "" || len(req.Name) > 100 {
+		return "name is required and must be at most 100 characters"
+	}
+	return ""
+}
+
+// GetAll retrieves all warehouses
+func (wc *WarehouseController) GetAll(c *fiber.Ctx) error {
+	warehouses, err := wc.warehouseRepo.GetAll()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve warehouses",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Warehouses retrieved successfully",
+		"data":    warehouses,
+	})
+}
+
+// GetByID retrieves a warehouse by ID
+func (wc *WarehouseController) GetByID(c *fiber.Ctx) error {
+	warehouse, err := wc.findWarehouse(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Warehouse retrieved successfully",
+		"data":    warehouse,
+	})
+}
+
+// Create creates a new warehouse
+func (wc *WarehouseController) Create(c *fiber.Ctx) error {
+	var req WarehouseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	// Check if code already exists
+	if existing, err := wc.warehouseRepo.FindByCode(req.Code); err == nil && existing != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Warehouse code already exists",
+		})
+	}
+
+	now := time.Now()
+	warehouse := models.Warehouse{
+		Code:      req.Code,
+		Name:      req.Name,
+		Address:   req.Address,
+		IsDefault: req.IsDefault,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := wc.warehouseRepo.Create(&warehouse); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create warehouse",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Warehouse created successfully",
+		"data":    warehouse,
+	})
+}
+
+// Update updates an existing warehouse
+func (wc *WarehouseController) Update(c *fiber.Ctx) error {
+	warehouse, err := wc.findWarehouse(c)
+	if err != nil {
+		return err
+	}
+
+	var req WarehouseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if existing, err := wc.warehouseRepo.FindByCode(req.Code); err == nil && existing.ID != warehouse.ID {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Warehouse code already exists",
+		})
+	}
+
+	warehouse.Code = req.Code
+	warehouse.Name = req.Name
+	warehouse.Address = req.Address
+	warehouse.IsDefault = req.IsDefault
+	warehouse.UpdatedAt = time.Now()
+
+	if err := wc.warehouseRepo.Update(warehouse); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update warehouse",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Warehouse updated successfully",
+		"data":    warehouse,
+	})
+}
+
+// Delete deletes a warehouse that is not the default, holds no stock and is named on no document
+func (wc *WarehouseController) Delete(c *fiber.Ctx) error {
+	warehouse, err := wc.findWarehouse(c)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.warehouseRepo.Delete(warehouse.ID); err != nil {
+		if errors.Is(err, repository.ErrWarehouseInUse) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Cannot delete warehouse: " + err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete warehouse",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Warehouse deleted successfully",
+	})
+}
+
+// findWarehouse loads the warehouse in the route, returning a 400 or 404 fiber error
+func (wc *WarehouseController) findWarehouse(c *fiber.Ctx) (*models.Warehouse, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid warehouse ID")
+	}
+
+	warehouse, err := wc.warehouseRepo.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Warehouse not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve warehouse")
+	}
+	return warehouse, nil
+}
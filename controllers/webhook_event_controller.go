@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"strconv"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WebhookEventController handles webhook outbox HTTP requests
+type WebhookEventController struct {
+	eventRepo        *repository.WebhookEventRepository
+	subscriptionRepo *repository.WebhookSubscriptionRepository
+}
+
+// NewWebhookEventController creates a new WebhookEventController instance
+func NewWebhookEventController() *WebhookEventController {
+	return &WebhookEventController{
+		eventRepo:        repository.NewWebhookEventRepository(),
+		subscriptionRepo: repository.NewWebhookSubscriptionRepository(),
+	}
+}
+
+// GetAll retrieves a page of webhook events, newest first
+// Supported filters: status (pending, delivered, dead), eventType and subscriptionId
+func (wc *WebhookEventController) GetAll(c *fiber.Ctx) error {
+	filter := repository.WebhookEventFilter{
+		Status:    c.Query("status"),
+		EventType: c.Query("eventType"),
+	}
+	switch filter.Status {
+	case "", models.WebhookEventStatusPending, models.WebhookEventStatusDelivered, models.WebhookEventStatusDead:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid status",
+		})
+	}
+
+	if v := c.Query("subscriptionId"); v != "" {
+		subscriptionID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid subscription ID",
+			})
+		}
+		filter.SubscriptionID = uint(subscriptionID)
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	events, pageInfo, err := wc.eventRepo.List(filter, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve webhook events")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Webhook events retrieved successfully",
+		"data":       events,
+		"pagination": pageInfo,
+	})
+}
+
+// Replay queues a dead-lettered or delivered webhook event for delivery again
+func (wc *WebhookEventController) Replay(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid webhook event ID",
+		})
+	}
+
+	event, err := wc.eventRepo.FindByID(uint(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Webhook event not found",
+		})
+	}
+
+	if event.Status == models.WebhookEventStatusPending {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":         "Webhook event is already queued for delivery",
+			"currentStatus": event.Status,
+		})
+	}
+
+	// Events of deleted subscriptions have nowhere to go
+	if event.SubscriptionID != nil {
+		if _, err := wc.subscriptionRepo.FindByID(*event.SubscriptionID); err != nil {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Webhook subscription of this event no longer exists",
+			})
+		}
+	}
+
+	if err := wc.eventRepo.Replay(event); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to replay webhook event",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook event queued for replay",
+		"data":    event,
+	})
+}
@@ -0,0 +1,352 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"procurement-system/models"
+	"procurement-system/repository"
+	"procurement-system/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// WebhookSubscriptionController handles webhook subscription HTTP requests
+type WebhookSubscriptionController struct {
+	subscriptionRepo *repository.WebhookSubscriptionRepository
+	deliveryRepo     *repository.WebhookDeliveryRepository
+}
+
+// NewWebhookSubscriptionController creates a new WebhookSubscriptionController instance
+func NewWebhookSubscriptionController() *WebhookSubscriptionController {
+	return &WebhookSubscriptionController{
+		subscriptionRepo: repository.NewWebhookSubscriptionRepository(),
+		deliveryRepo:     repository.NewWebhookDeliveryRepository(),
+	}
+}
+
+// WebhookSubscriptionRequest represents the request body for creating or updating a subscription
+// Secret is optional: on create a random secret is generated when empty, on update an empty
+// secret keeps the current one.
+type WebhookSubscriptionRequest struct {
+	Name   string   `json:"name" validate:"required"`
+	URL    string   `json:"url" validate:"required,url"`
+	Events []string `json:"events" validate:"required,min=1"`
+	Secret string   `json:"secret"`
+}
+
+// validate checks the fields the struct tags describe and removes duplicate events
+func (req *WebhookSubscriptionRequest) validate() string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "name is required"
+	}
+
+	if err := utils.ValidateWebhookURL(req.URL); err != nil {
+		return "Invalid url: " + err.Error()
+	}
+
+	if len(req.Events) == 0 {
+		return "events must contain at least one event type"
+	}
+	seen := make(map[string]bool, len(req.Events))
+	events := req.Events[:0]
+	for _, event := range req.Events {
+		if !models.IsValidWebhookEventType(event) {
+			return "Unknown event type: " + event
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	req.Events = events
+
+	return ""
+}
+
+// subscriptionEvents converts the requested event types into subscription event rows
+func (req *WebhookSubscriptionRequest) subscriptionEvents() []models.WebhookSubscriptionEvent {
+	events := make([]models.WebhookSubscriptionEvent, len(req.Events))
+	for i, event := range req.Events {
+		events[i].EventType = event
+	}
+	return events
+}
+
+// generateWebhookSecret returns a random signing secret for a new subscription
+func generateWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(raw), nil
+}
+
+// GetEventTypes lists the event types subscriptions can choose from
+func (wc *WebhookSubscriptionController) GetEventTypes(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"message": "Webhook event types retrieved successfully",
+		"data":    models.WebhookEventTypes,
+	})
+}
+
+// GetAll retrieves a page of webhook subscriptions
+// Supported filters: paused (true or false)
+func (wc *WebhookSubscriptionController) GetAll(c *fiber.Ctx) error {
+	var paused *bool
+	if v := c.Query("paused"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid paused",
+			})
+		}
+		paused = &parsed
+	}
+
+	params, err := parseListParams(c, false)
+	if err != nil {
+		return err
+	}
+
+	subscriptions, pageInfo, err := wc.subscriptionRepo.List(paused, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve webhook subscriptions")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Webhook subscriptions retrieved successfully",
+		"data":       subscriptions,
+		"pagination": pageInfo,
+	})
+}
+
+// GetByID retrieves a webhook subscription by ID
+func (wc *WebhookSubscriptionController) GetByID(c *fiber.Ctx) error {
+	subscription, err := wc.findSubscription(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook subscription retrieved successfully",
+		"data":    subscription,
+	})
+}
+
+// Create creates a new webhook subscription
+// The signing secret is only returned in this response.
+func (wc *WebhookSubscriptionController) Create(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User ID not found in token",
+		})
+	}
+
+	var req WebhookSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to generate webhook secret",
+			})
+		}
+		secret = generated
+	}
+
+	now := time.Now()
+	subscription := models.WebhookSubscription{
+		Name:      req.Name,
+		URL:       req.URL,
+		Secret:    secret,
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Events:    req.subscriptionEvents(),
+	}
+
+	if err := wc.subscriptionRepo.Create(&subscription); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create webhook subscription",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Webhook subscription created successfully",
+		"data":    subscription,
+		"secret":  secret,
+	})
+}
+
+// Update updates the name, URL, event types and optionally the secret of a subscription
+// Events already queued keep their payload but are delivered to the new URL with the new secret.
+func (wc *WebhookSubscriptionController) Update(c *fiber.Ctx) error {
+	subscription, err := wc.findSubscription(c)
+	if err != nil {
+		return err
+	}
+
+	var req WebhookSubscriptionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	subscription.Name = req.Name
+	subscription.URL = req.URL
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	subscription.UpdatedAt = time.Now()
+
+	if err := wc.subscriptionRepo.UpdateTransaction(subscription, req.subscriptionEvents()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update webhook subscription",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook subscription updated successfully",
+		"data":    subscription,
+	})
+}
+
+// Delete deletes a webhook subscription
+// Its undelivered events are dead-lettered; the delivery history is kept.
+func (wc *WebhookSubscriptionController) Delete(c *fiber.Ctx) error {
+	subscription, err := wc.findSubscription(c)
+	if err != nil {
+		return err
+	}
+
+	if err := wc.subscriptionRepo.DeleteTransaction(subscription.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete webhook subscription",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Webhook subscription deleted successfully",
+	})
+}
+
+// Pause stops queueing new events for a subscription and holds its queued events
+func (wc *WebhookSubscriptionController) Pause(c *fiber.Ctx) error {
+	return wc.setPaused(c, true)
+}
+
+// Resume restarts deliveries to a paused subscription, including the events held while paused
+func (wc *WebhookSubscriptionController) Resume(c *fiber.Ctx) error {
+	return wc.setPaused(c, false)
+}
+
+// GetDeliveries retrieves a page of delivery attempts for a subscription, newest first
+// Each attempt records the response status code and latency.
+// Supported filters: success (true or false)
+func (wc *WebhookSubscriptionController) GetDeliveries(c *fiber.Ctx) error {
+	subscription, err := wc.findSubscription(c)
+	if err != nil {
+		return err
+	}
+
+	var success *bool
+	if v := c.Query("success"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid success",
+			})
+		}
+		success = &parsed
+	}
+
+	params, err := parseListParams(c, true)
+	if err != nil {
+		return err
+	}
+
+	deliveries, pageInfo, err := wc.deliveryRepo.ListBySubscription(subscription.ID, success, params)
+	if err != nil {
+		return listErrorResponse(c, err, "Failed to retrieve webhook deliveries")
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Webhook deliveries retrieved successfully",
+		"data":       deliveries,
+		"pagination": pageInfo,
+	})
+}
+
+// setPaused pauses or resumes the subscription in the route
+func (wc *WebhookSubscriptionController) setPaused(c *fiber.Ctx, paused bool) error {
+	subscription, err := wc.findSubscription(c)
+	if err != nil {
+		return err
+	}
+
+	if subscription.Paused == paused {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  "Webhook subscription is already in that state",
+			"paused": subscription.Paused,
+		})
+	}
+
+	if err := wc.subscriptionRepo.SetPaused(subscription, paused); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update webhook subscription",
+		})
+	}
+
+	message := "Webhook subscription resumed"
+	if paused {
+		message = "Webhook subscription paused"
+	}
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    subscription,
+	})
+}
+
+// findSubscription loads the subscription in the route, returning a 400 or 404 fiber error
+func (wc *WebhookSubscriptionController) findSubscription(c *fiber.Ctx) (*models.WebhookSubscription, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid webhook subscription ID")
+	}
+
+	subscription, err := wc.subscriptionRepo.FindByID(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fiber.NewError(fiber.StatusNotFound, "Webhook subscription not found")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "Failed to retrieve webhook subscription")
+	}
+	return subscription, nil
+}
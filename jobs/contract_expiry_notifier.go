@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/repository"
+)
+
+// ContractExpiryNotifier periodically publishes a contract.expiring webhook event for every
+// supplier contract that entered its alert period
+// Each contract is alerted once; extending it re-arms the alert.
+type ContractExpiryNotifier struct {
+	contractRepo *repository.ContractRepository
+}
+
+// NewContractExpiryNotifier creates a new ContractExpiryNotifier instance
+func NewContractExpiryNotifier() *ContractExpiryNotifier {
+	return &ContractExpiryNotifier{
+		contractRepo: repository.NewContractRepository(),
+	}
+}
+
+// Start runs once right away, then every ContractAlertInterval in a background goroutine
+func (n *ContractExpiryNotifier) Start() {
+	go func() {
+		n.RunOnce()
+
+		ticker := time.NewTicker(config.ContractAlertInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			n.RunOnce()
+		}
+	}()
+	log.Printf("Contract expiry notifier started (interval %s)", config.ContractAlertInterval)
+}
+
+// RunOnce alerts on the contracts that entered their alert period since the last run
+func (n *ContractExpiryNotifier) RunOnce() {
+	alerted, err := n.contractRepo.NotifyExpiringTransaction(time.Now())
+	if err != nil {
+		log.Printf("Contract expiry notifier: failed to alert: %v", err)
+		return
+	}
+
+	for _, expiry := range alerted {
+		log.Printf("Contract expiry notifier: contract %d (%s) with supplier %d expires in %d day(s)",
+			expiry.Contract.ID, expiry.Contract.Reference, expiry.Contract.SupplierID, expiry.DaysLeft)
+	}
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/repository"
+
+	"gorm.io/gorm"
+)
+
+// ReorderPlanner periodically drafts purchasings for items that have fallen to their reorder point
+// It only drafts; a person still reviews, submits and approves every purchasing.
+type ReorderPlanner struct {
+	reorderRepo *repository.ReorderRepository
+	userRepo    *repository.UserRepository
+	webhookRepo *repository.WebhookEventRepository
+}
+
+// NewReorderPlanner creates a new ReorderPlanner instance
+func NewReorderPlanner() *ReorderPlanner {
+	return &ReorderPlanner{
+		reorderRepo: repository.NewReorderRepository(),
+		userRepo:    repository.NewUserRepository(),
+		webhookRepo: repository.NewWebhookEventRepository(),
+	}
+}
+
+// Start plans every ReorderInterval in a background goroutine
+func (p *ReorderPlanner) Start() {
+	go func() {
+		ticker := time.NewTicker(config.ReorderInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			p.RunOnce()
+		}
+	}()
+	log.Printf("Reorder planner started (interval %s)", config.ReorderInterval)
+}
+
+// RunOnce drafts one purchasing per supplier for the items that need reordering and logs them
+func (p *ReorderPlanner) RunOnce() {
+	user, err := p.findCreator()
+	if err != nil {
+		log.Printf("Reorder planner: no user to create drafts as (set REORDER_USER_ID): %v", err)
+		return
+	}
+
+	run, err := p.reorderRepo.CreateDraftsTransaction(user.ID, func(tx *gorm.DB, purchasing *models.Purchasing) error {
+		return p.webhookRepo.PublishPurchasingWithTx(tx, models.WebhookEventPurchasingCreated, config.WebhookURL, purchasing.ID)
+	})
+	if err != nil {
+		log.Printf("Reorder planner: failed to draft purchasings: %v", err)
+		return
+	}
+
+	for _, draft := range run.Drafts {
+		log.Printf("Reorder planner: drafted purchasing %d for supplier %d with %d line(s)",
+			draft.ID, draft.SupplierID, len(draft.PurchasingDetails))
+	}
+	for _, skip := range run.Skipped {
+		log.Printf("Reorder planner: skipped supplier %d: %s", skip.SupplierID, skip.Reason)
+	}
+}
+
+// findCreator returns the user configured with REORDER_USER_ID, or the oldest admin
+func (p *ReorderPlanner) findCreator() (*models.User, error) {
+	if config.ReorderUserID != 0 {
+		return p.userRepo.FindByID(config.ReorderUserID)
+	}
+	return p.userRepo.FindFirstByRole(models.RoleAdmin)
+}
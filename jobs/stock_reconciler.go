@@ -0,0 +1,67 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/repository"
+)
+
+// StockReconciler periodically checks that every item's stock, and every warehouse balance,
+// equals the sum of its stock ledger
+// It only reports discrepancies; fixing them is left to a person, since the cause matters.
+type StockReconciler struct {
+	movementRepo *repository.StockMovementRepository
+}
+
+// NewStockReconciler creates a new StockReconciler instance
+func NewStockReconciler() *StockReconciler {
+	return &StockReconciler{
+		movementRepo: repository.NewStockMovementRepository(),
+	}
+}
+
+// Start reconciles every StockReconcileInterval in a background goroutine
+func (r *StockReconciler) Start() {
+	go func() {
+		ticker := time.NewTicker(config.StockReconcileInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			r.RunOnce()
+		}
+	}()
+	log.Printf("Stock reconciler started (interval %s)", config.StockReconcileInterval)
+}
+
+// RunOnce compares item stock with the ledger and logs every discrepancy
+func (r *StockReconciler) RunOnce() {
+	discrepancies, err := r.movementRepo.FindDiscrepancies()
+	if err != nil {
+		log.Printf("Stock reconciler: failed to reconcile: %v", err)
+		return
+	}
+
+	for _, d := range discrepancies {
+		log.Printf("Stock reconciler: item %d (%s) has stock %d but its ledger sums to %d (difference %d)",
+			d.ItemID, d.ItemName, d.Stock, d.LedgerStock, d.Difference)
+	}
+	if len(discrepancies) > 0 {
+		log.Printf("Stock reconciler: %d item(s) do not match the stock ledger", len(discrepancies))
+	}
+
+	warehouseDiscrepancies, err := r.movementRepo.FindWarehouseDiscrepancies()
+	if err != nil {
+		log.Printf("Stock reconciler: failed to reconcile warehouse stock: %v", err)
+		return
+	}
+
+	for _, d := range warehouseDiscrepancies {
+		log.Printf("Stock reconciler: item %d has %d in warehouse %d but its ledger there sums to %d (difference %d)",
+			d.ItemID, d.Quantity, d.WarehouseID, d.LedgerQuantity, d.Difference)
+	}
+	if len(warehouseDiscrepancies) > 0 {
+		log.Printf("Stock reconciler: %d warehouse balance(s) do not match the stock ledger", len(warehouseDiscrepancies))
+	}
+}
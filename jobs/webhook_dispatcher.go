@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/repository"
+	"procurement-system/utils"
+	"procurement-system/webhooksig"
+
+	"gorm.io/gorm"
+)
+
+// dispatchBatchSize is the maximum number of events delivered per polling round
+const dispatchBatchSize = 50
+
+// dispatchLease is how long a claimed event is hidden from other dispatchers while being delivered
+const dispatchLease = time.Minute
+
+// WebhookDispatcher delivers webhook events from the outbox in the background
+type WebhookDispatcher struct {
+	eventRepo        *repository.WebhookEventRepository
+	subscriptionRepo *repository.WebhookSubscriptionRepository
+	deliveryRepo     *repository.WebhookDeliveryRepository
+}
+
+// NewWebhookDispatcher creates a new WebhookDispatcher instance
+func NewWebhookDispatcher() *WebhookDispatcher {
+	return &WebhookDispatcher{
+		eventRepo:        repository.NewWebhookEventRepository(),
+		subscriptionRepo: repository.NewWebhookSubscriptionRepository(),
+		deliveryRepo:     repository.NewWebhookDeliveryRepository(),
+	}
+}
+
+// Start polls the outbox every WebhookDispatchInterval in a background goroutine
+func (d *WebhookDispatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(config.WebhookDispatchInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			d.RunOnce()
+		}
+	}()
+	log.Printf("Webhook dispatcher started (interval %s)", config.WebhookDispatchInterval)
+}
+
+// RunOnce delivers every event that is currently due
+func (d *WebhookDispatcher) RunOnce() {
+	events, err := d.eventRepo.FindDue(dispatchBatchSize)
+	if err != nil {
+		log.Printf("Webhook dispatcher: failed to load due events: %v", err)
+		return
+	}
+
+	for i := range events {
+		claimed, err := d.eventRepo.Claim(&events[i], dispatchLease)
+		if err != nil {
+			log.Printf("Webhook dispatcher: failed to claim event %d: %v", events[i].ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		d.deliver(&events[i])
+	}
+}
+
+// deliver sends one event and records the outcome, scheduling a retry or dead-lettering on failure
+// Subscription events go to the subscription's current URL, signed with its own secret;
+// other events go to the URL they were queued with, signed with WEBHOOK_SECRET.
+func (d *WebhookDispatcher) deliver(event *models.WebhookEvent) {
+	targetURL, secret := event.TargetURL, config.WebhookSecret
+	if event.SubscriptionID != nil {
+		subscription, err := d.subscriptionRepo.FindByID(*event.SubscriptionID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			// Leave the event claimed; it becomes due again when the lease expires
+			log.Printf("Webhook dispatcher: failed to load subscription of event %d: %v", event.ID, err)
+			return
+		}
+		if err != nil {
+			log.Printf("Webhook event %d: subscription %d not found, moved to dead letter", event.ID, *event.SubscriptionID)
+			if err := d.eventRepo.MarkFailed(event, 0, "subscription not found", nil); err != nil {
+				log.Printf("Webhook dispatcher: failed to record failure of event %d: %v", event.ID, err)
+			}
+			return
+		}
+		targetURL, secret = subscription.URL, subscription.Secret
+	}
+
+	body := []byte(event.Payload)
+	headers := signatureHeaders(event, body, secret)
+	started := time.Now()
+	statusCode, err := utils.DeliverWebhook(targetURL, body, headers)
+	d.recordDelivery(event, headers[webhooksig.HeaderDeliveryID], statusCode, time.Since(started), err, started)
+
+	if err == nil {
+		if err := d.eventRepo.MarkDelivered(event, statusCode); err != nil {
+			log.Printf("Webhook dispatcher: failed to mark event %d delivered: %v", event.ID, err)
+		}
+		return
+	}
+
+	attempt := event.Attempts + 1
+	var nextAttempt *time.Time
+	if errors.Is(err, utils.ErrWebhookTargetNotAllowed) {
+		// Retrying cannot help until the target or the restrictions change; replay afterwards
+		log.Printf("Webhook event %d refused, moved to dead letter: %v", event.ID, err)
+	} else if attempt < config.WebhookMaxAttempts {
+		next := time.Now().Add(retryDelay(attempt))
+		nextAttempt = &next
+		log.Printf("Webhook event %d attempt %d failed, retrying at %s: %v", event.ID, attempt, next.Format(time.RFC3339), err)
+	} else {
+		log.Printf("Webhook event %d failed %d times, moved to dead letter: %v", event.ID, attempt, err)
+	}
+
+	if err := d.eventRepo.MarkFailed(event, statusCode, err.Error(), nextAttempt); err != nil {
+		log.Printf("Webhook dispatcher: failed to record failure of event %d: %v", event.ID, err)
+	}
+}
+
+// recordDelivery adds the attempt to the delivery history
+func (d *WebhookDispatcher) recordDelivery(event *models.WebhookEvent, deliveryID string, statusCode int, latency time.Duration, deliveryErr error, attemptedAt time.Time) {
+	delivery := models.WebhookDelivery{
+		EventID:        event.ID,
+		SubscriptionID: event.SubscriptionID,
+		DeliveryID:     deliveryID,
+		EventType:      event.EventType,
+		Attempt:        event.Attempts + 1,
+		Success:        deliveryErr == nil,
+		StatusCode:     statusCode,
+		LatencyMs:      latency.Milliseconds(),
+		AttemptedAt:    attemptedAt,
+	}
+	if deliveryErr != nil {
+		delivery.Error = deliveryErr.Error()
+	}
+	if err := d.deliveryRepo.Create(&delivery); err != nil {
+		log.Printf("Webhook dispatcher: failed to record delivery of event %d: %v", event.ID, err)
+	}
+}
+
+// signatureHeaders builds the delivery ID, timestamp and HMAC signature headers for an attempt
+// The timestamp is taken per attempt, so retries carry a fresh signature receivers will accept.
+func signatureHeaders(event *models.WebhookEvent, body []byte, secret string) map[string]string {
+	deliveryID := event.DeliveryID
+	if deliveryID == "" {
+		// Events queued before delivery IDs existed
+		deliveryID = fmt.Sprintf("event-%d", event.ID)
+	}
+
+	timestamp := time.Now().Unix()
+	headers := map[string]string{
+		webhooksig.HeaderDeliveryID: deliveryID,
+		webhooksig.HeaderTimestamp:  strconv.FormatInt(timestamp, 10),
+	}
+	if secret != "" {
+		headers[webhooksig.HeaderSignature] = webhooksig.Sign(secret, timestamp, body)
+	}
+	return headers
+}
+
+// retryDelay returns the wait before the next attempt after the given number of failed attempts
+// The delay doubles with every attempt up to WebhookRetryMax, and is jittered to a random value
+// between half and the full delay so failing receivers are not hit by synchronized retries.
+func retryDelay(attempt int) time.Duration {
+	delay := config.WebhookRetryBase
+	for i := 1; i < attempt && delay < config.WebhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > config.WebhookRetryMax {
+		delay = config.WebhookRetryMax
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -5,8 +5,11 @@ import (
 	"os"
 
 	"procurement-system/config"
+	"procurement-system/jobs"
 	"procurement-system/models"
+	"procurement-system/repository"
 	"procurement-system/routes"
+	"procurement-system/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -17,6 +20,13 @@ func main() {
 	// Load environment variables
 	config.LoadEnv()
 
+	// Deliveries to a refused WEBHOOK_URL are dead-lettered, so say so at startup
+	if config.WebhookURL != "" {
+		if err := utils.ValidateWebhookURL(config.WebhookURL); err != nil {
+			log.Printf("Warning: WEBHOOK_URL is refused by the webhook restrictions: %v", err)
+		}
+	}
+
 	// Initialize database
 	if err := config.InitDB(); err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
@@ -27,6 +37,20 @@ func main() {
 		log.Fatalf("Failed to auto migrate: %v", err)
 	}
 
+	// Deliver queued webhook events in the background
+	jobs.NewWebhookDispatcher().Start()
+
+	// Check item stock against the stock ledger in the background
+	jobs.NewStockReconciler().Start()
+
+	// Draft purchasings for items at their reorder point in the background
+	if config.ReorderAutoDraft {
+		jobs.NewReorderPlanner().Start()
+	}
+
+	// Alert on supplier contracts about to expire in the background
+	jobs.NewContractExpiryNotifier().Start()
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -42,7 +66,7 @@ func main() {
 
 	// Middleware
 	app.Use(logger.New())
-	
+
 	// CORS middleware - allow all origins for development
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
@@ -81,13 +105,88 @@ func getServerAddr() string {
 }
 
 func autoMigrate() error {
-	return config.DB.AutoMigrate(
+	err := config.DB.AutoMigrate(
 		&models.User{},
+		&models.Warehouse{},
+		&models.UnitOfMeasure{},
 		&models.Item{},
+		&models.ItemUnit{},
+		&models.WarehouseStock{},
 		&models.Supplier{},
+		&models.Department{},
+		&models.Budget{},
 		&models.Purchasing{},
 		&models.PurchasingDetail{},
+		&models.PurchasingStatusHistory{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptLine{},
+		&models.ApprovalRule{},
+		&models.PurchasingApproval{},
+		&models.WebhookEvent{},
+		&models.WebhookSubscription{},
+		&models.WebhookSubscriptionEvent{},
+		&models.WebhookDelivery{},
+		&models.StockMovement{},
+		&models.StockAdjustment{},
+		&models.CycleCount{},
+		&models.CycleCountLine{},
+		&models.StockTransfer{},
+		&models.StockTransferLine{},
+		&models.SupplierPrice{},
+		&models.ExchangeRate{},
+		&models.TaxCode{},
+		&models.SupplierInvoice{},
+		&models.SupplierInvoiceLine{},
+		&models.SupplierPayment{},
+		&models.PurchaseRequisition{},
+		&models.PurchaseRequisitionLine{},
+		&models.RFQ{},
+		&models.RFQLine{},
+		&models.RFQSupplier{},
+		&models.RFQQuote{},
+		&models.RFQQuoteLine{},
+		&models.Contract{},
+		&models.ContractItem{},
 	)
-}
+	if err != nil {
+		return err
+	}
+
+	// Purchasings created before the status column existed have an empty status
+	if err := repository.NewPurchasingRepository().BackfillLegacyStatus(); err != nil {
+		return err
+	}
 
+	// Items created before the stock ledger existed start it with an opening balance
+	if err := repository.NewStockMovementRepository().BackfillOpeningBalances(); err != nil {
+		return err
+	}
+
+	// Stock and documents from before warehouses existed belong to the default warehouse
+	if err := repository.NewWarehouseRepository().BackfillDefaultWarehouse(); err != nil {
+		return err
+	}
+
+	// Purchasing lines from before unit prices were stored get theirs from the subtotal
+	if err := repository.NewSupplierPriceRepository().BackfillUnitPrices(); err != nil {
+		return err
+	}
+
+	// Items and purchasing lines from before units of measure are counted in PCS
+	if err := repository.NewUnitRepository().BackfillBaseUnits(); err != nil {
+		return err
+	}
+
+	// Purchasings from before discounts and taxes have a cost breakdown of their subtotal only
+	if err := repository.NewPurchasingRepository().BackfillTotals(); err != nil {
+		return err
+	}
 
+	// Invoices from before payment terms existed are due on their supplier's terms
+	if err := repository.NewSupplierInvoiceRepository().BackfillPaymentTerms(); err != nil {
+		return err
+	}
+
+	// Suppliers and purchasings from before currencies existed are in the base currency
+	return repository.NewExchangeRateRepository().BackfillBaseCurrency()
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"procurement-system/models"
+)
+
+// Permissions checked by RequirePermission
+const (
+	PermItemsRead           = "items:read"
+	PermItemsWrite          = "items:write"
+	PermItemsDelete         = "items:delete"
+	PermStockReconcile      = "stock:reconcile"
+	PermStockAdjust         = "stock:adjust"
+	PermStockApprove        = "stock:approve"
+	PermStockTransfer       = "stock:transfer"
+	PermWarehousesWrite     = "warehouses:write"
+	PermExchangeRatesWrite  = "exchange-rates:write"
+	PermTaxCodesWrite       = "tax-codes:write"
+	PermDepartmentsWrite    = "departments:write"
+	PermBudgetsWrite        = "budgets:write"
+	PermSuppliersRead       = "suppliers:read"
+	PermSuppliersWrite      = "suppliers:write"
+	PermSuppliersDelete     = "suppliers:delete"
+	PermContractsWrite      = "contracts:write"
+	PermPurchasingsRead     = "purchasings:read"
+	PermPurchasingsCreate   = "purchasings:create"
+	PermPurchasingsSubmit   = "purchasings:submit"
+	PermPurchasingsApprove  = "purchasings:approve"
+	PermPurchasingsOrder    = "purchasings:order"
+	PermPurchasingsCancel   = "purchasings:cancel"
+	PermPurchasingsClose    = "purchasings:close"
+	PermReceiptsCreate      = "receipts:create"
+	PermRequisitionsRead    = "requisitions:read"
+	PermRequisitionsCreate  = "requisitions:create"
+	PermRequisitionsApprove = "requisitions:approve"
+	PermRFQsRead            = "rfqs:read"
+	PermRFQsWrite           = "rfqs:write"
+	PermInvoicesRead        = "invoices:read"
+	PermInvoicesCreate      = "invoices:create"
+	PermInvoicesApprove     = "invoices:approve"
+	PermInvoicesPay         = "invoices:pay"
+	PermApprovalRulesRead   = "approval-rules:read"
+	PermApprovalRulesWrite  = "approval-rules:write"
+	PermWebhooksRead        = "webhooks:read"
+	PermWebhooksWrite       = "webhooks:write"
+)
+
+// rolePermissions is the permission matrix: which permissions each role holds
+// Admins hold every permission; staff run day-to-day purchasing but cannot delete
+// master data, close orders, change approval rules, warehouses, exchange rates, tax codes,
+// departments, budgets or supplier contracts, approve stock corrections, requisitions or supplier
+// invoices, or pay suppliers.
+var rolePermissions = map[string][]string{
+	models.RoleAdmin: {
+		PermItemsRead, PermItemsWrite, PermItemsDelete,
+		PermStockReconcile, PermStockAdjust, PermStockApprove, PermStockTransfer,
+		PermWarehousesWrite, PermExchangeRatesWrite, PermTaxCodesWrite,
+		PermDepartmentsWrite, PermBudgetsWrite,
+		PermSuppliersRead, PermSuppliersWrite, PermSuppliersDelete, PermContractsWrite,
+		PermPurchasingsRead, PermPurchasingsCreate, PermPurchasingsSubmit, PermPurchasingsApprove,
+		PermPurchasingsOrder, PermPurchasingsCancel, PermPurchasingsClose,
+		PermReceiptsCreate,
+		PermRequisitionsRead, PermRequisitionsCreate, PermRequisitionsApprove,
+		PermRFQsRead, PermRFQsWrite,
+		PermInvoicesRead, PermInvoicesCreate, PermInvoicesApprove, PermInvoicesPay,
+		PermApprovalRulesRead, PermApprovalRulesWrite,
+		PermWebhooksRead, PermWebhooksWrite,
+	},
+	models.RoleStaff: {
+		PermItemsRead, PermItemsWrite,
+		PermStockAdjust, PermStockTransfer,
+		PermSuppliersRead, PermSuppliersWrite,
+		PermPurchasingsRead, PermPurchasingsCreate, PermPurchasingsSubmit, PermPurchasingsApprove,
+		PermPurchasingsOrder, PermPurchasingsCancel,
+		PermReceiptsCreate,
+		PermRequisitionsRead, PermRequisitionsCreate,
+		PermRFQsRead, PermRFQsWrite,
+		PermInvoicesRead, PermInvoicesCreate,
+		PermApprovalRulesRead,
+	},
+}
+
+// HasPermission reports whether the role holds the permission
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission allows the request only when the authenticated user's role holds the permission
+// Must be registered after JWTAuth, which stores the role in Fiber locals
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		if !HasPermission(role, permission) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error":             "Missing permission: " + permission,
+				"missingPermission": permission,
+			})
+		}
+		return c.Next()
+	}
+}
+
+// RequireRole allows the request only when the authenticated user has one of the roles
+// Must be registered after JWTAuth, which stores the role in Fiber locals
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, allowed := range roles {
+			if role == allowed {
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":         "Requires role: " + strings.Join(roles, " or "),
+			"requiredRoles": roles,
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "github.com/shopspring/decimal"
+
+// ApprovalRule defines who has to approve a purchasing above a given amount
+// A purchasing whose BaseGrandTotal is strictly above MinAmount (in the base currency) needs RequiredApprovals
+// distinct approvals from users holding ApproverRole. When several rules match,
+// the one with the highest MinAmount applies.
+type ApprovalRule struct {
+	ID                uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	MinAmount         decimal.Decimal `gorm:"type:decimal(15,2);not null;uniqueIndex" json:"minAmount"`
+	ApproverRole      string          `gorm:"type:varchar(20);not null" json:"approverRole"`
+	RequiredApprovals int             `gorm:"not null;default:1" json:"requiredApprovals"`
+}
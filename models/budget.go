@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Budget controls, deciding what happens to purchasings the remaining budget does not cover
+const (
+	BudgetControlHard = "hard" // refused
+	BudgetControlSoft = "soft" // allowed, but flagged as over budget
+)
+
+// Budget is what a department may spend from PeriodStart to PeriodEnd (both inclusive)
+// Amounts are in the base currency. Encumbered is committed by approved purchasings that have not
+// been invoiced yet; Spent is what approved supplier invoices charged. The periods of a
+// department's budgets do not overlap.
+type Budget struct {
+	ID           uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	DepartmentID uint            `gorm:"not null;index" json:"departmentId"`
+	PeriodStart  time.Time       `gorm:"type:date;not null;index" json:"periodStart"`
+	PeriodEnd    time.Time       `gorm:"type:date;not null;index" json:"periodEnd"`
+	Amount       decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Encumbered   decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"encumbered"`
+	Spent        decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"spent"`
+	Control      string          `gorm:"type:varchar(10);not null;default:hard" json:"control"`
+	Note         string          `gorm:"type:text" json:"note"`
+	CreatedBy    uint            `gorm:"not null" json:"createdBy"`
+	CreatedAt    time.Time       `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt    time.Time       `gorm:"type:datetime;not null" json:"updatedAt"`
+
+	// Relationships
+	Department *Department `gorm:"foreignKey:DepartmentID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"department,omitempty"`
+}
+
+// Remaining is what is left of the budget after encumbrances and spending
+func (b *Budget) Remaining() decimal.Decimal {
+	return b.Amount.Sub(b.Encumbered).Sub(b.Spent)
+}
+
+// IsValidBudgetControl reports whether control is a known budget control
+func IsValidBudgetControl(control string) bool {
+	return control == BudgetControlHard || control == BudgetControlSoft
+}
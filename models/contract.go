@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Contract enforcement, deciding what happens to purchasing lines that deviate from a contract
+const (
+	ContractEnforcementWarn  = "warn"  // allowed, but flagged on the line
+	ContractEnforcementBlock = "block" // refused
+)
+
+// Ways a purchasing line can deviate from the contracts in force
+const (
+	ContractViolationPriceAbove    = "price_above_contract"    // priced above the supplier's contract price
+	ContractViolationNotContracted = "non_contracted_supplier" // another supplier holds a contract for the item
+)
+
+// Contract is an agreement with a supplier, in force from ValidFrom to ValidTo (both inclusive)
+// Agreed prices are per base unit of the item in the supplier's currency, like its price list;
+// CommittedSpend is what was committed to buy from the supplier over the period, in the base
+// currency so it can be compared with purchasings at their own rates. Purchasings created while the contract is in force are checked against it, and
+// Enforcement decides whether deviations are refused or flagged. An expiry alert goes out AlertDays
+// before ValidTo; ExpiryAlertedAt records when. A supplier's contracts do not overlap.
+type Contract struct {
+	ID              uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	SupplierID      uint            `gorm:"not null;index" json:"supplierId"`
+	Reference       string          `gorm:"type:varchar(50);not null;uniqueIndex" json:"reference"`
+	Title           string          `gorm:"type:varchar(150);not null" json:"title"`
+	ValidFrom       time.Time       `gorm:"type:date;not null;index" json:"validFrom"`
+	ValidTo         time.Time       `gorm:"type:date;not null;index" json:"validTo"`
+	CommittedSpend  decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"committedSpend"`
+	Enforcement     string          `gorm:"type:varchar(10);not null;default:warn" json:"enforcement"`
+	Terms           string          `gorm:"type:text" json:"terms"`
+	AlertDays       int             `gorm:"not null;default:30" json:"alertDays"`
+	ExpiryAlertedAt *time.Time      `gorm:"type:datetime" json:"expiryAlertedAt"`
+	CreatedBy       uint            `gorm:"not null" json:"createdBy"`
+	CreatedAt       time.Time       `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt       time.Time       `gorm:"type:datetime;not null" json:"updatedAt"`
+
+	// Relationships
+	Supplier *Supplier      `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"supplier,omitempty"`
+	Items    []ContractItem `gorm:"foreignKey:ContractID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+}
+
+// ContractItem is the price agreed for an item under a contract
+type ContractItem struct {
+	ID         uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	ContractID uint            `gorm:"not null;uniqueIndex:idx_contract_item,priority:1" json:"contractId"`
+	ItemID     uint            `gorm:"not null;uniqueIndex:idx_contract_item,priority:2;index" json:"itemId"`
+	UnitPrice  decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"unitPrice"`
+
+	// Relationships
+	Item Item `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"item,omitempty"`
+}
+
+// IsValidContractEnforcement reports whether enforcement is a known contract enforcement
+func IsValidContractEnforcement(enforcement string) bool {
+	return enforcement == ContractEnforcementWarn || enforcement == ContractEnforcementBlock
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Cycle count statuses
+const (
+	CycleCountStatusOpen      = "open"
+	CycleCountStatusSubmitted = "submitted"
+	CycleCountStatusApproved  = "approved"
+	CycleCountStatusCancelled = "cancelled"
+)
+
+// CycleCount is a counting session for a set of items in one warehouse
+// Staff record counted quantities while the count is open. Submitting snapshots the warehouse's
+// stock as ExpectedQty and creates a pending count_correction adjustment for every variance;
+// approving the count posts those adjustments to stock, rejecting it reopens the count.
+type CycleCount struct {
+	ID          uint             `gorm:"primaryKey;autoIncrement" json:"id"`
+	WarehouseID *uint            `gorm:"index" json:"warehouseId"`
+	Status      string           `gorm:"type:varchar(20);not null;index" json:"status"`
+	Note        string           `gorm:"type:text" json:"note"`
+	CreatedBy   uint             `gorm:"not null" json:"createdBy"`
+	SubmittedBy *uint            `json:"submittedBy"`
+	DecidedBy   *uint            `json:"decidedBy"`
+	CreatedAt   time.Time        `gorm:"type:datetime;not null" json:"createdAt"`
+	SubmittedAt *time.Time       `gorm:"type:datetime" json:"submittedAt"`
+	DecidedAt   *time.Time       `gorm:"type:datetime" json:"decidedAt"`
+	Lines       []CycleCountLine `gorm:"foreignKey:CycleCountID" json:"lines,omitempty"`
+}
+
+// CycleCountLine is the count of one item in a cycle count
+// CountedQty is nil until counted; ExpectedQty and Variance are set on submission.
+type CycleCountLine struct {
+	ID           uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	CycleCountID uint `gorm:"not null;uniqueIndex:idx_cycle_count_item" json:"cycleCountId"`
+	ItemID       uint `gorm:"not null;uniqueIndex:idx_cycle_count_item" json:"itemId"`
+	CountedQty   *int `json:"countedQty"`
+	ExpectedQty  *int `json:"expectedQty"`
+	Variance     *int `json:"variance"`
+	Item         Item `gorm:"foreignKey:ItemID" json:"item,omitempty"`
+}
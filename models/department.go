@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Department is a department or cost center purchasings are charged to
+type Department struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code      string    `gorm:"type:varchar(20);not null;uniqueIndex" json:"code"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"type:datetime;not null" json:"updatedAt"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Exchange rate sources
+const (
+	ExchangeRateSourceManual = "manual"
+	ExchangeRateSourceImport = "import"
+)
+
+// currencyCodePattern matches ISO 4217 style currency codes such as IDR, USD or SGD
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// IsValidCurrencyCode reports whether code is a three-letter upper case currency code
+func IsValidCurrencyCode(code string) bool {
+	return currencyCodePattern.MatchString(code)
+}
+
+// ExchangeRate is the value of one unit of Currency in the base currency from EffectiveDate on
+// A rate applies until the next rate of the same currency takes effect.
+type ExchangeRate struct {
+	ID            uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Currency      string          `gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rate_day,priority:1" json:"currency"`
+	EffectiveDate time.Time       `gorm:"type:date;not null;uniqueIndex:idx_exchange_rate_day,priority:2" json:"effectiveDate"`
+	Rate          decimal.Decimal `gorm:"type:decimal(18,6);not null" json:"rate"`
+	Source        string          `gorm:"type:varchar(20);not null" json:"source"`
+	CreatedBy     uint            `gorm:"not null" json:"createdBy"`
+	CreatedAt     time.Time       `gorm:"type:datetime;not null" json:"createdAt"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// GoodsReceipt records goods physically received against a purchasing
+// WarehouseID is where the accepted goods were put; it defaults to the purchasing's warehouse.
+type GoodsReceipt struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PurchasingID uint      `gorm:"not null;index" json:"purchasingId"`
+	UserID       uint      `gorm:"not null;index" json:"userId"`
+	WarehouseID  *uint     `gorm:"index" json:"warehouseId"`
+	ReceivedAt   time.Time `gorm:"type:datetime;not null" json:"receivedAt"`
+	Note         string    `gorm:"type:text" json:"note"`
+
+	// Relationships
+	Purchasing Purchasing         `gorm:"foreignKey:PurchasingID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	User       User               `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	Lines      []GoodsReceiptLine `gorm:"foreignKey:GoodsReceiptID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// GoodsReceiptLine records the quantity received for a single purchasing detail
+// ReceivedQty is the accepted quantity that goes into stock; RejectedQty was delivered
+// but refused (damaged, wrong item) and does not count towards the ordered quantity. Both are
+// in the purchasing detail's unit.
+type GoodsReceiptLine struct {
+	ID                 uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	GoodsReceiptID     uint `gorm:"not null;index" json:"goodsReceiptId"`
+	PurchasingDetailID uint `gorm:"not null;index" json:"purchasingDetailId"`
+	ItemID             uint `gorm:"not null;index" json:"itemId"`
+	ReceivedQty        int  `gorm:"not null;default:0" json:"receivedQty"`
+	RejectedQty        int  `gorm:"not null;default:0" json:"rejectedQty"`
+
+	// Relationships
+	PurchasingDetail PurchasingDetail `gorm:"foreignKey:PurchasingDetailID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	Item             Item             `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"item,omitempty"`
+}
@@ -3,13 +3,35 @@ package models
 import "github.com/shopspring/decimal"
 
 // Item represents a product/item in the inventory
+// Stock, stock movements and Price are in the item's base unit; purchasing lines may use one of
+// its alternative units (Units) and are converted to the base unit when goods are received.
 type Item struct {
 	ID    uint            `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name  string          `gorm:"type:varchar(100);not null" json:"name"`
-	Stock int             `gorm:"not null;default:0" json:"stock"`
-	Price decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"price"`
+	Name  string          `gorm:"type:varchar(100);not null;index" json:"name"`
+	Stock int             `gorm:"not null;default:0;index" json:"stock"`
+	Price decimal.Decimal `gorm:"type:decimal(15,2);not null;index" json:"price"`
+
+	BaseUnitID *uint          `gorm:"index" json:"baseUnitId"`
+	BaseUnit   *UnitOfMeasure `gorm:"foreignKey:BaseUnitID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"baseUnit,omitempty"`
+
+	// Reorder settings: when Stock plus what is already on order falls to ReorderPoint, a draft
+	// purchasing is suggested for ReorderQty, or for enough to bring the item up to MaxStock
+	// when that is set. A nil ReorderPoint turns reordering off for the item.
+	ReorderPoint *int `json:"reorderPoint"`
+	ReorderQty   int  `gorm:"not null;default:0" json:"reorderQty"`
+	MaxStock     int  `gorm:"not null;default:0" json:"maxStock"`
+
+	// Taxes charged on purchasing lines of the item unless the line names its own; nil for none
+	TaxCodeID         *uint `gorm:"index" json:"taxCodeId"`
+	WithholdingCodeID *uint `gorm:"index" json:"withholdingCodeId"`
 
 	// Relationships
 	SupplierID uint     `gorm:"not null;index" json:"supplierId"`
 	Supplier   Supplier `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"supplier,omitempty"`
+
+	// Stock per warehouse; Stock above is their total. Only loaded when stock by location is requested.
+	Stocks []WarehouseStock `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE" json:"stocks,omitempty"`
+
+	// Alternative purchase units; only loaded when the item's units are requested.
+	Units []ItemUnit `gorm:"foreignKey:ItemID;constraint:OnDelete:CASCADE" json:"units,omitempty"`
 }
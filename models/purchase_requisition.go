@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Purchase requisition statuses
+const (
+	RequisitionStatusDraft     = "draft"
+	RequisitionStatusSubmitted = "submitted"
+	RequisitionStatusApproved  = "approved"
+	RequisitionStatusRejected  = "rejected"
+	RequisitionStatusCancelled = "cancelled"
+	RequisitionStatusConverted = "converted"
+)
+
+// Purchase requisition line statuses
+const (
+	RequisitionLinePending   = "pending"
+	RequisitionLineApproved  = "approved"
+	RequisitionLineRejected  = "rejected"
+	RequisitionLineConverted = "converted"
+)
+
+// requisitionTransitions lists the statuses a requisition may move to from each status
+var requisitionTransitions = map[string][]string{
+	RequisitionStatusDraft:     {RequisitionStatusSubmitted, RequisitionStatusCancelled},
+	RequisitionStatusSubmitted: {RequisitionStatusApproved, RequisitionStatusRejected, RequisitionStatusCancelled},
+	RequisitionStatusApproved:  {RequisitionStatusConverted, RequisitionStatusCancelled},
+	RequisitionStatusRejected:  {},
+	RequisitionStatusCancelled: {},
+	RequisitionStatusConverted: {},
+}
+
+// PurchaseRequisition is a request for goods raised by a department before anything is ordered
+// Once reviewed, its approved lines are converted into purchasings, one per supplier. The
+// requisition is converted when none of its approved lines is left to convert.
+type PurchaseRequisition struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	RequesterID     uint       `gorm:"not null;index" json:"requesterId"`
+	DepartmentID    uint       `gorm:"not null;index" json:"departmentId"`
+	NeededBy        time.Time  `gorm:"type:date;not null;index" json:"neededBy"`
+	Justification   string     `gorm:"type:text;not null" json:"justification"`
+	Status          string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	SubmittedAt     *time.Time `gorm:"type:datetime" json:"submittedAt"`
+	DecidedBy       *uint      `json:"decidedBy"`
+	DecisionComment string     `gorm:"type:text" json:"decisionComment"`
+	DecidedAt       *time.Time `gorm:"type:datetime" json:"decidedAt"`
+	CreatedAt       time.Time  `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt       time.Time  `gorm:"type:datetime;not null" json:"updatedAt"`
+
+	// Relationships
+	Requester  User                      `gorm:"foreignKey:RequesterID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"requester,omitempty"`
+	Department Department                `gorm:"foreignKey:DepartmentID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"department,omitempty"`
+	Lines      []PurchaseRequisitionLine `gorm:"foreignKey:RequisitionID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+}
+
+// PurchaseRequisitionLine is one thing requested: a catalog item, or a free-text description
+// Qty is in the item's base unit for catalog lines. A free-text line is given an item when it is
+// converted. EstimatedPrice is the requester's guess per unit in the base currency, for the
+// reviewer only; purchasings are priced from the supplier's price list. A converted line points
+// to the purchasing and purchasing line it became.
+type PurchaseRequisitionLine struct {
+	ID                 uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	RequisitionID      uint            `gorm:"not null;index" json:"requisitionId"`
+	ItemID             *uint           `gorm:"index" json:"itemId"`
+	Description        string          `gorm:"type:varchar(255);not null;default:''" json:"description"`
+	Qty                int             `gorm:"not null" json:"qty"`
+	EstimatedPrice     decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"estimatedPrice"`
+	Status             string          `gorm:"type:varchar(20);not null;index" json:"status"`
+	PurchasingID       *uint           `gorm:"index" json:"purchasingId"`
+	PurchasingDetailID *uint           `gorm:"index" json:"purchasingDetailId"`
+
+	// Relationships
+	Item *Item `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"item,omitempty"`
+}
+
+// CanTransitionTo reports whether the requisition may move from its current status to the given status
+func (r *PurchaseRequisition) CanTransitionTo(status string) bool {
+	for _, allowed := range requisitionTransitions[r.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidRequisitionStatus reports whether status is a known requisition status
+func IsValidRequisitionStatus(status string) bool {
+	_, ok := requisitionTransitions[status]
+	return ok
+}
@@ -6,17 +6,119 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Purchasing statuses
+const (
+	PurchasingStatusDraft             = "draft"
+	PurchasingStatusSubmitted         = "submitted"
+	PurchasingStatusApproved          = "approved"
+	PurchasingStatusOrdered           = "ordered"
+	PurchasingStatusPartiallyReceived = "partially_received"
+	PurchasingStatusReceived          = "received"
+	PurchasingStatusCancelled         = "cancelled"
+	PurchasingStatusClosed            = "closed"
+)
+
+// Purchasing origins
+const (
+	PurchasingOriginManual      = "manual"
+	PurchasingOriginReorder     = "reorder"
+	PurchasingOriginRequisition = "requisition"
+	PurchasingOriginRFQ         = "rfq"
+)
+
+// Discount types, for purchasing lines and the purchasing as a whole
+const (
+	DiscountTypePercent = "percent"
+	DiscountTypeAmount  = "amount"
+)
+
+// purchasingTransitions lists the statuses a purchasing may move to from each status
+var purchasingTransitions = map[string][]string{
+	PurchasingStatusDraft:             {PurchasingStatusSubmitted, PurchasingStatusCancelled},
+	PurchasingStatusSubmitted:         {PurchasingStatusApproved, PurchasingStatusDraft, PurchasingStatusCancelled},
+	PurchasingStatusApproved:          {PurchasingStatusOrdered, PurchasingStatusCancelled},
+	PurchasingStatusOrdered:           {PurchasingStatusPartiallyReceived, PurchasingStatusReceived, PurchasingStatusCancelled},
+	PurchasingStatusPartiallyReceived: {PurchasingStatusReceived, PurchasingStatusCancelled, PurchasingStatusClosed},
+	PurchasingStatusReceived:          {PurchasingStatusClosed},
+	PurchasingStatusCancelled:         {},
+	PurchasingStatusClosed:            {},
+}
+
 // Purchasing represents a purchasing transaction
 type Purchasing struct {
-	ID         uint            `gorm:"primaryKey;autoIncrement" json:"id"`
-	Date       time.Time       `gorm:"type:datetime;not null;default:CURRENT_TIMESTAMP" json:"date"`
-	SupplierID uint            `gorm:"not null;index" json:"supplierId"`
-	UserID     uint            `gorm:"not null;index" json:"userId"`
-	GrandTotal decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"grandTotal"`
-	
+	ID          uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Date        time.Time       `gorm:"type:datetime;not null;default:CURRENT_TIMESTAMP;index" json:"date"`
+	SupplierID  uint            `gorm:"not null;index" json:"supplierId"`
+	UserID      uint            `gorm:"not null;index" json:"userId"`
+	WarehouseID *uint           `gorm:"index" json:"warehouseId"`
+	GrandTotal  decimal.Decimal `gorm:"type:decimal(15,2);not null;index" json:"grandTotal"`
+	Status      string          `gorm:"type:varchar(20);not null;index" json:"status"`
+	Origin      string          `gorm:"type:varchar(20);not null;default:manual;index" json:"origin"`
+
+	// Cost breakdown, computed server-side from the lines: NetTotal is SubTotal less DiscountTotal
+	// (the line discounts plus the purchasing's own discount, given by DiscountType and
+	// DiscountValue), and GrandTotal = NetTotal + TaxTotal - WithholdingTotal + ShippingAmount.
+	SubTotal         decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"subTotal"`
+	DiscountType     string          `gorm:"type:varchar(10);not null;default:''" json:"discountType"`
+	DiscountValue    decimal.Decimal `gorm:"type:decimal(15,4);not null;default:0" json:"discountValue"`
+	DiscountTotal    decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"discountTotal"`
+	NetTotal         decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"netTotal"`
+	TaxTotal         decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"taxTotal"`
+	WithholdingTotal decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"withholdingTotal"`
+	ShippingAmount   decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"shippingAmount"`
+
+	// Amounts above are in Currency, the supplier's currency. ExchangeRate is the value of one
+	// unit of it in the base currency on the order date, and BaseGrandTotal is GrandTotal
+	// converted at that rate.
+	Currency       string          `gorm:"type:varchar(3);not null;default:'';index" json:"currency"`
+	ExchangeRate   decimal.Decimal `gorm:"type:decimal(18,6);not null;default:1" json:"exchangeRate"`
+	BaseGrandTotal decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0;index" json:"baseGrandTotal"`
+
+	// Department the purchasing is charged to and the budget of the period it falls in.
+	// EncumberedAmount is the part of BaseGrandTotal still committed against the budget: set on
+	// approval, reduced by approved invoices and released on cancellation or closing. OverBudget
+	// marks a purchasing the remaining budget did not cover, allowed because the control is soft.
+	DepartmentID     *uint           `gorm:"index" json:"departmentId"`
+	BudgetID         *uint           `gorm:"index" json:"budgetId"`
+	EncumberedAmount decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"encumberedAmount"`
+	OverBudget       bool            `gorm:"not null;default:false" json:"overBudget"`
+
+	// Day the supplier is expected to deliver, set when the purchasing is created or ordered.
+	// Supplier scorecards measure on-time delivery against it.
+	ExpectedDeliveryDate *time.Time `gorm:"type:date;index" json:"expectedDeliveryDate"`
+
+	// OffContract marks a purchasing with lines that deviate from a supplier contract in force on
+	// its date, let through because the contract only warns; the lines say how they deviate.
+	OffContract bool `gorm:"not null;default:false;index" json:"offContract"`
+
+	// Approval requirements, evaluated against BaseGrandTotal when the purchasing is submitted
+	ApprovalRound     int    `gorm:"not null;default:0" json:"approvalRound"`
+	RequiredApprovals int    `gorm:"not null;default:0" json:"requiredApprovals"`
+	ApproverRole      string `gorm:"type:varchar(20)" json:"approverRole,omitempty"`
+
 	// Relationships
-	Supplier        Supplier          `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"supplier,omitempty"`
-	User            User              `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"user,omitempty"`
-	PurchasingDetails []PurchasingDetail `gorm:"foreignKey:PurchasingID;constraint:OnDelete:CASCADE" json:"purchasingDetails,omitempty"`
+	Supplier          Supplier                  `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"supplier,omitempty"`
+	User              User                      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"user,omitempty"`
+	Warehouse         *Warehouse                `gorm:"foreignKey:WarehouseID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"warehouse,omitempty"`
+	Department        *Department               `gorm:"foreignKey:DepartmentID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"department,omitempty"`
+	Budget            *Budget                   `gorm:"foreignKey:BudgetID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	PurchasingDetails []PurchasingDetail        `gorm:"foreignKey:PurchasingID;constraint:OnDelete:CASCADE" json:"purchasingDetails,omitempty"`
+	StatusHistory     []PurchasingStatusHistory `gorm:"foreignKey:PurchasingID;constraint:OnDelete:CASCADE" json:"statusHistory,omitempty"`
+	Approvals         []PurchasingApproval      `gorm:"foreignKey:PurchasingID;constraint:OnDelete:CASCADE" json:"approvals,omitempty"`
+}
+
+// CanTransitionTo reports whether the purchasing may move from its current status to the given status
+func (p *Purchasing) CanTransitionTo(status string) bool {
+	for _, allowed := range purchasingTransitions[p.Status] {
+		if allowed == status {
+			return true
+		}
+	}
+	return false
 }
 
+// IsValidPurchasingStatus reports whether status is a known purchasing status
+func IsValidPurchasingStatus(status string) bool {
+	_, ok := purchasingTransitions[status]
+	return ok
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Approval decisions
+const (
+	ApprovalDecisionApproved = "approved"
+	ApprovalDecisionRejected = "rejected"
+)
+
+// PurchasingApproval records one approver's decision on a submitted purchasing
+// Round matches Purchasing.ApprovalRound, so decisions from an earlier submission
+// do not count after the purchasing is sent back to draft and resubmitted.
+type PurchasingApproval struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PurchasingID uint      `gorm:"not null;index" json:"purchasingId"`
+	Round        int       `gorm:"not null" json:"round"`
+	ApproverID   uint      `gorm:"not null;index" json:"approverId"`
+	Decision     string    `gorm:"type:varchar(10);not null" json:"decision"`
+	Comment      string    `gorm:"type:text" json:"comment"`
+	DecidedAt    time.Time `gorm:"type:datetime;not null" json:"decidedAt"`
+
+	// Relationships
+	Purchasing Purchasing `gorm:"foreignKey:PurchasingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"-"`
+	Approver   User       `gorm:"foreignKey:ApproverID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+}
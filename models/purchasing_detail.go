@@ -8,10 +8,56 @@ type PurchasingDetail struct {
 	PurchasingID uint            `gorm:"not null;index" json:"purchasingId"`
 	ItemID       uint            `gorm:"not null;index" json:"itemId"`
 	Qty          int             `gorm:"not null" json:"qty"`
+	ReceivedQty  int             `gorm:"not null;default:0" json:"receivedQty"`
+	UnitPrice    decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"unitPrice"`
 	SubTotal     decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"subTotal"`
+
+	// Unit the line is ordered in; Qty, ReceivedQty and UnitPrice are per this unit. UnitFactor is
+	// how many of the item's base unit it held when the line was created.
+	UnitID     *uint `gorm:"index" json:"unitId"`
+	UnitFactor int   `gorm:"not null;default:1" json:"unitFactor"`
+
+	// Discounts: DiscountType (percent, amount or empty) and DiscountValue are the line's own
+	// discount as entered and DiscountAmount what it comes to; HeaderDiscount is the line's share
+	// of the purchasing's discount. NetAmount is SubTotal less both, the base the taxes are on.
+	DiscountType   string          `gorm:"type:varchar(10);not null;default:''" json:"discountType"`
+	DiscountValue  decimal.Decimal `gorm:"type:decimal(15,4);not null;default:0" json:"discountValue"`
+	DiscountAmount decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"discountAmount"`
+	HeaderDiscount decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"headerDiscount"`
+	NetAmount      decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"netAmount"`
+
+	// Taxes, with the rates (percent) of their codes when the line was priced
+	TaxCodeID         *uint           `gorm:"index" json:"taxCodeId"`
+	TaxRate           decimal.Decimal `gorm:"type:decimal(7,4);not null;default:0" json:"taxRate"`
+	TaxAmount         decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"taxAmount"`
+	WithholdingCodeID *uint           `gorm:"index" json:"withholdingCodeId"`
+	WithholdingRate   decimal.Decimal `gorm:"type:decimal(7,4);not null;default:0" json:"withholdingRate"`
+	WithholdingAmount decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"withholdingAmount"`
+
+	// Price list entry the line was priced from; nil when the item's own price was used
+	SupplierPriceID *uint `gorm:"index" json:"supplierPriceId"`
+
+	// Purchase requisition line the line was converted from; nil when it was ordered directly
+	RequisitionLineID *uint `gorm:"index" json:"requisitionLineId"`
+
+	// Contract the line was checked against and how it deviates from it (ContractViolation*);
+	// the violation is empty when the line complies or no contract covers the item
+	ContractID        *uint  `gorm:"index" json:"contractId"`
+	ContractViolation string `gorm:"type:varchar(30);not null;default:''" json:"contractViolation"`
 	
 	// Relationships
-	Purchasing Purchasing `gorm:"foreignKey:PurchasingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"purchasing,omitempty"`
-	Item       Item       `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"item,omitempty"`
+	Purchasing      Purchasing     `gorm:"foreignKey:PurchasingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"purchasing,omitempty"`
+	Item            Item           `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"item,omitempty"`
+	TaxCode         *TaxCode       `gorm:"foreignKey:TaxCodeID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"taxCode,omitempty"`
+	WithholdingCode *TaxCode       `gorm:"foreignKey:WithholdingCodeID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"withholdingCode,omitempty"`
+	Unit            *UnitOfMeasure `gorm:"foreignKey:UnitID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"unit,omitempty"`
+}
+
+// BaseQty converts a quantity in the line's unit to the item's base unit
+func (d *PurchasingDetail) BaseQty(qty int) int {
+	if d.UnitFactor <= 0 {
+		return qty
+	}
+	return qty * d.UnitFactor
 }
 
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PurchasingStatusHistory records a single status transition of a purchasing
+type PurchasingStatusHistory struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	PurchasingID uint      `gorm:"not null;index" json:"purchasingId"`
+	FromStatus   string    `gorm:"type:varchar(20)" json:"fromStatus"`
+	ToStatus     string    `gorm:"type:varchar(20);not null" json:"toStatus"`
+	UserID       uint      `gorm:"not null;index" json:"userId"`
+	Note         string    `gorm:"type:text" json:"note"`
+	ChangedAt    time.Time `gorm:"type:datetime;not null" json:"changedAt"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"user,omitempty"`
+}
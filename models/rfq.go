@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// RFQ statuses
+const (
+	RFQStatusOpen      = "open"
+	RFQStatusAwarded   = "awarded"
+	RFQStatusCancelled = "cancelled"
+)
+
+// RFQ is a request for quotation: the lines to buy, sent to several invited suppliers
+// Each invited supplier may enter one quote while the RFQ is open, until DueDate when it has one.
+// Awarding the RFQ to a supplier creates a draft purchasing at that supplier's quoted prices.
+type RFQ struct {
+	ID                uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Title             string     `gorm:"type:varchar(150);not null" json:"title"`
+	Status            string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	DueDate           *time.Time `gorm:"type:date" json:"dueDate"`
+	DepartmentID      *uint      `gorm:"index" json:"departmentId"`
+	Note              string     `gorm:"type:text" json:"note"`
+	CreatedBy         uint       `gorm:"not null" json:"createdBy"`
+	AwardedSupplierID *uint      `gorm:"index" json:"awardedSupplierId"`
+	PurchasingID      *uint      `gorm:"index" json:"purchasingId"`
+	AwardedBy         *uint      `json:"awardedBy"`
+	AwardedAt         *time.Time `gorm:"type:datetime" json:"awardedAt"`
+	CreatedAt         time.Time  `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt         time.Time  `gorm:"type:datetime;not null" json:"updatedAt"`
+
+	// Relationships
+	Lines     []RFQLine     `gorm:"foreignKey:RFQID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+	Suppliers []RFQSupplier `gorm:"foreignKey:RFQID;constraint:OnDelete:CASCADE" json:"suppliers,omitempty"`
+	Quotes    []RFQQuote    `gorm:"foreignKey:RFQID;constraint:OnDelete:CASCADE" json:"quotes,omitempty"`
+}
+
+// RFQLine is an item and quantity suppliers are asked to quote for
+// Qty is in the item's base unit.
+type RFQLine struct {
+	ID     uint   `gorm:"primaryKey;autoIncrement" json:"id"`
+	RFQID  uint   `gorm:"not null;index" json:"rfqId"`
+	ItemID uint   `gorm:"not null;index" json:"itemId"`
+	Qty    int    `gorm:"not null" json:"qty"`
+	Note   string `gorm:"type:varchar(255)" json:"note"`
+
+	// Relationships
+	Item Item `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"item,omitempty"`
+}
+
+// RFQSupplier is a supplier invited to quote for an RFQ
+type RFQSupplier struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	RFQID      uint      `gorm:"not null;uniqueIndex:idx_rfq_supplier,priority:1" json:"rfqId"`
+	SupplierID uint      `gorm:"not null;uniqueIndex:idx_rfq_supplier,priority:2;index" json:"supplierId"`
+	InvitedBy  uint      `gorm:"not null" json:"invitedBy"`
+	InvitedAt  time.Time `gorm:"type:datetime;not null" json:"invitedAt"`
+
+	// Relationships
+	Supplier Supplier `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"supplier,omitempty"`
+}
+
+// RFQQuote is what an invited supplier offered for an RFQ
+// Prices and ShippingAmount are in Currency, the supplier's currency. A supplier has at most one
+// quote per RFQ; entering it again replaces it.
+type RFQQuote struct {
+	ID             uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	RFQID          uint            `gorm:"not null;uniqueIndex:idx_rfq_quote_supplier,priority:1" json:"rfqId"`
+	SupplierID     uint            `gorm:"not null;uniqueIndex:idx_rfq_quote_supplier,priority:2;index" json:"supplierId"`
+	Currency       string          `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	ShippingAmount decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"shippingAmount"`
+	Note           string          `gorm:"type:text" json:"note"`
+	CreatedBy      uint            `gorm:"not null" json:"createdBy"`
+	CreatedAt      time.Time       `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt      time.Time       `gorm:"type:datetime;not null" json:"updatedAt"`
+
+	// Relationships
+	Supplier Supplier       `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"supplier,omitempty"`
+	Lines    []RFQQuoteLine `gorm:"foreignKey:QuoteID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+}
+
+// RFQQuoteLine is the price a supplier quoted for one RFQ line
+// UnitPrice is per base unit of the item. LeadTimeDays is how long after ordering the supplier
+// delivers, and the price holds until ValidUntil (inclusive).
+type RFQQuoteLine struct {
+	ID           uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	QuoteID      uint            `gorm:"not null;index" json:"quoteId"`
+	RFQLineID    uint            `gorm:"not null;index" json:"rfqLineId"`
+	UnitPrice    decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"unitPrice"`
+	LeadTimeDays int             `gorm:"not null;default:0" json:"leadTimeDays"`
+	ValidUntil   time.Time       `gorm:"type:date;not null" json:"validUntil"`
+}
+
+// IsValidRFQStatus reports whether status is a known RFQ status
+func IsValidRFQStatus(status string) bool {
+	switch status {
+	case RFQStatusOpen, RFQStatusAwarded, RFQStatusCancelled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Stock adjustment statuses
+const (
+	StockAdjustmentStatusPending  = "pending"
+	StockAdjustmentStatusApproved = "approved"
+	StockAdjustmentStatusRejected = "rejected"
+)
+
+// Stock adjustment reason codes
+const (
+	AdjustmentReasonDamage          = "damage"
+	AdjustmentReasonLoss            = "loss"
+	AdjustmentReasonExpired         = "expired"
+	AdjustmentReasonFound           = "found"
+	AdjustmentReasonCountCorrection = "count_correction"
+)
+
+// StockAdjustment is a requested correction of an item's stock in one warehouse
+// Adjustments only change stock once an admin approves them; the approved quantity is then
+// posted to the stock ledger. Adjustments created by a cycle count carry its CycleCountID and
+// are approved or rejected together with the count.
+type StockAdjustment struct {
+	ID              uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ItemID          uint       `gorm:"not null;index" json:"itemId"`
+	WarehouseID     *uint      `gorm:"index" json:"warehouseId"`
+	Quantity        int        `gorm:"not null" json:"quantity"`
+	Reason          string     `gorm:"type:varchar(30);not null;index" json:"reason"`
+	Note            string     `gorm:"type:text" json:"note"`
+	Status          string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	CycleCountID    *uint      `gorm:"index" json:"cycleCountId"`
+	RequestedBy     uint       `gorm:"not null" json:"requestedBy"`
+	DecidedBy       *uint      `json:"decidedBy"`
+	DecisionComment string     `gorm:"type:text" json:"decisionComment"`
+	CreatedAt       time.Time  `gorm:"type:datetime;not null" json:"createdAt"`
+	DecidedAt       *time.Time `gorm:"type:datetime" json:"decidedAt"`
+	Item            Item       `gorm:"foreignKey:ItemID" json:"item,omitempty"`
+}
+
+// IsValidAdjustmentReason reports whether the reason code is known
+func IsValidAdjustmentReason(reason string) bool {
+	switch reason {
+	case AdjustmentReasonDamage, AdjustmentReasonLoss, AdjustmentReasonExpired,
+		AdjustmentReasonFound, AdjustmentReasonCountCorrection:
+		return true
+	}
+	return false
+}
+
+// AdjustmentReasonAllowsQuantity reports whether the sign of the quantity fits the reason
+// Damage, loss and expiry only remove stock, found goods only add it, and count corrections go either way.
+func AdjustmentReasonAllowsQuantity(reason string, quantity int) bool {
+	if quantity == 0 {
+		return false
+	}
+	switch reason {
+	case AdjustmentReasonDamage, AdjustmentReasonLoss, AdjustmentReasonExpired:
+		return quantity < 0
+	case AdjustmentReasonFound:
+		return quantity > 0
+	}
+	return true
+}
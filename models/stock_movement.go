@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Stock movement reasons
+const (
+	StockMovementOpeningBalance   = "opening_balance"
+	StockMovementInitialStock     = "initial_stock"
+	StockMovementManualAdjustment = "manual_adjustment" // stock edited on the item, before adjustments existed
+	StockMovementGoodsReceipt     = "goods_receipt"
+	StockMovementPurchasingCancel = "purchasing_cancel"
+	StockMovementAdjustment       = "adjustment"
+	StockMovementTransferOut      = "transfer_out"
+	StockMovementTransferIn       = "transfer_in"
+	StockMovementTransferReturn   = "transfer_return"
+)
+
+// Stock movement source document types
+const (
+	StockSourceItem         = "item"
+	StockSourceGoodsReceipt = "goods_receipt"
+	StockSourcePurchasing   = "purchasing"
+	StockSourceAdjustment   = "stock_adjustment"
+	StockSourceTransfer     = "stock_transfer"
+)
+
+// StockMovement is one entry of the append-only stock ledger
+// Every change to Item.Stock writes a movement in the same transaction, so the sum of an
+// item's deltas always equals its stock and BalanceAfter shows the stock after the change.
+// Movements are never updated or deleted, and are kept when their item is deleted.
+// Each movement also changes the balance of its warehouse; WarehouseBalanceAfter is that
+// balance after the change (nil for movements recorded before warehouses existed).
+type StockMovement struct {
+	ID                    uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ItemID                uint      `gorm:"not null;index:idx_stock_movements_item,priority:1" json:"itemId"`
+	WarehouseID           *uint     `gorm:"index" json:"warehouseId"`
+	Delta                 int       `gorm:"not null" json:"delta"`
+	Reason                string    `gorm:"type:varchar(30);not null;index" json:"reason"`
+	SourceType            string    `gorm:"type:varchar(30);not null;index:idx_stock_movements_source,priority:1" json:"sourceType"`
+	SourceID              *uint     `gorm:"index:idx_stock_movements_source,priority:2" json:"sourceId"`
+	UserID                *uint     `gorm:"index" json:"userId"`
+	BalanceAfter          int       `gorm:"not null" json:"balanceAfter"`
+	WarehouseBalanceAfter *int      `json:"warehouseBalanceAfter"`
+	CreatedAt             time.Time `gorm:"type:datetime;not null;index:idx_stock_movements_item,priority:2" json:"createdAt"`
+	User                  *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Stock transfer statuses
+const (
+	StockTransferStatusDraft     = "draft"
+	StockTransferStatusInTransit = "in_transit"
+	StockTransferStatusReceived  = "received"
+	StockTransferStatusCancelled = "cancelled"
+)
+
+// StockTransfer moves goods from one warehouse to another
+// Shipping takes the quantities out of the source warehouse and puts the transfer in transit;
+// receiving adds them to the destination. While in transit the goods are in neither warehouse,
+// so they are not part of Item.Stock. Cancelling a transfer in transit returns the goods to the
+// source warehouse.
+type StockTransfer struct {
+	ID              uint                `gorm:"primaryKey;autoIncrement" json:"id"`
+	FromWarehouseID uint                `gorm:"not null;index" json:"fromWarehouseId"`
+	ToWarehouseID   uint                `gorm:"not null;index" json:"toWarehouseId"`
+	Status          string              `gorm:"type:varchar(20);not null;index" json:"status"`
+	Note            string              `gorm:"type:text" json:"note"`
+	CreatedBy       uint                `gorm:"not null" json:"createdBy"`
+	ShippedBy       *uint               `json:"shippedBy"`
+	ReceivedBy      *uint               `json:"receivedBy"`
+	CancelledBy     *uint               `json:"cancelledBy"`
+	CreatedAt       time.Time           `gorm:"type:datetime;not null" json:"createdAt"`
+	ShippedAt       *time.Time          `gorm:"type:datetime" json:"shippedAt"`
+	ReceivedAt      *time.Time          `gorm:"type:datetime" json:"receivedAt"`
+	CancelledAt     *time.Time          `gorm:"type:datetime" json:"cancelledAt"`
+	FromWarehouse   Warehouse           `gorm:"foreignKey:FromWarehouseID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"fromWarehouse,omitempty"`
+	ToWarehouse     Warehouse           `gorm:"foreignKey:ToWarehouseID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"toWarehouse,omitempty"`
+	Lines           []StockTransferLine `gorm:"foreignKey:StockTransferID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+}
+
+// StockTransferLine is the quantity of one item on a transfer
+type StockTransferLine struct {
+	ID              uint `gorm:"primaryKey;autoIncrement" json:"id"`
+	StockTransferID uint `gorm:"not null;uniqueIndex:idx_stock_transfer_item" json:"stockTransferId"`
+	ItemID          uint `gorm:"not null;uniqueIndex:idx_stock_transfer_item;index" json:"itemId"`
+	Quantity        int  `gorm:"not null" json:"quantity"`
+	Item            Item `gorm:"foreignKey:ItemID" json:"item,omitempty"`
+}
@@ -1,10 +1,66 @@
 package models
 
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// DefaultPaymentTerms are the payment terms of suppliers that were not given any
+const DefaultPaymentTerms = "Net 30"
+
+// paymentTermsPattern matches "Net 30" and "2/10 Net 30" (2% off when paid within 10 days, due in 30)
+var paymentTermsPattern = regexp.MustCompile(`(?i)^(?:(\d{1,2}(?:\.\d{1,2})?)\s*/\s*(\d{1,3})\s+)?net\s*(\d{1,3})$`)
+
 // Supplier represents a supplier/vendor in the system
 type Supplier struct {
 	ID      uint   `gorm:"primaryKey;autoIncrement" json:"id"`
-	Name    string `gorm:"type:varchar(100);not null" json:"name"`
-	Email   string `gorm:"type:varchar(100);not null" json:"email"`
+	Name    string `gorm:"type:varchar(100);not null;index" json:"name"`
+	Email   string `gorm:"type:varchar(100);not null;index" json:"email"`
 	Address string `gorm:"type:text" json:"address"`
-	Items   []Item `gorm:"foreignKey:SupplierID" json:"items,omitempty"`
+
+	// Currency the supplier invoices in; item prices and price lists of the supplier are in it
+	Currency string `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+
+	// Payment terms as entered, e.g. "2/10 Net 30", and what they mean: invoices are due
+	// PaymentDueDays after the invoice date, and EarlyPaymentDiscount percent may be deducted
+	// when paying within EarlyPaymentDays (0 when there is no early payment discount).
+	PaymentTerms         string          `gorm:"type:varchar(30);not null;default:'Net 30'" json:"paymentTerms"`
+	PaymentDueDays       int             `gorm:"not null;default:30" json:"paymentDueDays"`
+	EarlyPaymentDays     int             `gorm:"not null;default:0" json:"earlyPaymentDays"`
+	EarlyPaymentDiscount decimal.Decimal `gorm:"type:decimal(5,2);not null;default:0" json:"earlyPaymentDiscount"`
+
+	Items []Item `gorm:"foreignKey:SupplierID" json:"items,omitempty"`
+}
+
+// SetPaymentTerms parses terms such as "Net 30" or "2/10 Net 30" and stores them on the supplier
+// The early payment period may not be longer than the due period and the discount must be below 100%.
+func (s *Supplier) SetPaymentTerms(terms string) error {
+	match := paymentTermsPattern.FindStringSubmatch(strings.TrimSpace(terms))
+	if match == nil {
+		return fmt.Errorf("payment terms %q must look like \"Net 30\" or \"2/10 Net 30\"", terms)
+	}
+
+	dueDays, _ := strconv.Atoi(match[3])
+	discountDays := 0
+	discount := decimal.Zero
+	if match[1] != "" {
+		discount, _ = decimal.NewFromString(match[1])
+		discountDays, _ = strconv.Atoi(match[2])
+		if !discount.IsPositive() || discountDays < 1 || discountDays > dueDays {
+			return fmt.Errorf("payment terms %q need a positive discount paid within the due period", terms)
+		}
+	}
+
+	s.PaymentDueDays = dueDays
+	s.EarlyPaymentDays = discountDays
+	s.EarlyPaymentDiscount = discount
+	s.PaymentTerms = fmt.Sprintf("Net %d", dueDays)
+	if discountDays > 0 {
+		s.PaymentTerms = fmt.Sprintf("%s/%d Net %d", discount.String(), discountDays, dueDays)
+	}
+	return nil
 }
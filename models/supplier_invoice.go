@@ -0,0 +1,132 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Supplier invoice statuses
+const (
+	SupplierInvoiceStatusPending  = "pending"
+	SupplierInvoiceStatusApproved = "approved"
+	SupplierInvoiceStatusRejected = "rejected"
+)
+
+// Supplier invoice payment statuses
+const (
+	InvoicePaymentUnpaid        = "unpaid"
+	InvoicePaymentPartiallyPaid = "partially_paid"
+	InvoicePaymentPaid          = "paid"
+)
+
+// Invoice match statuses, from best to worst
+const (
+	InvoiceMatchMatched  = "matched"
+	InvoiceMatchVariance = "variance"
+	InvoiceMatchBlocked  = "blocked"
+)
+
+// invoiceMatchSeverity orders the match statuses so an invoice takes the worst of its lines
+var invoiceMatchSeverity = map[string]int{
+	InvoiceMatchMatched:  0,
+	InvoiceMatchVariance: 1,
+	InvoiceMatchBlocked:  2,
+}
+
+// SupplierInvoice is a bill a supplier sent for goods of one purchasing
+// Its lines are matched against the purchasing lines and what was received (three-way match);
+// MatchStatus is the worst status of the lines and decides whether the invoice can be approved
+// for payment. Amounts are in Currency, the purchasing's currency.
+type SupplierInvoice struct {
+	ID              uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	SupplierID      uint            `gorm:"not null;uniqueIndex:idx_supplier_invoice_number,priority:1" json:"supplierId"`
+	PurchasingID    uint            `gorm:"not null;index" json:"purchasingId"`
+	InvoiceNumber   string          `gorm:"type:varchar(50);not null;uniqueIndex:idx_supplier_invoice_number,priority:2" json:"invoiceNumber"`
+	InvoiceDate     time.Time       `gorm:"type:datetime;not null;index" json:"invoiceDate"`
+	Currency        string          `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+	SubTotal        decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"subTotal"`
+	TaxAmount       decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"taxAmount"`
+	Total           decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"total"`
+	Status          string          `gorm:"type:varchar(20);not null;index" json:"status"`
+	MatchStatus     string          `gorm:"type:varchar(20);not null;index" json:"matchStatus"`
+	MatchedAt       time.Time       `gorm:"type:datetime;not null" json:"matchedAt"`
+	Note            string          `gorm:"type:text" json:"note"`
+	CreatedBy       uint            `gorm:"not null" json:"createdBy"`
+	DecidedBy       *uint           `json:"decidedBy"`
+	DecisionComment string          `gorm:"type:text" json:"decisionComment"`
+	DecidedAt       *time.Time      `gorm:"type:datetime" json:"decidedAt"`
+	CreatedAt       time.Time       `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt       time.Time       `gorm:"type:datetime;not null" json:"updatedAt"`
+
+	// Payment terms of the supplier when the invoice was entered. The invoice is due on DueDate;
+	// paid in full by DiscountDueDate, EarlyPaymentDiscount may be deducted. The balance still
+	// owed is Total less PaidAmount and DiscountTaken.
+	PaymentTerms         string          `gorm:"type:varchar(30);not null;default:''" json:"paymentTerms"`
+	DueDate              *time.Time      `gorm:"type:datetime;index" json:"dueDate"`
+	DiscountDueDate      *time.Time      `gorm:"type:datetime" json:"discountDueDate"`
+	EarlyPaymentDiscount decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"earlyPaymentDiscount"`
+	PaidAmount           decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"paidAmount"`
+	DiscountTaken        decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"discountTaken"`
+	PaymentStatus        string          `gorm:"type:varchar(20);not null;default:unpaid;index" json:"paymentStatus"`
+	PaidAt               *time.Time      `gorm:"type:datetime" json:"paidAt"`
+
+	// Relationships
+	Supplier   Supplier              `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"supplier,omitempty"`
+	Purchasing Purchasing            `gorm:"foreignKey:PurchasingID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	Lines      []SupplierInvoiceLine `gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE" json:"lines,omitempty"`
+	Payments   []SupplierPayment     `gorm:"foreignKey:InvoiceID;constraint:OnDelete:RESTRICT" json:"payments,omitempty"`
+}
+
+// SupplierInvoiceLine is the quantity and price billed for one purchasing line
+// Qty and UnitPrice are in the purchasing line's unit. The match fields record what the line was
+// compared with: POUnitPrice is the purchasing line's net unit price (after discounts, before
+// tax), ReceivedQty what had been received and InvoicedQty what other open or approved invoices
+// already billed. PriceVariance is what the line costs above or below the purchasing price.
+type SupplierInvoiceLine struct {
+	ID                 uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	InvoiceID          uint            `gorm:"not null;index" json:"invoiceId"`
+	PurchasingDetailID uint            `gorm:"not null;index" json:"purchasingDetailId"`
+	ItemID             uint            `gorm:"not null;index" json:"itemId"`
+	Qty                int             `gorm:"not null" json:"qty"`
+	UnitPrice          decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"unitPrice"`
+	Amount             decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+	POUnitPrice        decimal.Decimal `gorm:"type:decimal(15,4);not null;default:0" json:"poUnitPrice"`
+	ReceivedQty        int             `gorm:"not null;default:0" json:"receivedQty"`
+	InvoicedQty        int             `gorm:"not null;default:0" json:"invoicedQty"`
+	PriceVariance      decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"priceVariance"`
+	MatchStatus        string          `gorm:"type:varchar(20);not null" json:"matchStatus"`
+	MatchNote          string          `gorm:"type:varchar(255)" json:"matchNote"`
+
+	// Relationships
+	PurchasingDetail PurchasingDetail `gorm:"foreignKey:PurchasingDetailID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+	Item             Item             `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"item,omitempty"`
+}
+
+// Balance is what is still owed on the invoice
+func (i *SupplierInvoice) Balance() decimal.Decimal {
+	return i.Total.Sub(i.PaidAmount).Sub(i.DiscountTaken)
+}
+
+// WorseInvoiceMatch returns the worse of two match statuses
+func WorseInvoiceMatch(a, b string) string {
+	if invoiceMatchSeverity[b] > invoiceMatchSeverity[a] {
+		return b
+	}
+	return a
+}
+
+// IsValidInvoiceMatchStatus reports whether status is a known match status
+func IsValidInvoiceMatchStatus(status string) bool {
+	_, ok := invoiceMatchSeverity[status]
+	return ok
+}
+
+// IsValidInvoicePaymentStatus reports whether status is a known payment status
+func IsValidInvoicePaymentStatus(status string) bool {
+	switch status {
+	case InvoicePaymentUnpaid, InvoicePaymentPartiallyPaid, InvoicePaymentPaid:
+		return true
+	}
+	return false
+}
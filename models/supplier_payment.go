@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SupplierPayment is a full or partial payment of an approved supplier invoice
+// Amount is what was paid, in the invoice's currency. DiscountTaken is the early payment
+// discount deducted with it; it is only taken by the payment that settles the invoice
+// within the discount period.
+type SupplierPayment struct {
+	ID            uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	InvoiceID     uint            `gorm:"not null;index" json:"invoiceId"`
+	SupplierID    uint            `gorm:"not null;index" json:"supplierId"`
+	PaymentDate   time.Time       `gorm:"type:datetime;not null;index" json:"paymentDate"`
+	Amount        decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"amount"`
+	DiscountTaken decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"discountTaken"`
+	Reference     string          `gorm:"type:varchar(100)" json:"reference"`
+	Note          string          `gorm:"type:text" json:"note"`
+	CreatedBy     uint            `gorm:"not null" json:"createdBy"`
+	CreatedAt     time.Time       `gorm:"type:datetime;not null" json:"createdAt"`
+
+	// Relationships
+	Supplier Supplier `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"-"`
+}
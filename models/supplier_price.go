@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SupplierPrice is one entry of a supplier's price list: the price of one base unit of an item
+// from MinQty base units up, valid from ValidFrom through ValidTo (open-ended when ValidTo is nil)
+// Entries are never changed once they are in effect, so together they are the item's price
+// history; a price change is a new entry, which ends the open-ended entry it replaces.
+type SupplierPrice struct {
+	ID         uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	SupplierID uint            `gorm:"not null;index:idx_supplier_price_lookup,priority:1" json:"supplierId"`
+	ItemID     uint            `gorm:"not null;index:idx_supplier_price_lookup,priority:2;index" json:"itemId"`
+	MinQty     int             `gorm:"not null;default:1;index:idx_supplier_price_lookup,priority:3" json:"minQty"`
+	Price      decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"price"`
+	ValidFrom  time.Time       `gorm:"type:date;not null;index:idx_supplier_price_lookup,priority:4" json:"validFrom"`
+	ValidTo    *time.Time      `gorm:"type:date" json:"validTo"`
+	Note       string          `gorm:"type:text" json:"note"`
+	CreatedBy  uint            `gorm:"not null" json:"createdBy"`
+	CreatedAt  time.Time       `gorm:"type:datetime;not null" json:"createdAt"`
+
+	// Relationships
+	Supplier Supplier `gorm:"foreignKey:SupplierID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"supplier,omitempty"`
+	Item     Item     `gorm:"foreignKey:ItemID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"item,omitempty"`
+}
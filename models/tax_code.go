@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Tax kinds
+const (
+	TaxKindVAT         = "vat"         // added to the amount payable, e.g. PPN
+	TaxKindWithholding = "withholding" // withheld from the amount payable, e.g. PPh 23
+)
+
+// TaxCode is a tax that can be charged on purchasing lines
+// Rate is a percentage of the line's net amount. Purchasing lines keep the rate they were
+// priced with, so a changed rate only applies to new purchasings.
+type TaxCode struct {
+	ID        uint            `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code      string          `gorm:"type:varchar(20);not null;uniqueIndex" json:"code"`
+	Name      string          `gorm:"type:varchar(100);not null" json:"name"`
+	Kind      string          `gorm:"type:varchar(20);not null" json:"kind"`
+	Rate      decimal.Decimal `gorm:"type:decimal(7,4);not null" json:"rate"`
+	Active    bool            `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time       `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt time.Time       `gorm:"type:datetime;not null" json:"updatedAt"`
+}
+
+// IsValidTaxKind reports whether kind is a known tax kind
+func IsValidTaxKind(kind string) bool {
+	return kind == TaxKindVAT || kind == TaxKindWithholding
+}
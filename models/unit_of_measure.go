@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// DefaultUnitCode is the code of the unit given to items that predate units of measure
+const DefaultUnitCode = "PCS"
+
+// UnitOfMeasure is a unit items are counted in, such as PCS, REAM or BOX
+type UnitOfMeasure struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code      string    `gorm:"type:varchar(20);not null;uniqueIndex" json:"code"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	CreatedAt time.Time `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"type:datetime;not null" json:"updatedAt"`
+}
+
+// ItemUnit is an alternative unit an item can be purchased in
+// Factor is how many of the item's base unit one of this unit holds, e.g. 5 when a box holds
+// 5 reams and the item is stocked by the ream.
+type ItemUnit struct {
+	ID     uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	ItemID uint           `gorm:"not null;uniqueIndex:idx_item_unit,priority:1" json:"itemId"`
+	UnitID uint           `gorm:"not null;uniqueIndex:idx_item_unit,priority:2;index" json:"unitId"`
+	Factor int            `gorm:"not null" json:"factor"`
+	Unit   *UnitOfMeasure `gorm:"foreignKey:UnitID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"unit,omitempty"`
+}
@@ -1,9 +1,15 @@
 package models
 
+// User roles
+const (
+	RoleAdmin = "admin"
+	RoleStaff = "staff"
+)
+
 // User represents a user account in the system
 type User struct {
 	ID       uint   `gorm:"primaryKey;autoIncrement" json:"id"`
 	Username string `gorm:"type:varchar(50);not null;unique" json:"username"`
-	Password string `gorm:"type:varchar(255);not null" json:"password"`
+	Password string `gorm:"type:varchar(255);not null" json:"-"`
 	Role     string `gorm:"type:varchar(20);not null" json:"role"`
 }
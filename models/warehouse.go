@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// DefaultWarehouseCode is the code of the warehouse created for stock that predates warehouses
+const DefaultWarehouseCode = "MAIN"
+
+// Warehouse is a location that holds stock
+// Exactly one warehouse is the default: it receives stock when a document names no warehouse.
+type Warehouse struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code      string    `gorm:"type:varchar(20);not null;uniqueIndex" json:"code"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Address   string    `gorm:"type:text" json:"address"`
+	IsDefault bool      `gorm:"not null;default:false" json:"isDefault"`
+	CreatedAt time.Time `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"type:datetime;not null" json:"updatedAt"`
+}
+
+// WarehouseStock is the balance of one item in one warehouse
+// Item.Stock is the sum of an item's warehouse balances; both change together through the
+// stock ledger.
+type WarehouseStock struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ItemID      uint       `gorm:"not null;uniqueIndex:idx_warehouse_stock_item,priority:1" json:"itemId"`
+	WarehouseID uint       `gorm:"not null;uniqueIndex:idx_warehouse_stock_item,priority:2;index" json:"warehouseId"`
+	Quantity    int        `gorm:"not null;default:0" json:"quantity"`
+	Warehouse   *Warehouse `gorm:"foreignKey:WarehouseID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"warehouse,omitempty"`
+}
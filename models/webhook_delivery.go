@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// WebhookDelivery records one delivery attempt of a webhook event
+// StatusCode is 0 when no response was received; Error is empty for successful attempts.
+type WebhookDelivery struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	EventID        uint      `gorm:"not null;index" json:"eventId"`
+	SubscriptionID *uint     `gorm:"index" json:"subscriptionId"`
+	DeliveryID     string    `gorm:"type:varchar(64);not null" json:"deliveryId"`
+	EventType      string    `gorm:"type:varchar(50);not null" json:"eventType"`
+	Attempt        int       `gorm:"not null" json:"attempt"`
+	Success        bool      `gorm:"not null" json:"success"`
+	StatusCode     int       `gorm:"not null;default:0" json:"statusCode"`
+	LatencyMs      int64     `gorm:"not null" json:"latencyMs"`
+	Error          string    `gorm:"type:text" json:"error"`
+	AttemptedAt    time.Time `gorm:"type:datetime;not null" json:"attemptedAt"`
+}
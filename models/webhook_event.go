@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Webhook event statuses
+const (
+	WebhookEventStatusPending   = "pending"
+	WebhookEventStatusDelivered = "delivered"
+	WebhookEventStatusDead      = "dead"
+)
+
+// Webhook event types
+const (
+	WebhookEventPurchasingCreated = "purchasing.created"
+	WebhookEventItemCreated       = "item.created"
+	WebhookEventItemUpdated       = "item.updated"
+	WebhookEventItemDeleted       = "item.deleted"
+	WebhookEventStockLow          = "stock.low"
+	WebhookEventSupplierCreated   = "supplier.created"
+	WebhookEventSupplierUpdated   = "supplier.updated"
+	WebhookEventSupplierDeleted   = "supplier.deleted"
+	WebhookEventContractExpiring  = "contract.expiring"
+)
+
+// WebhookEventTypes lists every event type subscriptions can choose from
+var WebhookEventTypes = []string{
+	WebhookEventPurchasingCreated,
+	WebhookEventItemCreated,
+	WebhookEventItemUpdated,
+	WebhookEventItemDeleted,
+	WebhookEventStockLow,
+	WebhookEventSupplierCreated,
+	WebhookEventSupplierUpdated,
+	WebhookEventSupplierDeleted,
+	WebhookEventContractExpiring,
+}
+
+// IsValidWebhookEventType reports whether the event type is one subscriptions can choose
+func IsValidWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEvent is an outbox entry for one webhook delivery
+// Events are written in the same database transaction as the change they describe and
+// delivered afterwards by the background dispatcher, so a crash or an unreachable receiver
+// never loses them. After MaxAttempts failed deliveries the event is moved to the dead state
+// and stays there until it is replayed. DeliveryID stays the same across retries and replays
+// so receivers can recognise events they already processed. SubscriptionID is nil for events
+// sent to WEBHOOK_URL or an X-Webhook-URL override.
+type WebhookEvent struct {
+	ID             uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeliveryID     string     `gorm:"type:varchar(64);not null;index" json:"deliveryId"`
+	EventType      string     `gorm:"type:varchar(50);not null;index" json:"eventType"`
+	SubscriptionID *uint      `gorm:"index" json:"subscriptionId"`
+	TargetURL      string     `gorm:"type:varchar(2048);not null" json:"targetUrl"`
+	Payload        string     `gorm:"type:longtext;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(20);not null;index:idx_webhook_events_due,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"type:datetime;not null;index:idx_webhook_events_due,priority:2" json:"nextAttemptAt"`
+	LastStatusCode int        `gorm:"not null;default:0" json:"lastStatusCode"`
+	LastError      string     `gorm:"type:text" json:"lastError"`
+	CreatedAt      time.Time  `gorm:"type:datetime;not null" json:"createdAt"`
+	DeliveredAt    *time.Time `gorm:"type:datetime" json:"deliveredAt"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// WebhookSubscription is a persisted webhook receiver
+// Each subscription receives only the event types listed in Events and signs its deliveries
+// with its own Secret. A paused subscription receives no new events; events already queued
+// for it are held until it is resumed.
+type WebhookSubscription struct {
+	ID        uint                       `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name      string                     `gorm:"type:varchar(100);not null" json:"name"`
+	URL       string                     `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret    string                     `gorm:"type:varchar(255);not null" json:"-"`
+	Paused    bool                       `gorm:"not null;default:false;index" json:"paused"`
+	CreatedBy uint                       `gorm:"not null" json:"createdBy"`
+	CreatedAt time.Time                  `gorm:"type:datetime;not null" json:"createdAt"`
+	UpdatedAt time.Time                  `gorm:"type:datetime;not null" json:"updatedAt"`
+	Events    []WebhookSubscriptionEvent `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"events"`
+}
+
+// WebhookSubscriptionEvent is one event type a subscription receives
+type WebhookSubscriptionEvent struct {
+	ID             uint   `gorm:"primaryKey;autoIncrement" json:"-"`
+	SubscriptionID uint   `gorm:"not null;uniqueIndex:idx_webhook_subscription_event" json:"-"`
+	EventType      string `gorm:"type:varchar(50);not null;uniqueIndex:idx_webhook_subscription_event;index" json:"eventType"`
+}
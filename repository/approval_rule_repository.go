@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"errors"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// ApprovalRuleRepository handles approval rule data operations
+type ApprovalRuleRepository struct{}
+
+// NewApprovalRuleRepository creates a new ApprovalRuleRepository instance
+func NewApprovalRuleRepository() *ApprovalRuleRepository {
+	return &ApprovalRuleRepository{}
+}
+
+// FindByID finds an approval rule by ID
+func (r *ApprovalRuleRepository) FindByID(id uint) (*models.ApprovalRule, error) {
+	var rule models.ApprovalRule
+	result := config.DB.First(&rule, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &rule, nil
+}
+
+// GetAll retrieves all approval rules ordered by threshold
+func (r *ApprovalRuleRepository) GetAll() ([]models.ApprovalRule, error) {
+	var rules []models.ApprovalRule
+	result := config.DB.Order("min_amount ASC").Find(&rules)
+	return rules, result.Error
+}
+
+// FindApplicableWithTx returns the rule with the highest threshold strictly below amount
+// A nil rule with a nil error means no approval is required for the amount.
+func (r *ApprovalRuleRepository) FindApplicableWithTx(tx *gorm.DB, amount decimal.Decimal) (*models.ApprovalRule, error) {
+	var rule models.ApprovalRule
+	result := tx.Where("min_amount < ?", amount).Order("min_amount DESC").First(&rule)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &rule, nil
+}
+
+// Create creates a new approval rule
+func (r *ApprovalRuleRepository) Create(rule *models.ApprovalRule) error {
+	result := config.DB.Create(rule)
+	return result.Error
+}
+
+// Update updates an existing approval rule
+func (r *ApprovalRuleRepository) Update(rule *models.ApprovalRule) error {
+	result := config.DB.Save(rule)
+	return result.Error
+}
+
+// Delete deletes an approval rule by ID
+func (r *ApprovalRuleRepository) Delete(id uint) error {
+	result := config.DB.Delete(&models.ApprovalRule{}, id)
+	return result.Error
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Budget errors
+var (
+	ErrBudgetPeriodOverlap = errors.New("the department already has a budget for part of this period")
+	ErrBudgetInUse         = errors.New("budget has purchasings charged to it")
+	ErrBudgetBelowUsed     = errors.New("budget amount cannot be less than what is encumbered and spent")
+)
+
+// BudgetExceededError is returned when a purchasing does not fit in the remaining hard budget
+type BudgetExceededError struct {
+	BudgetID  uint
+	Required  decimal.Decimal
+	Remaining decimal.Decimal
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("purchasing of %s exceeds the remaining budget of %s (budget %d)",
+		e.Required.StringFixed(2), e.Remaining.StringFixed(2), e.BudgetID)
+}
+
+// BudgetRepository handles department budgets and what purchasings commit and spend against them
+// All amounts are in the base currency.
+type BudgetRepository struct{}
+
+// NewBudgetRepository creates a new BudgetRepository instance
+func NewBudgetRepository() *BudgetRepository {
+	return &BudgetRepository{}
+}
+
+// FindByID finds a budget by ID with its department
+func (r *BudgetRepository) FindByID(id uint) (*models.Budget, error) {
+	var budget models.Budget
+	result := config.DB.Preload("Department").First(&budget, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &budget, nil
+}
+
+// BudgetFilter holds the optional criteria for listing budgets
+// Zero values mean "no filter" for that field.
+type BudgetFilter struct {
+	DepartmentID uint
+	ActiveOn     *time.Time // budgets whose period contains the day
+}
+
+// GetAll retrieves the budgets matching the filter, latest period first
+func (r *BudgetRepository) GetAll(filter BudgetFilter) ([]models.Budget, error) {
+	query := config.DB.Preload("Department").Order("period_start DESC, department_id ASC")
+	if filter.DepartmentID != 0 {
+		query = query.Where("department_id = ?", filter.DepartmentID)
+	}
+	if filter.ActiveOn != nil {
+		day := filter.ActiveOn.Format(dateLayout)
+		query = query.Where("period_start <= ? AND period_end >= ?", day, day)
+	}
+
+	budgets := []models.Budget{}
+	result := query.Find(&budgets)
+	return budgets, result.Error
+}
+
+// Create creates a budget; ErrBudgetPeriodOverlap is returned when the department already has
+// a budget for part of the period
+func (r *BudgetRepository) Create(budget *models.Budget) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the department so two overlapping budgets cannot be created side by side
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Department{}, budget.DepartmentID).Error; err != nil {
+			return err
+		}
+
+		var overlapping int64
+		if err := tx.Model(&models.Budget{}).
+			Where("department_id = ? AND period_start <= ? AND period_end >= ?",
+				budget.DepartmentID, budget.PeriodEnd.Format(dateLayout), budget.PeriodStart.Format(dateLayout)).
+			Count(&overlapping).Error; err != nil {
+			return err
+		}
+		if overlapping > 0 {
+			return ErrBudgetPeriodOverlap
+		}
+
+		return tx.Omit("Department").Create(budget).Error
+	})
+}
+
+// UpdateTransaction changes a budget's amount, control and note
+// The period and department stay fixed once purchasings may be charged to the budget, and the
+// amount cannot drop below what is already encumbered and spent (ErrBudgetBelowUsed).
+func (r *BudgetRepository) UpdateTransaction(id uint, amount decimal.Decimal, control, note string) (*models.Budget, error) {
+	var budget models.Budget
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&budget, id).Error; err != nil {
+			return err
+		}
+		if amount.LessThan(budget.Encumbered.Add(budget.Spent)) {
+			return ErrBudgetBelowUsed
+		}
+
+		budget.Amount = amount
+		budget.Control = control
+		budget.Note = note
+		budget.UpdatedAt = time.Now()
+		return tx.Model(&budget).Updates(map[string]interface{}{
+			"amount":     budget.Amount,
+			"control":    budget.Control,
+			"note":       budget.Note,
+			"updated_at": budget.UpdatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// Delete deletes a budget no purchasing is charged to
+// Otherwise ErrBudgetInUse is returned.
+func (r *BudgetRepository) Delete(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var budget models.Budget
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&budget, id).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.Purchasing{}).Where("budget_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 || !budget.Encumbered.IsZero() || !budget.Spent.IsZero() {
+			return ErrBudgetInUse
+		}
+
+		return tx.Delete(&budget).Error
+	})
+}
+
+// CheckWithTx checks a new purchasing against the budget of its department for its date
+// The purchasing is charged to that budget. When its base total is more than the remaining
+// budget, a BudgetExceededError is returned for a hard budget, while a soft budget lets it through
+// marked OverBudget. Purchasings without a department, or of a period without a budget, are not
+// controlled.
+func (r *BudgetRepository) CheckWithTx(tx *gorm.DB, purchasing *models.Purchasing) error {
+	if purchasing.DepartmentID == nil {
+		return nil
+	}
+
+	budget, err := r.findForDateWithTx(tx, *purchasing.DepartmentID, purchasing.Date)
+	if err != nil || budget == nil {
+		return err
+	}
+
+	purchasing.BudgetID = &budget.ID
+	purchasing.OverBudget = false
+	if remaining := budget.Remaining(); purchasing.BaseGrandTotal.GreaterThan(remaining) {
+		if budget.Control == models.BudgetControlHard {
+			return &BudgetExceededError{BudgetID: budget.ID, Required: purchasing.BaseGrandTotal, Remaining: remaining}
+		}
+		purchasing.OverBudget = true
+	}
+	return nil
+}
+
+// EncumberWithTx commits an approved purchasing's base total against its department's budget
+// A hard budget refuses a purchasing it no longer covers with a BudgetExceededError; a soft one
+// takes it and the purchasing is marked OverBudget.
+func (r *BudgetRepository) EncumberWithTx(tx *gorm.DB, purchasing *models.Purchasing) error {
+	if purchasing.DepartmentID == nil {
+		return nil
+	}
+
+	var budget *models.Budget
+	var err error
+	if purchasing.BudgetID != nil {
+		budget, err = r.lockWithTx(tx, *purchasing.BudgetID)
+	} else {
+		budget, err = r.findForDateWithTx(tx, *purchasing.DepartmentID, purchasing.Date)
+	}
+	if err != nil || budget == nil {
+		return err
+	}
+
+	overBudget := purchasing.BaseGrandTotal.GreaterThan(budget.Remaining())
+	if overBudget && budget.Control == models.BudgetControlHard {
+		return &BudgetExceededError{BudgetID: budget.ID, Required: purchasing.BaseGrandTotal, Remaining: budget.Remaining()}
+	}
+
+	if err := r.adjustWithTx(tx, budget, purchasing.BaseGrandTotal, decimal.Zero); err != nil {
+		return err
+	}
+
+	purchasing.BudgetID = &budget.ID
+	purchasing.EncumberedAmount = purchasing.BaseGrandTotal
+	purchasing.OverBudget = overBudget
+	return tx.Model(purchasing).Updates(map[string]interface{}{
+		"budget_id":         budget.ID,
+		"encumbered_amount": purchasing.EncumberedAmount,
+		"over_budget":       purchasing.OverBudget,
+	}).Error
+}
+
+// ReleaseWithTx releases what a cancelled purchasing still has encumbered
+func (r *BudgetRepository) ReleaseWithTx(tx *gorm.DB, purchasing *models.Purchasing) error {
+	if purchasing.BudgetID == nil || !purchasing.EncumberedAmount.IsPositive() {
+		return nil
+	}
+
+	budget, err := r.lockWithTx(tx, *purchasing.BudgetID)
+	if err != nil {
+		return err
+	}
+	if err := r.adjustWithTx(tx, budget, purchasing.EncumberedAmount.Neg(), decimal.Zero); err != nil {
+		return err
+	}
+
+	purchasing.EncumberedAmount = decimal.Zero
+	return tx.Model(purchasing).Update("encumbered_amount", purchasing.EncumberedAmount).Error
+}
+
+// ConsumeWithTx records an approved invoice of the purchasing as spending of its budget
+// baseAmount is the invoice total in the base currency. It is released from the purchasing's
+// encumbrance, as far as that goes, and added to what the budget spent.
+func (r *BudgetRepository) ConsumeWithTx(tx *gorm.DB, purchasing *models.Purchasing, baseAmount decimal.Decimal) error {
+	if purchasing.BudgetID == nil {
+		return nil
+	}
+
+	budget, err := r.lockWithTx(tx, *purchasing.BudgetID)
+	if err != nil {
+		return err
+	}
+
+	released := decimal.Min(baseAmount, purchasing.EncumberedAmount)
+	if released.IsNegative() {
+		released = decimal.Zero
+	}
+	if err := r.adjustWithTx(tx, budget, released.Neg(), baseAmount); err != nil {
+		return err
+	}
+
+	purchasing.EncumberedAmount = purchasing.EncumberedAmount.Sub(released)
+	return tx.Model(purchasing).Update("encumbered_amount", purchasing.EncumberedAmount).Error
+}
+
+// findForDateWithTx locks the department's budget whose period contains the day, or returns nil when there is none
+// date is an instant, such as a purchasing date; its local calendar day is used.
+func (r *BudgetRepository) findForDateWithTx(tx *gorm.DB, departmentID uint, date time.Time) (*models.Budget, error) {
+	day := utils.DateOf(date).Format(dateLayout)
+	var budget models.Budget
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("department_id = ? AND period_start <= ? AND period_end >= ?", departmentID, day, day).
+		Limit(1).
+		Find(&budget)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &budget, nil
+}
+
+// lockWithTx loads a budget and locks its row until the transaction ends
+func (r *BudgetRepository) lockWithTx(tx *gorm.DB, id uint) (*models.Budget, error) {
+	var budget models.Budget
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&budget, id).Error; err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// adjustWithTx adds to a locked budget's encumbered and spent amounts
+func (r *BudgetRepository) adjustWithTx(tx *gorm.DB, budget *models.Budget, encumbered, spent decimal.Decimal) error {
+	budget.Encumbered = budget.Encumbered.Add(encumbered)
+	budget.Spent = budget.Spent.Add(spent)
+	budget.UpdatedAt = time.Now()
+	return tx.Model(budget).Updates(map[string]interface{}{
+		"encumbered": budget.Encumbered,
+		"spent":      budget.Spent,
+		"updated_at": budget.UpdatedAt,
+	}).Error
+}
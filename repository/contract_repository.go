@@ -0,0 +1,394 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Contract errors
+var (
+	ErrContractPeriodOverlap  = errors.New("the supplier already has a contract for part of this period")
+	ErrContractInUse          = errors.New("contract has purchasing lines checked against it")
+	ErrContractReferenceTaken = errors.New("contract reference already exists")
+)
+
+// ContractViolationError is returned when a purchasing line deviates from a contract that blocks deviations
+type ContractViolationError struct {
+	ItemID     uint
+	ContractID uint
+	Violation  string
+}
+
+func (e *ContractViolationError) Error() string {
+	if e.Violation == models.ContractViolationNotContracted {
+		return fmt.Sprintf("item %d is under contract %d with another supplier", e.ItemID, e.ContractID)
+	}
+	return fmt.Sprintf("item %d is priced above its price in contract %d", e.ItemID, e.ContractID)
+}
+
+// ContractExpiry is a contract in force that is about to expire
+type ContractExpiry struct {
+	Contract models.Contract `json:"contract"`
+	DaysLeft int             `json:"daysLeft"`
+}
+
+// ContractRepository handles supplier contracts and checks purchasings against them
+type ContractRepository struct {
+	eventRepo *WebhookEventRepository
+}
+
+// NewContractRepository creates a new ContractRepository instance
+func NewContractRepository() *ContractRepository {
+	return &ContractRepository{
+		eventRepo: NewWebhookEventRepository(),
+	}
+}
+
+// FindByID finds a contract by ID with its supplier and agreed prices
+func (r *ContractRepository) FindByID(id uint) (*models.Contract, error) {
+	var contract models.Contract
+	result := config.DB.Preload("Supplier").Preload("Items").Preload("Items.Item").First(&contract, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &contract, nil
+}
+
+// ContractFilter holds the optional criteria for listing contracts
+// Zero values mean "no filter" for that field.
+type ContractFilter struct {
+	SupplierID uint
+	ItemID     uint       // contracts agreeing a price for the item
+	ActiveOn   *time.Time // contracts in force on the day
+}
+
+// GetAll retrieves the contracts matching the filter, latest first
+func (r *ContractRepository) GetAll(filter ContractFilter) ([]models.Contract, error) {
+	query := config.DB.Preload("Supplier").Preload("Items").Order("valid_from DESC, id DESC")
+	if filter.SupplierID != 0 {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if filter.ItemID != 0 {
+		query = query.Where("id IN (?)", config.DB.Model(&models.ContractItem{}).Select("contract_id").Where("item_id = ?", filter.ItemID))
+	}
+	if filter.ActiveOn != nil {
+		day := filter.ActiveOn.Format(dateLayout)
+		query = query.Where("valid_from <= ? AND valid_to >= ?", day, day)
+	}
+
+	contracts := []models.Contract{}
+	result := query.Find(&contracts)
+	return contracts, result.Error
+}
+
+// Create creates a contract with its agreed prices; ErrContractPeriodOverlap is returned when the
+// supplier already has a contract for part of the period and ErrContractReferenceTaken when the
+// reference is used by another contract
+func (r *ContractRepository) Create(contract *models.Contract, items []models.ContractItem) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the supplier so two overlapping contracts cannot be created side by side
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Supplier{}, contract.SupplierID).Error; err != nil {
+			return err
+		}
+		if err := r.checkOverlapWithTx(tx, contract); err != nil {
+			return err
+		}
+
+		var taken int64
+		if err := tx.Model(&models.Contract{}).Where("reference = ?", contract.Reference).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrContractReferenceTaken
+		}
+
+		// The unique index still catches a contract of another supplier created meanwhile
+		if err := tx.Omit("Supplier", "Items").Create(contract).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrContractReferenceTaken
+			}
+			return err
+		}
+		if err := r.replaceItemsWithTx(tx, contract.ID, items); err != nil {
+			return err
+		}
+		contract.Items = items
+		return nil
+	})
+}
+
+// UpdateTransaction changes a contract and replaces its agreed prices
+// The supplier and start of a contract stay fixed; moving its end re-arms the expiry alert.
+func (r *ContractRepository) UpdateTransaction(update *models.Contract, items []models.ContractItem) (*models.Contract, error) {
+	var contract models.Contract
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Supplier{}, update.SupplierID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&contract, update.ID).Error; err != nil {
+			return err
+		}
+
+		if contract.ValidTo.Format(dateLayout) != update.ValidTo.Format(dateLayout) {
+			contract.ValidTo = update.ValidTo
+			contract.ExpiryAlertedAt = nil
+			if err := r.checkOverlapWithTx(tx, &contract); err != nil {
+				return err
+			}
+		}
+		contract.Title = update.Title
+		contract.CommittedSpend = update.CommittedSpend
+		contract.Enforcement = update.Enforcement
+		contract.Terms = update.Terms
+		contract.AlertDays = update.AlertDays
+		contract.UpdatedAt = time.Now()
+		if err := tx.Model(&contract).Select(
+			"title", "valid_to", "committed_spend", "enforcement", "terms", "alert_days", "expiry_alerted_at", "updated_at",
+		).Updates(&contract).Error; err != nil {
+			return err
+		}
+
+		if err := r.replaceItemsWithTx(tx, contract.ID, items); err != nil {
+			return err
+		}
+		contract.Items = items
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &contract, nil
+}
+
+// Delete deletes a contract no purchasing line was checked against
+// Otherwise ErrContractInUse is returned; end the contract early instead.
+func (r *ContractRepository) Delete(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var contract models.Contract
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&contract, id).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.PurchasingDetail{}).Where("contract_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrContractInUse
+		}
+
+		if err := tx.Where("contract_id = ?", id).Delete(&models.ContractItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&contract).Error
+	})
+}
+
+// Spend totals the purchasings placed with the contract's supplier during the contract, drafts
+// and cancelled ones aside, in the base currency at the purchasings' rates
+func (r *ContractRepository) Spend(contract *models.Contract) (decimal.Decimal, error) {
+	var spent decimal.Decimal
+	err := config.DB.Model(&models.Purchasing{}).
+		Select("COALESCE(SUM(base_grand_total), 0)").
+		Where("supplier_id = ? AND status NOT IN ?", contract.SupplierID,
+			[]string{models.PurchasingStatusDraft, models.PurchasingStatusCancelled}).
+		Where("date >= ? AND date < ?", utils.StartOfDate(contract.ValidFrom), utils.StartOfDate(contract.ValidTo.AddDate(0, 0, 1))).
+		Scan(&spent).Error
+	return spent, err
+}
+
+// Expiring lists the contracts in force on asOf that expire within their alert period, soonest first
+func (r *ContractRepository) Expiring(asOf time.Time) ([]ContractExpiry, error) {
+	return r.expiringWithTx(config.DB, asOf, false)
+}
+
+// NotifyExpiringTransaction publishes a contract.expiring event for every contract that entered its
+// alert period and was not alerted yet, and records the alert; it returns the contracts alerted
+func (r *ContractRepository) NotifyExpiringTransaction(asOf time.Time) ([]ContractExpiry, error) {
+	var alerted []ContractExpiry
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		alerted, err = r.expiringWithTx(tx, asOf, true)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for i := range alerted {
+			contract := &alerted[i].Contract
+			if err := tx.Model(contract).Update("expiry_alerted_at", now).Error; err != nil {
+				return err
+			}
+			contract.ExpiryAlertedAt = &now
+
+			payload := utils.NewEventPayload(models.WebhookEventContractExpiring, alerted[i])
+			if err := r.eventRepo.PublishWithTx(tx, models.WebhookEventContractExpiring, payload); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return alerted, nil
+}
+
+// CheckWithTx checks the lines of a new purchasing against the contracts in force on its date
+// A line for an item the supplier has a contract price for is checked against that price, after
+// discounts and per base unit; a line for an item only other suppliers have contracts for is
+// ordered from a non-contracted supplier. Deviating lines are marked with the contract and the
+// violation, and the purchasing OffContract, unless the contract blocks deviations: then a
+// ContractViolationError is returned. Must run after the line amounts are calculated.
+func (r *ContractRepository) CheckWithTx(tx *gorm.DB, purchasing *models.Purchasing, details []models.PurchasingDetail) error {
+	purchasing.OffContract = false
+	if len(details) == 0 {
+		return nil
+	}
+
+	itemIDs := make([]uint, 0, len(details))
+	for _, detail := range details {
+		itemIDs = append(itemIDs, detail.ItemID)
+	}
+
+	day := utils.DateOf(purchasing.Date).Format(dateLayout)
+	var terms []struct {
+		ContractID  uint
+		SupplierID  uint
+		ItemID      uint
+		UnitPrice   decimal.Decimal
+		Enforcement string
+	}
+	err := tx.Model(&models.ContractItem{}).
+		Select("contract_items.contract_id, contracts.supplier_id, contract_items.item_id, contract_items.unit_price, contracts.enforcement").
+		Joins("JOIN contracts ON contracts.id = contract_items.contract_id").
+		Where("contract_items.item_id IN ? AND contracts.valid_from <= ? AND contracts.valid_to >= ?", itemIDs, day, day).
+		Order("contracts.id ASC").
+		Scan(&terms).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range details {
+		detail := &details[i]
+		detail.ContractID = nil
+		detail.ContractViolation = ""
+
+		// The supplier's own contract decides; without one, any other supplier's contract does
+		var own, other *int
+		for j := range terms {
+			if terms[j].ItemID != detail.ItemID {
+				continue
+			}
+			j := j
+			if terms[j].SupplierID == purchasing.SupplierID {
+				own = &j
+				break
+			}
+			if other == nil || terms[j].Enforcement == models.ContractEnforcementBlock {
+				other = &j
+			}
+		}
+
+		switch {
+		case own != nil:
+			term := terms[*own]
+			detail.ContractID = &term.ContractID
+			qty := decimal.NewFromInt(int64(detail.BaseQty(detail.Qty)))
+			if qty.IsPositive() && detail.NetAmount.Div(qty).Round(2).GreaterThan(term.UnitPrice) {
+				detail.ContractViolation = models.ContractViolationPriceAbove
+			}
+			if detail.ContractViolation != "" && term.Enforcement == models.ContractEnforcementBlock {
+				return &ContractViolationError{ItemID: detail.ItemID, ContractID: term.ContractID, Violation: detail.ContractViolation}
+			}
+		case other != nil:
+			term := terms[*other]
+			detail.ContractID = &term.ContractID
+			detail.ContractViolation = models.ContractViolationNotContracted
+			if term.Enforcement == models.ContractEnforcementBlock {
+				return &ContractViolationError{ItemID: detail.ItemID, ContractID: term.ContractID, Violation: detail.ContractViolation}
+			}
+		}
+
+		if detail.ContractViolation != "" {
+			purchasing.OffContract = true
+		}
+	}
+	return nil
+}
+
+// expiringWithTx finds the contracts in force on asOf whose end is within their alert period,
+// only those not alerted yet when pendingOnly is set. asOf is an instant; its local calendar day
+// is used.
+func (r *ContractRepository) expiringWithTx(tx *gorm.DB, asOf time.Time, pendingOnly bool) ([]ContractExpiry, error) {
+	today := utils.DateOf(asOf)
+	day := today.Format(dateLayout)
+	query := tx.Preload("Supplier").
+		Where("valid_from <= ? AND valid_to >= ?", day, day).
+		Where("valid_to <= DATE_ADD(?, INTERVAL alert_days DAY)", day).
+		Order("valid_to ASC, id ASC")
+	if pendingOnly {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"}).Where("expiry_alerted_at IS NULL")
+	}
+
+	var contracts []models.Contract
+	if err := query.Find(&contracts).Error; err != nil {
+		return nil, err
+	}
+
+	// Both are calendar dates at midnight UTC, so they are whole days apart
+	expiring := make([]ContractExpiry, 0, len(contracts))
+	for _, contract := range contracts {
+		end, err := utils.ParseDate(contract.ValidTo.Format(dateLayout))
+		if err != nil {
+			return nil, err
+		}
+		expiring = append(expiring, ContractExpiry{
+			Contract: contract,
+			DaysLeft: int(end.Sub(today).Hours() / 24),
+		})
+	}
+	return expiring, nil
+}
+
+// checkOverlapWithTx returns ErrContractPeriodOverlap when another contract of the supplier
+// shares a day with the contract's period
+func (r *ContractRepository) checkOverlapWithTx(tx *gorm.DB, contract *models.Contract) error {
+	var overlapping int64
+	if err := tx.Model(&models.Contract{}).
+		Where("supplier_id = ? AND id <> ? AND valid_from <= ? AND valid_to >= ?",
+			contract.SupplierID, contract.ID, contract.ValidTo.Format(dateLayout), contract.ValidFrom.Format(dateLayout)).
+		Count(&overlapping).Error; err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return ErrContractPeriodOverlap
+	}
+	return nil
+}
+
+// replaceItemsWithTx replaces the agreed prices of a contract
+func (r *ContractRepository) replaceItemsWithTx(tx *gorm.DB, contractID uint, items []models.ContractItem) error {
+	if err := tx.Where("contract_id = ?", contractID).Delete(&models.ContractItem{}).Error; err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].ID = 0
+		items[i].ContractID = contractID
+		if err := tx.Omit("Item").Create(&items[i]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
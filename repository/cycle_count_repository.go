@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CycleCountLineError is returned when counted quantities do not fit the cycle count
+type CycleCountLineError struct {
+	ItemID  uint
+	Message string
+}
+
+func (e *CycleCountLineError) Error() string {
+	return fmt.Sprintf("item %d: %s", e.ItemID, e.Message)
+}
+
+// CycleCountRepository handles cycle count sessions
+// Variances become stock adjustments, so approved counts reach stock through the same path as
+// any other adjustment.
+type CycleCountRepository struct {
+	adjustmentRepo *StockAdjustmentRepository
+	warehouseRepo  *WarehouseRepository
+}
+
+// NewCycleCountRepository creates a new CycleCountRepository instance
+func NewCycleCountRepository() *CycleCountRepository {
+	return &CycleCountRepository{
+		adjustmentRepo: NewStockAdjustmentRepository(),
+		warehouseRepo:  NewWarehouseRepository(),
+	}
+}
+
+// FindByID finds a cycle count by ID with its lines and their items
+func (r *CycleCountRepository) FindByID(id uint) (*models.CycleCount, error) {
+	var count models.CycleCount
+	result := config.DB.Preload("Lines.Item").First(&count, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &count, nil
+}
+
+// cycleCountSortColumns maps the accepted sort keys of cycle count listings to indexed columns
+var cycleCountSortColumns = map[string]string{
+	"id": "id",
+}
+
+// List retrieves one page of cycle counts, optionally only those with the given status or warehouse
+func (r *CycleCountRepository) List(status string, warehouseID uint, params ListParams) ([]models.CycleCount, PageInfo, error) {
+	query := config.DB.Model(&models.CycleCount{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+
+	return paginate(query, params, cycleCountSortColumns, "id", func(count *models.CycleCount) (interface{}, uint) {
+		return count.ID, count.ID
+	})
+}
+
+// Create opens a cycle count with one line per item in the count's warehouse
+func (r *CycleCountRepository) Create(count *models.CycleCount, itemIDs []uint) error {
+	count.Status = models.CycleCountStatusOpen
+	count.CreatedAt = time.Now()
+	count.Lines = make([]models.CycleCountLine, len(itemIDs))
+	for i, itemID := range itemIDs {
+		count.Lines[i].ItemID = itemID
+	}
+	return config.DB.Create(count).Error
+}
+
+// RecordCountsTransaction stores counted quantities, keyed by item ID, on an open count
+// Counts may be recorded several times before submission; the last one wins.
+func (r *CycleCountRepository) RecordCountsTransaction(id uint, counts map[uint]int) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := r.findForUpdateWithTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.CycleCountStatusOpen {
+			return &StockStatusError{Document: "cycle count", CurrentStatus: count.Status, Action: "record counts on"}
+		}
+
+		var lines []models.CycleCountLine
+		if err := tx.Where("cycle_count_id = ?", id).Find(&lines).Error; err != nil {
+			return err
+		}
+		lineByItem := make(map[uint]*models.CycleCountLine, len(lines))
+		for i := range lines {
+			lineByItem[lines[i].ItemID] = &lines[i]
+		}
+
+		for itemID, counted := range counts {
+			line, ok := lineByItem[itemID]
+			if !ok {
+				return &CycleCountLineError{ItemID: itemID, Message: fmt.Sprintf("is not part of cycle count %d", id)}
+			}
+			if counted < 0 {
+				return &CycleCountLineError{ItemID: itemID, Message: "counted quantity must not be negative"}
+			}
+			if err := tx.Model(line).Update("counted_qty", counted).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SubmitTransaction closes counting, computes variances against the current stock of the count's
+// warehouse and creates a pending count_correction adjustment for every line that differs
+func (r *CycleCountRepository) SubmitTransaction(id uint, userID uint) (*models.CycleCount, error) {
+	var count *models.CycleCount
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		count, err = r.findForUpdateWithTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.CycleCountStatusOpen {
+			return &StockStatusError{Document: "cycle count", CurrentStatus: count.Status, Action: "submit"}
+		}
+
+		warehouseID, err := r.warehouseRepo.ResolveWithTx(tx, count.WarehouseID)
+		if err != nil {
+			return err
+		}
+
+		var lines []models.CycleCountLine
+		if err := tx.Where("cycle_count_id = ?", id).Find(&lines).Error; err != nil {
+			return err
+		}
+
+		for i := range lines {
+			line := &lines[i]
+			if line.CountedQty == nil {
+				return &CycleCountLineError{ItemID: line.ItemID, Message: "has not been counted"}
+			}
+
+			expected, err := r.warehouseRepo.StockQuantityWithTx(tx, line.ItemID, warehouseID)
+			if err != nil {
+				return err
+			}
+			variance := *line.CountedQty - expected
+			line.ExpectedQty = &expected
+			line.Variance = &variance
+			if err := tx.Model(line).Updates(map[string]interface{}{
+				"expected_qty": expected,
+				"variance":     variance,
+			}).Error; err != nil {
+				return err
+			}
+
+			if variance == 0 {
+				continue
+			}
+			if err := r.adjustmentRepo.CreateWithTx(tx, &models.StockAdjustment{
+				ItemID:       line.ItemID,
+				WarehouseID:  &warehouseID,
+				Quantity:     variance,
+				Reason:       models.AdjustmentReasonCountCorrection,
+				Note:         fmt.Sprintf("cycle count #%d: counted %d, system %d", id, *line.CountedQty, expected),
+				CycleCountID: &count.ID,
+				RequestedBy:  userID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		count.Status = models.CycleCountStatusSubmitted
+		count.SubmittedBy = &userID
+		count.SubmittedAt = &now
+		return tx.Model(count).Updates(map[string]interface{}{
+			"status":       count.Status,
+			"submitted_by": userID,
+			"submitted_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return count, nil
+}
+
+// ApproveTransaction posts every variance of a submitted count to stock
+// The count is approved as a whole: if any variance cannot be posted nothing is.
+func (r *CycleCountRepository) ApproveTransaction(
+	id uint,
+	userID uint,
+	comment string,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) (*models.CycleCount, error) {
+	return r.decideTransaction(id, userID, "approve", func(tx *gorm.DB, count *models.CycleCount, adjustments []models.StockAdjustment) error {
+		for i := range adjustments {
+			if err := r.adjustmentRepo.approveWithTx(tx, &adjustments[i], userID, comment, updateStockFn); err != nil {
+				return err
+			}
+		}
+		return r.setDecisionWithTx(tx, count, models.CycleCountStatusApproved, userID)
+	})
+}
+
+// RejectTransaction rejects the variances of a submitted count and reopens it for recounting
+func (r *CycleCountRepository) RejectTransaction(id uint, userID uint, comment string) (*models.CycleCount, error) {
+	return r.decideTransaction(id, userID, "reject", func(tx *gorm.DB, count *models.CycleCount, adjustments []models.StockAdjustment) error {
+		for i := range adjustments {
+			if err := r.adjustmentRepo.rejectWithTx(tx, &adjustments[i], userID, comment); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&models.CycleCountLine{}).Where("cycle_count_id = ?", id).Updates(map[string]interface{}{
+			"expected_qty": nil,
+			"variance":     nil,
+		}).Error; err != nil {
+			return err
+		}
+
+		count.Status = models.CycleCountStatusOpen
+		count.SubmittedBy = nil
+		count.SubmittedAt = nil
+		return tx.Model(count).Updates(map[string]interface{}{
+			"status":       count.Status,
+			"submitted_by": nil,
+			"submitted_at": nil,
+		}).Error
+	})
+}
+
+// CancelTransaction cancels an open or submitted count; pending variances are rejected
+func (r *CycleCountRepository) CancelTransaction(id uint, userID uint) (*models.CycleCount, error) {
+	var count *models.CycleCount
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		count, err = r.findForUpdateWithTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.CycleCountStatusOpen && count.Status != models.CycleCountStatusSubmitted {
+			return &StockStatusError{Document: "cycle count", CurrentStatus: count.Status, Action: "cancel"}
+		}
+
+		adjustments, err := r.pendingAdjustmentsWithTx(tx, id)
+		if err != nil {
+			return err
+		}
+		for i := range adjustments {
+			if err := r.adjustmentRepo.rejectWithTx(tx, &adjustments[i], userID, "cycle count cancelled"); err != nil {
+				return err
+			}
+		}
+		return r.setDecisionWithTx(tx, count, models.CycleCountStatusCancelled, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return count, nil
+}
+
+// decideTransaction locks a submitted count and its pending adjustments and runs the decision
+// Whoever submitted the count cannot decide on it.
+func (r *CycleCountRepository) decideTransaction(
+	id uint,
+	userID uint,
+	action string,
+	decide func(tx *gorm.DB, count *models.CycleCount, adjustments []models.StockAdjustment) error,
+) (*models.CycleCount, error) {
+	var count *models.CycleCount
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		count, err = r.findForUpdateWithTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.CycleCountStatusSubmitted {
+			return &StockStatusError{Document: "cycle count", CurrentStatus: count.Status, Action: action}
+		}
+		if count.SubmittedBy != nil && *count.SubmittedBy == userID {
+			return &ApprovalDeniedError{Reason: "you cannot decide on a cycle count you submitted"}
+		}
+
+		adjustments, err := r.pendingAdjustmentsWithTx(tx, id)
+		if err != nil {
+			return err
+		}
+		return decide(tx, count, adjustments)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return count, nil
+}
+
+// setDecisionWithTx moves the count to a final status
+func (r *CycleCountRepository) setDecisionWithTx(tx *gorm.DB, count *models.CycleCount, status string, userID uint) error {
+	now := time.Now()
+	count.Status = status
+	count.DecidedBy = &userID
+	count.DecidedAt = &now
+	return tx.Model(count).Updates(map[string]interface{}{
+		"status":     status,
+		"decided_by": userID,
+		"decided_at": now,
+	}).Error
+}
+
+// pendingAdjustmentsWithTx locks the pending adjustments created by the count
+func (r *CycleCountRepository) pendingAdjustmentsWithTx(tx *gorm.DB, id uint) ([]models.StockAdjustment, error) {
+	var adjustments []models.StockAdjustment
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("cycle_count_id = ? AND status = ?", id, models.StockAdjustmentStatusPending).
+		Find(&adjustments).Error
+	return adjustments, err
+}
+
+// findForUpdateWithTx locks the cycle count row for the rest of the transaction
+func (r *CycleCountRepository) findForUpdateWithTx(tx *gorm.DB, id uint) (*models.CycleCount, error) {
+	var count models.CycleCount
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&count, id).Error; err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
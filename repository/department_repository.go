@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"errors"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrDepartmentInUse is returned when a department that budgets or purchasings refer to is deleted
+var ErrDepartmentInUse = errors.New("department has budgets or purchasings; deactivate it instead")
+
+// DepartmentRepository handles departments (cost centers)
+type DepartmentRepository struct{}
+
+// NewDepartmentRepository creates a new DepartmentRepository instance
+func NewDepartmentRepository() *DepartmentRepository {
+	return &DepartmentRepository{}
+}
+
+// FindByID finds a department by ID
+func (r *DepartmentRepository) FindByID(id uint) (*models.Department, error) {
+	var department models.Department
+	result := config.DB.First(&department, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &department, nil
+}
+
+// FindByCode finds a department by its code
+func (r *DepartmentRepository) FindByCode(code string) (*models.Department, error) {
+	var department models.Department
+	result := config.DB.Where("code = ?", code).First(&department)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &department, nil
+}
+
+// GetAll retrieves all departments ordered by code, optionally only the active ones
+func (r *DepartmentRepository) GetAll(activeOnly bool) ([]models.Department, error) {
+	query := config.DB.Order("code ASC")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	departments := []models.Department{}
+	result := query.Find(&departments)
+	return departments, result.Error
+}
+
+// Create creates a new department
+func (r *DepartmentRepository) Create(department *models.Department) error {
+	return config.DB.Create(department).Error
+}
+
+// Update updates an existing department
+func (r *DepartmentRepository) Update(department *models.Department) error {
+	return config.DB.Save(department).Error
+}
+
+// Delete deletes a department that no budget or purchasing refers to
+// Otherwise ErrDepartmentInUse is returned.
+func (r *DepartmentRepository) Delete(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var department models.Department
+		if err := tx.First(&department, id).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{&models.Budget{}, &models.Purchasing{}} {
+			var count int64
+			if err := tx.Model(model).Where("department_id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrDepartmentInUse
+			}
+		}
+
+		return tx.Delete(&department).Error
+	})
+}
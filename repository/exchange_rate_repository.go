@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MissingExchangeRateError is returned when no rate of a currency is in effect on a date
+type MissingExchangeRateError struct {
+	Currency string
+	Date     time.Time
+}
+
+func (e *MissingExchangeRateError) Error() string {
+	return fmt.Sprintf("no %s exchange rate in effect on %s", e.Currency, e.Date.Format(dateLayout))
+}
+
+// ExchangeRateRepository handles the exchange rates to the base currency
+type ExchangeRateRepository struct{}
+
+// NewExchangeRateRepository creates a new ExchangeRateRepository instance
+func NewExchangeRateRepository() *ExchangeRateRepository {
+	return &ExchangeRateRepository{}
+}
+
+// FindByID finds an exchange rate by ID
+func (r *ExchangeRateRepository) FindByID(id uint) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	result := config.DB.First(&rate, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &rate, nil
+}
+
+// FindByDay finds the rate of a currency taking effect on the given date
+func (r *ExchangeRateRepository) FindByDay(currency string, date time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	result := config.DB.Where("currency = ? AND effective_date = ?", currency, date.Format(dateLayout)).First(&rate)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &rate, nil
+}
+
+// ExchangeRateFilter holds the optional criteria for listing exchange rates
+// Zero values mean "no filter" for that field.
+type ExchangeRateFilter struct {
+	Currency string
+	DateFrom *time.Time
+	DateTo   *time.Time
+}
+
+// exchangeRateSortColumns maps the accepted sort keys of exchange rate listings to indexed columns
+var exchangeRateSortColumns = map[string]string{
+	"id":            "id",
+	"effectiveDate": "effective_date",
+}
+
+// List retrieves one page of exchange rates matching the filter, latest first by default
+func (r *ExchangeRateRepository) List(filter ExchangeRateFilter, params ListParams) ([]models.ExchangeRate, PageInfo, error) {
+	query := config.DB.Model(&models.ExchangeRate{})
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("effective_date >= ?", filter.DateFrom.Format(dateLayout))
+	}
+	if filter.DateTo != nil {
+		query = query.Where("effective_date <= ?", filter.DateTo.Format(dateLayout))
+	}
+
+	return paginate(query, params, exchangeRateSortColumns, "effectiveDate", func(rate *models.ExchangeRate) (interface{}, uint) {
+		if params.SortBy == "id" {
+			return rate.ID, rate.ID
+		}
+		return rate.EffectiveDate.Format(dateLayout), rate.ID
+	})
+}
+
+// Create records a manually entered rate
+func (r *ExchangeRateRepository) Create(rate *models.ExchangeRate) error {
+	rate.Source = models.ExchangeRateSourceManual
+	rate.CreatedAt = time.Now()
+	return config.DB.Create(rate).Error
+}
+
+// ImportTransaction stores imported rates, replacing the rate of a currency already set for the same day
+// Either every rate is stored or none is.
+func (r *ExchangeRateRepository) ImportTransaction(rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for i := range rates {
+		rates[i].Source = models.ExchangeRateSourceImport
+		rates[i].CreatedAt = now
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "currency"}, {Name: "effective_date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "created_by", "created_at"}),
+		}).CreateInBatches(rates, 500).Error
+	})
+}
+
+// Delete deletes an exchange rate by ID
+// Purchasings keep the rate they were converted at, so deleting a rate only affects new ones.
+func (r *ExchangeRateRepository) Delete(id uint) error {
+	return config.DB.Delete(&models.ExchangeRate{}, id).Error
+}
+
+// RateOnWithTx returns the value of one unit of currency in the base currency on date
+// The base currency is always 1; any other currency uses its latest rate effective on or before
+// date, or returns a MissingExchangeRateError. date is an instant, such as the purchasing's date;
+// the rate is the one in effect on its local day.
+func (r *ExchangeRateRepository) RateOnWithTx(tx *gorm.DB, currency string, date time.Time) (decimal.Decimal, error) {
+	if currency == config.BaseCurrency {
+		return decimal.NewFromInt(1), nil
+	}
+	date = utils.DateOf(date)
+
+	var rate models.ExchangeRate
+	result := tx.
+		Where("currency = ? AND effective_date <= ?", currency, date.Format(dateLayout)).
+		Order("effective_date DESC").
+		Limit(1).
+		Find(&rate)
+	if result.Error != nil {
+		return decimal.Zero, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return decimal.Zero, &MissingExchangeRateError{Currency: currency, Date: date}
+	}
+	return rate.Rate, nil
+}
+
+// BackfillBaseCurrency puts suppliers and purchasings from before currencies existed in the base currency
+// Safe to run on every startup.
+func (r *ExchangeRateRepository) BackfillBaseCurrency() error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Supplier{}).Where("currency = ''").Update("currency", config.BaseCurrency).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Purchasing{}).Where("currency = ''").Updates(map[string]interface{}{
+			"currency":         config.BaseCurrency,
+			"exchange_rate":    1,
+			"base_grand_total": gorm.Expr("grand_total"),
+		}).Error
+	})
+}
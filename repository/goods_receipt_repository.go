@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"fmt"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReceiptQuantityError is returned when a receipt line cannot be accepted as submitted
+type ReceiptQuantityError struct {
+	PurchasingDetailID uint
+	Message            string
+}
+
+func (e *ReceiptQuantityError) Error() string {
+	return fmt.Sprintf("purchasing detail %d: %s", e.PurchasingDetailID, e.Message)
+}
+
+// GoodsReceiptRepository handles goods receipt operations
+type GoodsReceiptRepository struct {
+	purchasingRepo *PurchasingRepository
+}
+
+// NewGoodsReceiptRepository creates a new GoodsReceiptRepository instance
+func NewGoodsReceiptRepository() *GoodsReceiptRepository {
+	return &GoodsReceiptRepository{
+		purchasingRepo: NewPurchasingRepository(),
+	}
+}
+
+// CreateReceiptTransaction records a goods receipt and moves stock for the accepted quantities
+// Everything happens in one transaction:
+// - The purchasing row is locked and must be ordered or partially received
+// - Each line is checked against the outstanding quantity and the over-delivery tolerance
+// - Received quantities are added to the purchasing details and, converted from the lines' units
+// to the items' base units, to stock in the receipt's warehouse
+// - The purchasing moves to partially_received or received depending on what is outstanding
+// If any line is rejected, nothing is written.
+func (r *GoodsReceiptRepository) CreateReceiptTransaction(
+	receipt *models.GoodsReceipt,
+	lines []models.GoodsReceiptLine,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) (*models.Purchasing, error) {
+	var purchasing *models.Purchasing
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purchasing, err = r.purchasingRepo.FindForUpdateWithTx(tx, receipt.PurchasingID)
+		if err != nil {
+			return err
+		}
+
+		if purchasing.Status != models.PurchasingStatusOrdered && purchasing.Status != models.PurchasingStatusPartiallyReceived {
+			return &InvalidTransitionError{
+				CurrentStatus:   purchasing.Status,
+				RequestedStatus: models.PurchasingStatusReceived,
+			}
+		}
+
+		var details []models.PurchasingDetail
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("purchasing_id = ?", purchasing.ID).
+			Find(&details).Error; err != nil {
+			return err
+		}
+
+		detailsByID := make(map[uint]*models.PurchasingDetail, len(details))
+		for i := range details {
+			detailsByID[details[i].ID] = &details[i]
+		}
+
+		// Validate every line before writing anything
+		for i := range lines {
+			detail, ok := detailsByID[lines[i].PurchasingDetailID]
+			if !ok {
+				return &ReceiptQuantityError{
+					PurchasingDetailID: lines[i].PurchasingDetailID,
+					Message:            fmt.Sprintf("does not belong to purchasing %d", purchasing.ID),
+				}
+			}
+
+			received := detail.ReceivedQty + lines[i].ReceivedQty
+			if maxQty := maxReceivableQty(detail.Qty); received > maxQty {
+				return &ReceiptQuantityError{
+					PurchasingDetailID: detail.ID,
+					Message:            fmt.Sprintf("receiving %d would bring the total to %d, above the allowed %d", lines[i].ReceivedQty, received, maxQty),
+				}
+			}
+
+			lines[i].ItemID = detail.ItemID
+			detail.ReceivedQty = received
+		}
+
+		if receipt.WarehouseID == nil {
+			receipt.WarehouseID = purchasing.WarehouseID
+		}
+
+		// Step 1: Insert receipt header
+		if err := tx.Create(receipt).Error; err != nil {
+			return err
+		}
+
+		// Step 2: Insert lines and move stock for the accepted quantity only
+		for i := range lines {
+			lines[i].GoodsReceiptID = receipt.ID
+			if err := tx.Create(&lines[i]).Error; err != nil {
+				return err
+			}
+
+			if lines[i].ReceivedQty > 0 {
+				if err := updateStockFn(tx, &models.StockMovement{
+					ItemID:      lines[i].ItemID,
+					WarehouseID: receipt.WarehouseID,
+					Delta:       detailsByID[lines[i].PurchasingDetailID].BaseQty(lines[i].ReceivedQty),
+					Reason:      models.StockMovementGoodsReceipt,
+					SourceType:  models.StockSourceGoodsReceipt,
+					SourceID:    &receipt.ID,
+					UserID:      &receipt.UserID,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		// Step 3: Persist the new received totals and work out the purchasing status
+		complete := true
+		for i := range details {
+			if err := tx.Model(&details[i]).Update("received_qty", details[i].ReceivedQty).Error; err != nil {
+				return err
+			}
+			if details[i].ReceivedQty < minCompleteQty(details[i].Qty) {
+				complete = false
+			}
+		}
+
+		status := models.PurchasingStatusPartiallyReceived
+		if complete {
+			status = models.PurchasingStatusReceived
+		}
+		if status == purchasing.Status {
+			return nil
+		}
+
+		purchasing, err = r.purchasingRepo.TransitionStatusWithTx(tx, purchasing.ID, status, receipt.UserID, fmt.Sprintf("goods receipt #%d", receipt.ID))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	receipt.Lines = lines
+	return purchasing, nil
+}
+
+// GetByPurchasing retrieves all receipts recorded against a purchasing
+func (r *GoodsReceiptRepository) GetByPurchasing(purchasingID uint) ([]models.GoodsReceipt, error) {
+	var receipts []models.GoodsReceipt
+	result := config.DB.Preload("Lines").Preload("Lines.Item").
+		Where("purchasing_id = ?", purchasingID).
+		Order("received_at ASC, id ASC").
+		Find(&receipts)
+	return receipts, result.Error
+}
+
+// maxReceivableQty is the highest total quantity that may be received for an ordered quantity
+func maxReceivableQty(ordered int) int {
+	factor := decimal.NewFromFloat(1 + config.ReceiptOverTolerance/100)
+	return int(decimal.NewFromInt(int64(ordered)).Mul(factor).Floor().IntPart())
+}
+
+// minCompleteQty is the lowest total quantity that counts as fully received for an ordered quantity
+func minCompleteQty(ordered int) int {
+	factor := decimal.NewFromFloat(1 - config.ReceiptUnderTolerance/100)
+	if factor.IsNegative() {
+		return 0
+	}
+	return int(decimal.NewFromInt(int64(ordered)).Mul(factor).Ceil().IntPart())
+}
@@ -1,18 +1,37 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+
 	"procurement-system/config"
 	"procurement-system/models"
+	"procurement-system/utils"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrInsufficientStock is returned when a stock update would make stock negative
+var ErrInsufficientStock = errors.New("insufficient stock")
+
 // ItemRepository handles item data operations
-type ItemRepository struct{}
+// Every stock change is recorded in the stock ledger, and item changes publish item.* and
+// stock.low webhook events, in the same transaction as the change.
+type ItemRepository struct {
+	eventRepo     *WebhookEventRepository
+	movementRepo  *StockMovementRepository
+	warehouseRepo *WarehouseRepository
+}
 
 // NewItemRepository creates a new ItemRepository instance
 func NewItemRepository() *ItemRepository {
-	return &ItemRepository{}
+	return &ItemRepository{
+		eventRepo:     NewWebhookEventRepository(),
+		movementRepo:  NewStockMovementRepository(),
+		warehouseRepo: NewWarehouseRepository(),
+	}
 }
 
 // FindByID finds an item by ID
@@ -25,40 +44,275 @@ func (r *ItemRepository) FindByID(id uint) (*models.Item, error) {
 	return &item, nil
 }
 
-// UpdateStockWithTx updates stock using the provided transaction
-func (r *ItemRepository) UpdateStockWithTx(tx *gorm.DB, itemID uint, qty int) error {
-	result := tx.Model(&models.Item{}).Where("id = ?", itemID).Update("stock", gorm.Expr("stock + ?", qty))
-	return result.Error
+// UpdateStockWithTx applies the movement's delta to the item's balance in the movement's
+// warehouse and to its total stock, and appends the movement to the ledger using the provided
+// transaction. The caller fills in the item, warehouse, delta, reason, source and user; a nil
+// warehouse means the default warehouse. BalanceAfter and WarehouseBalanceAfter are set here.
+// A negative delta removes stock; the update is refused with ErrInsufficientStock if it would
+// take the warehouse balance below zero.
+func (r *ItemRepository) UpdateStockWithTx(tx *gorm.DB, movement *models.StockMovement) error {
+	if movement.Delta == 0 {
+		return nil
+	}
+
+	warehouseID, err := r.warehouseRepo.ResolveWithTx(tx, movement.WarehouseID)
+	if err != nil {
+		return err
+	}
+	movement.WarehouseID = &warehouseID
+
+	// The first movement of an item in a warehouse opens its balance there
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.WarehouseStock{
+		ItemID:      movement.ItemID,
+		WarehouseID: warehouseID,
+	}).Error; err != nil {
+		return err
+	}
+
+	result := tx.Model(&models.WarehouseStock{}).
+		Where("item_id = ? AND warehouse_id = ? AND quantity + ? >= 0", movement.ItemID, warehouseID, movement.Delta).
+		Update("quantity", gorm.Expr("quantity + ?", movement.Delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("item %d in warehouse %d: %w", movement.ItemID, warehouseID, ErrInsufficientStock)
+	}
+
+	result = tx.Model(&models.Item{}).
+		Where("id = ? AND stock + ? >= 0", movement.ItemID, movement.Delta).
+		Update("stock", gorm.Expr("stock + ?", movement.Delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("item %d: %w", movement.ItemID, ErrInsufficientStock)
+	}
+
+	// Both rows stay locked by the updates, so these are the balances our change produced
+	warehouseStock, err := r.warehouseRepo.StockQuantityWithTx(tx, movement.ItemID, warehouseID)
+	if err != nil {
+		return err
+	}
+	var stock int
+	if err := tx.Model(&models.Item{}).Select("stock").Where("id = ?", movement.ItemID).Scan(&stock).Error; err != nil {
+		return err
+	}
+	movement.BalanceAfter = stock
+	movement.WarehouseBalanceAfter = &warehouseStock
+	if err := r.movementRepo.CreateWithTx(tx, movement); err != nil {
+		return err
+	}
+
+	return r.publishStockLowWithTx(tx, movement.ItemID, stock-movement.Delta, stock)
 }
 
-// GetAll retrieves all items
-func (r *ItemRepository) GetAll() ([]models.Item, error) {
-	var items []models.Item
-	result := config.DB.Preload("Supplier").Find(&items)
-	return items, result.Error
+// publishStockLowWithTx publishes stock.low when a change takes the item's stock below LowStockThreshold
+// Only the crossing is published, not every change while the stock stays low.
+func (r *ItemRepository) publishStockLowWithTx(tx *gorm.DB, itemID uint, oldStock, newStock int) error {
+	if oldStock < config.LowStockThreshold || newStock >= config.LowStockThreshold {
+		return nil
+	}
+
+	var item models.Item
+	if err := tx.Preload("Supplier").First(&item, itemID).Error; err != nil {
+		return err
+	}
+	return r.eventRepo.PublishWithTx(tx, models.WebhookEventStockLow, utils.NewEventPayload(models.WebhookEventStockLow, map[string]interface{}{
+		"item":      item,
+		"threshold": config.LowStockThreshold,
+	}))
 }
 
-// GetAllBySupplier retrieves items for a specific supplier
-func (r *ItemRepository) GetAllBySupplier(supplierID uint) ([]models.Item, error) {
-	var items []models.Item
-	result := config.DB.Preload("Supplier").Where("supplier_id = ?", supplierID).Find(&items)
-	return items, result.Error
+// publishItemWithTx publishes an item.* event carrying the item with its supplier
+func (r *ItemRepository) publishItemWithTx(tx *gorm.DB, eventType string, itemID uint) error {
+	var item models.Item
+	if err := tx.Preload("Supplier").First(&item, itemID).Error; err != nil {
+		return err
+	}
+	return r.eventRepo.PublishWithTx(tx, eventType, utils.NewEventPayload(eventType, item))
+}
+
+// ItemFilter holds the optional criteria for listing items
+// Zero values mean "no filter" for that field.
+type ItemFilter struct {
+	Name       string
+	SupplierID uint
+	MinPrice   *decimal.Decimal
+	MaxPrice   *decimal.Decimal
+	StockBelow *int
+
+	// WarehouseID keeps items with stock in that warehouse
+	WarehouseID uint
+	// ByLocation loads each item's per-warehouse balances (only the filtered warehouse's, if any)
+	ByLocation bool
+}
+
+// itemSortColumns maps the accepted sort keys of item listings to indexed columns
+var itemSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"price":      "price",
+	"stock":      "stock",
+	"supplierId": "supplier_id",
+}
+
+// List retrieves one page of items matching the filter
+func (r *ItemRepository) List(filter ItemFilter, params ListParams) ([]models.Item, PageInfo, error) {
+	query := config.DB.Model(&models.Item{})
+
+	if filter.Name != "" {
+		query = query.Where("name LIKE ?", "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.SupplierID != 0 {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if filter.MinPrice != nil {
+		query = query.Where("price >= ?", *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		query = query.Where("price <= ?", *filter.MaxPrice)
+	}
+	if filter.StockBelow != nil {
+		query = query.Where("stock < ?", *filter.StockBelow)
+	}
+	if filter.WarehouseID != 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM warehouse_stocks WHERE warehouse_stocks.item_id = items.id AND warehouse_stocks.warehouse_id = ? AND warehouse_stocks.quantity <> 0)", filter.WarehouseID)
+	}
+
+	query = query.Preload("Supplier")
+	if filter.ByLocation {
+		query = query.Preload("Stocks", func(db *gorm.DB) *gorm.DB {
+			db = db.Where("quantity <> 0")
+			if filter.WarehouseID != 0 {
+				db = db.Where("warehouse_id = ?", filter.WarehouseID)
+			}
+			return db.Order("warehouse_id ASC")
+		}).Preload("Stocks.Warehouse")
+	}
+
+	return paginate(query, params, itemSortColumns, "id", func(item *models.Item) (interface{}, uint) {
+		switch params.SortBy {
+		case "name":
+			return item.Name, item.ID
+		case "price":
+			return item.Price.String(), item.ID
+		case "stock":
+			return item.Stock, item.ID
+		case "supplierId":
+			return item.SupplierID, item.ID
+		default:
+			return item.ID, item.ID
+		}
+	})
+}
+
+// ItemStockSummary is an item's stock by location
+// Total is the item's stock, the sum of its warehouse balances; InTransit is shipped on
+// transfers but not yet received, and so counted in no warehouse.
+type ItemStockSummary struct {
+	ItemID    uint                    `json:"itemId"`
+	Total     int                     `json:"total"`
+	InTransit int                     `json:"inTransit"`
+	Locations []models.WarehouseStock `json:"locations"`
+}
+
+// FindStockSummary returns the stock of an item per warehouse, in total and in transit
+func (r *ItemRepository) FindStockSummary(itemID uint) (*ItemStockSummary, error) {
+	var item models.Item
+	if err := config.DB.First(&item, itemID).Error; err != nil {
+		return nil, err
+	}
+
+	summary := ItemStockSummary{ItemID: item.ID, Total: item.Stock}
+	if err := config.DB.Preload("Warehouse").
+		Where("item_id = ?", itemID).
+		Order("warehouse_id ASC").
+		Find(&summary.Locations).Error; err != nil {
+		return nil, err
+	}
+
+	err := config.DB.Model(&models.StockTransferLine{}).
+		Select("COALESCE(SUM(stock_transfer_lines.quantity), 0)").
+		Joins("JOIN stock_transfers ON stock_transfers.id = stock_transfer_lines.stock_transfer_id").
+		Where("stock_transfer_lines.item_id = ? AND stock_transfers.status = ?", itemID, models.StockTransferStatusInTransit).
+		Scan(&summary.InTransit).Error
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
 }
 
 // Create creates a new item
-func (r *ItemRepository) Create(item *models.Item) error {
-	result := config.DB.Create(item)
-	return result.Error
+// Initial stock is put into the given warehouse (nil for the default) and recorded in the
+// ledger as the item's first movement.
+func (r *ItemRepository) Create(item *models.Item, warehouseID *uint, userID uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		initialStock := item.Stock
+		item.Stock = 0
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+
+		itemID := item.ID
+		if err := r.UpdateStockWithTx(tx, &models.StockMovement{
+			ItemID:      item.ID,
+			WarehouseID: warehouseID,
+			Delta:       initialStock,
+			Reason:      models.StockMovementInitialStock,
+			SourceType:  models.StockSourceItem,
+			SourceID:    &itemID,
+			UserID:      &userID,
+		}); err != nil {
+			return err
+		}
+		item.Stock = initialStock
+
+		return r.publishItemWithTx(tx, models.WebhookEventItemCreated, item.ID)
+	})
 }
 
 // Update updates an existing item
+// Stock is not changed here; it only moves through the ledger (receipts, adjustments, ...).
 func (r *ItemRepository) Update(item *models.Item) error {
-	result := config.DB.Save(item)
-	return result.Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("stock").Save(item).Error; err != nil {
+			return err
+		}
+		return r.publishItemWithTx(tx, models.WebhookEventItemUpdated, item.ID)
+	})
+}
+
+// UpdateReorderSettings saves the item's reorder point, reorder quantity and maximum stock
+func (r *ItemRepository) UpdateReorderSettings(item *models.Item) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(item).Select("reorder_point", "reorder_qty", "max_stock").Updates(item).Error; err != nil {
+			return err
+		}
+		return r.publishItemWithTx(tx, models.WebhookEventItemUpdated, item.ID)
+	})
+}
+
+// UpdateTaxes saves the tax and withholding codes charged by default on the item's purchasing lines
+func (r *ItemRepository) UpdateTaxes(item *models.Item) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(item).Select("tax_code_id", "withholding_code_id").Updates(item).Error; err != nil {
+			return err
+		}
+		return r.publishItemWithTx(tx, models.WebhookEventItemUpdated, item.ID)
+	})
 }
 
 // Delete deletes an item by ID
 func (r *ItemRepository) Delete(id uint) error {
-	result := config.DB.Delete(&models.Item{}, id)
-	return result.Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var item models.Item
+		if err := tx.Preload("Supplier").First(&item, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Item{}, id).Error; err != nil {
+			return err
+		}
+		return r.eventRepo.PublishWithTx(tx, models.WebhookEventItemDeleted, utils.NewEventPayload(models.WebhookEventItemDeleted, item))
+	})
 }
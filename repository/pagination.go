@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Default and maximum page sizes for paginated listings
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Listing parameter errors, returned before any query is run
+var (
+	ErrInvalidSort   = errors.New("invalid sort column")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Pagination holds offset pagination parameters
+type Pagination struct {
+	Page  int
+	Limit int
+}
+
+// Normalize clamps the page and limit to sensible values
+func (p *Pagination) Normalize() {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+}
+
+// Offset returns the number of rows to skip for the current page
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// ListParams holds the pagination and sorting parameters shared by listings
+// When Cursor is set, keyset pagination is used and Page is ignored.
+type ListParams struct {
+	Pagination
+	Cursor string
+	SortBy string
+	Desc   bool
+}
+
+// PageInfo describes the page returned by a paginated listing
+// NextCursor is nil on the last page; pass it back as the cursor parameter to fetch the next one.
+type PageInfo struct {
+	Page       int     `json:"page,omitempty"`
+	Limit      int     `json:"limit"`
+	Total      int64   `json:"total"`
+	TotalPages int     `json:"totalPages"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+// cursor is the decoded form of an opaque pagination cursor:
+// the sort column value and ID of the last row on the previous page
+type cursor struct {
+	Value interface{} `json:"v"`
+	ID    uint        `json:"id"`
+}
+
+// paginate runs query as a sorted, paginated listing
+// sortColumns maps the public sort keys to (indexed) column names; defaultSort is used when
+// params.SortBy is empty. keyOf returns the sort value and ID of a row, used to build the next cursor.
+// Rows are always ordered by the sort column and then by id, so keyset pagination is stable.
+func paginate[T any](
+	query *gorm.DB,
+	params ListParams,
+	sortColumns map[string]string,
+	defaultSort string,
+	keyOf func(row *T) (interface{}, uint),
+) ([]T, PageInfo, error) {
+	sortBy := params.SortBy
+	if sortBy == "" {
+		sortBy = defaultSort
+	}
+	column, ok := sortColumns[sortBy]
+	if !ok {
+		return nil, PageInfo{}, fmt.Errorf("%w: %s", ErrInvalidSort, sortBy)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if params.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	page := query.Session(&gorm.Session{})
+	if params.Cursor != "" {
+		after, err := decodeCursor(params.Cursor)
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		page = page.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, comparison, column, comparison),
+			after.Value, after.Value, after.ID,
+		)
+	} else {
+		page = page.Offset(params.Offset())
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	var rows []T
+	if err := page.
+		Order(fmt.Sprintf("%s %s, id %s", column, direction, direction)).
+		Limit(params.Limit + 1).
+		Find(&rows).Error; err != nil {
+		return nil, PageInfo{}, err
+	}
+
+	info := PageInfo{
+		Limit:      params.Limit,
+		Total:      total,
+		TotalPages: int((total + int64(params.Limit) - 1) / int64(params.Limit)),
+	}
+	if params.Cursor == "" {
+		info.Page = params.Page
+	}
+
+	if len(rows) > params.Limit {
+		rows = rows[:params.Limit]
+		value, id := keyOf(&rows[len(rows)-1])
+		next, err := encodeCursor(cursor{Value: value, ID: id})
+		if err != nil {
+			return nil, PageInfo{}, err
+		}
+		info.NextCursor = &next
+	}
+
+	return rows, info, nil
+}
+
+// encodeCursor turns a cursor into an opaque URL-safe string
+func encodeCursor(c cursor) (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(value string) (cursor, error) {
+	var c cursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == 0 {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// likeEscaper escapes the LIKE wildcards so user input is matched literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike prepares user input for use inside a LIKE pattern
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
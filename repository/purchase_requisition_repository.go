@@ -0,0 +1,495 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Purchase requisition errors
+var (
+	ErrRequisitionNoApprovedLines  = errors.New("approve at least one line, or reject the requisition")
+	ErrRequisitionNothingToConvert = errors.New("requisition has no approved lines left to convert")
+	ErrRequisitionPartlyConverted  = errors.New("requisition already has converted lines and can no longer be cancelled")
+)
+
+// RequisitionStatusError is returned when a purchase requisition is not in a status that allows the action
+type RequisitionStatusError struct {
+	CurrentStatus string
+	Action        string
+}
+
+func (e *RequisitionStatusError) Error() string {
+	return fmt.Sprintf("cannot %s a requisition that is %s", e.Action, e.CurrentStatus)
+}
+
+// RequisitionLineError is returned when a requisition line cannot be used as requested
+type RequisitionLineError struct {
+	LineID  uint
+	Message string
+}
+
+func (e *RequisitionLineError) Error() string {
+	return fmt.Sprintf("requisition line %d: %s", e.LineID, e.Message)
+}
+
+// RequisitionConversion says which approved lines of a requisition to convert and how
+// LineIDs limits the conversion to some lines (all approved lines when empty). ItemIDs gives the
+// catalog item of each free-text line converted, by line ID; catalog lines keep the item they were
+// approved with, and an entry for one is refused. The purchasings go to WarehouseID.
+type RequisitionConversion struct {
+	LineIDs     []uint
+	ItemIDs     map[uint]uint
+	WarehouseID uint
+}
+
+// PurchaseRequisitionRepository handles purchase requisitions, their review and their conversion into purchasings
+type PurchaseRequisitionRepository struct {
+	purchasingRepo *PurchasingRepository
+	priceRepo      *SupplierPriceRepository
+}
+
+// NewPurchaseRequisitionRepository creates a new PurchaseRequisitionRepository instance
+func NewPurchaseRequisitionRepository() *PurchaseRequisitionRepository {
+	return &PurchaseRequisitionRepository{
+		purchasingRepo: NewPurchasingRepository(),
+		priceRepo:      NewSupplierPriceRepository(),
+	}
+}
+
+// FindByID finds a requisition by ID with its requester, department and lines
+func (r *PurchaseRequisitionRepository) FindByID(id uint) (*models.PurchaseRequisition, error) {
+	var requisition models.PurchaseRequisition
+	result := config.DB.Preload("Requester").Preload("Department").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Lines.Item").
+		First(&requisition, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &requisition, nil
+}
+
+// PurchaseRequisitionFilter holds the optional criteria for listing requisitions
+// Zero values mean "no filter" for that field.
+type PurchaseRequisitionFilter struct {
+	RequesterID  uint
+	DepartmentID uint
+	Status       string
+}
+
+// requisitionSortColumns maps the accepted sort keys of requisition listings to indexed columns
+var requisitionSortColumns = map[string]string{
+	"id":       "id",
+	"neededBy": "needed_by",
+}
+
+// List retrieves one page of requisitions matching the filter
+func (r *PurchaseRequisitionRepository) List(filter PurchaseRequisitionFilter, params ListParams) ([]models.PurchaseRequisition, PageInfo, error) {
+	query := config.DB.Model(&models.PurchaseRequisition{})
+	if filter.RequesterID != 0 {
+		query = query.Where("requester_id = ?", filter.RequesterID)
+	}
+	if filter.DepartmentID != 0 {
+		query = query.Where("department_id = ?", filter.DepartmentID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	query = query.Preload("Requester").Preload("Department")
+
+	return paginate(query, params, requisitionSortColumns, "id", func(requisition *models.PurchaseRequisition) (interface{}, uint) {
+		if params.SortBy == "neededBy" {
+			// Dates are stored without time; this is how MySQL compares them
+			return requisition.NeededBy.Format(dateLayout), requisition.ID
+		}
+		return requisition.ID, requisition.ID
+	})
+}
+
+// Create creates a draft requisition with its lines
+func (r *PurchaseRequisitionRepository) Create(requisition *models.PurchaseRequisition, lines []models.PurchaseRequisitionLine) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		requisition.Status = models.RequisitionStatusDraft
+		if err := tx.Omit("Requester", "Department", "Lines").Create(requisition).Error; err != nil {
+			return err
+		}
+
+		for i := range lines {
+			lines[i].RequisitionID = requisition.ID
+			lines[i].Status = models.RequisitionLinePending
+			if err := tx.Omit("Item").Create(&lines[i]).Error; err != nil {
+				return err
+			}
+		}
+		requisition.Lines = lines
+		return nil
+	})
+}
+
+// SubmitTransaction sends a draft requisition for review; only its requester may submit it
+func (r *PurchaseRequisitionRepository) SubmitTransaction(id, userID uint) (*models.PurchaseRequisition, error) {
+	var requisition *models.PurchaseRequisition
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		requisition, err = r.findForUpdateWithTx(tx, id, models.RequisitionStatusSubmitted, "submit")
+		if err != nil {
+			return err
+		}
+		if requisition.RequesterID != userID {
+			return &ApprovalDeniedError{Reason: "only the requester can submit the requisition"}
+		}
+
+		now := time.Now()
+		requisition.Status = models.RequisitionStatusSubmitted
+		requisition.SubmittedAt = &now
+		requisition.UpdatedAt = now
+		return tx.Model(requisition).Updates(map[string]interface{}{
+			"status":       requisition.Status,
+			"submitted_at": now,
+			"updated_at":   now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return requisition, nil
+}
+
+// ApproveTransaction approves a submitted requisition, except for the lines in rejectedLineIDs
+// Those lines are rejected and will not be converted; at least one line must stay approved
+// (ErrRequisitionNoApprovedLines). Requesters cannot approve their own requisitions.
+func (r *PurchaseRequisitionRepository) ApproveTransaction(id, userID uint, comment string, rejectedLineIDs []uint) (*models.PurchaseRequisition, error) {
+	var requisition *models.PurchaseRequisition
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		requisition, err = r.findForUpdateWithTx(tx, id, models.RequisitionStatusApproved, "approve")
+		if err != nil {
+			return err
+		}
+		if requisition.RequesterID == userID {
+			return &ApprovalDeniedError{Reason: "you cannot approve your own requisition"}
+		}
+
+		lineIDs := make(map[uint]bool, len(requisition.Lines))
+		for _, line := range requisition.Lines {
+			lineIDs[line.ID] = true
+		}
+		rejected := make(map[uint]bool, len(rejectedLineIDs))
+		for _, lineID := range rejectedLineIDs {
+			if !lineIDs[lineID] {
+				return &RequisitionLineError{LineID: lineID, Message: "is not a line of this requisition"}
+			}
+			rejected[lineID] = true
+		}
+		if len(rejected) == len(requisition.Lines) {
+			return ErrRequisitionNoApprovedLines
+		}
+
+		for i := range requisition.Lines {
+			line := &requisition.Lines[i]
+			line.Status = models.RequisitionLineApproved
+			if rejected[line.ID] {
+				line.Status = models.RequisitionLineRejected
+			}
+			if err := tx.Model(line).Update("status", line.Status).Error; err != nil {
+				return err
+			}
+		}
+
+		return r.decideWithTx(tx, requisition, models.RequisitionStatusApproved, userID, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return requisition, nil
+}
+
+// RejectTransaction rejects a submitted requisition as a whole
+func (r *PurchaseRequisitionRepository) RejectTransaction(id, userID uint, comment string) (*models.PurchaseRequisition, error) {
+	var requisition *models.PurchaseRequisition
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		requisition, err = r.findForUpdateWithTx(tx, id, models.RequisitionStatusRejected, "reject")
+		if err != nil {
+			return err
+		}
+		if requisition.RequesterID == userID {
+			return &ApprovalDeniedError{Reason: "you cannot reject your own requisition; cancel it instead"}
+		}
+
+		if err := tx.Model(&models.PurchaseRequisitionLine{}).
+			Where("requisition_id = ?", requisition.ID).
+			Update("status", models.RequisitionLineRejected).Error; err != nil {
+			return err
+		}
+		for i := range requisition.Lines {
+			requisition.Lines[i].Status = models.RequisitionLineRejected
+		}
+
+		return r.decideWithTx(tx, requisition, models.RequisitionStatusRejected, userID, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return requisition, nil
+}
+
+// CancelTransaction cancels a requisition before any of its lines was converted
+// Only the requester or an admin may cancel it.
+func (r *PurchaseRequisitionRepository) CancelTransaction(id, userID uint, role, note string) (*models.PurchaseRequisition, error) {
+	var requisition *models.PurchaseRequisition
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		requisition, err = r.findForUpdateWithTx(tx, id, models.RequisitionStatusCancelled, "cancel")
+		if err != nil {
+			return err
+		}
+		if requisition.RequesterID != userID && role != models.RoleAdmin {
+			return &ApprovalDeniedError{Reason: "only the requester or an admin can cancel the requisition"}
+		}
+		for _, line := range requisition.Lines {
+			if line.Status == models.RequisitionLineConverted {
+				return ErrRequisitionPartlyConverted
+			}
+		}
+
+		now := time.Now()
+		requisition.Status = models.RequisitionStatusCancelled
+		requisition.UpdatedAt = now
+		updates := map[string]interface{}{
+			"status":     requisition.Status,
+			"updated_at": now,
+		}
+		if note != "" {
+			requisition.DecisionComment = note
+			updates["decision_comment"] = note
+		}
+		return tx.Model(requisition).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return requisition, nil
+}
+
+// ConvertTransaction turns approved lines of the requisition into draft purchasings, one per supplier
+// The supplier of a line is the supplier of its item; free-text lines need an item in
+// conversion.ItemIDs. Lines are in the item's base unit and priced from the supplier's price list
+// today, with the item's default tax codes. The purchasings are charged to the requisition's
+// department, so its budget is checked as for any new purchasing. Each converted line records the
+// purchasing line it became, and that line records the requisition line. afterCreateFn runs for
+// each purchasing inside the transaction, as in CreatePurchasingTransaction.
+func (r *PurchaseRequisitionRepository) ConvertTransaction(
+	id, userID uint,
+	conversion RequisitionConversion,
+	afterCreateFn func(tx *gorm.DB, purchasing *models.Purchasing) error,
+) ([]models.Purchasing, error) {
+	purchasings := []models.Purchasing{}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		requisition, err := r.findForUpdateWithTx(tx, id, models.RequisitionStatusConverted, "convert")
+		if err != nil {
+			return err
+		}
+
+		lines, err := selectConvertibleLines(requisition.Lines, conversion.LineIDs)
+		if err != nil {
+			return err
+		}
+
+		// Resolve the item of every line and group the lines by the item's supplier
+		items := make(map[uint]*models.Item, len(lines))
+		bySupplier := make(map[uint][]*models.PurchaseRequisitionLine)
+		for _, line := range lines {
+			// A catalog line is ordered as approved; only free-text lines get their item at conversion
+			itemID, given := conversion.ItemIDs[line.ID]
+			if line.ItemID != nil {
+				if given {
+					return &RequisitionLineError{LineID: line.ID, Message: "catalog line keeps its approved item; do not send an itemId for it"}
+				}
+				itemID = *line.ItemID
+			}
+			if itemID == 0 {
+				return &RequisitionLineError{LineID: line.ID, Message: "free-text line needs an itemId to be converted"}
+			}
+
+			var item models.Item
+			if err := tx.First(&item, itemID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return &RequisitionLineError{LineID: line.ID, Message: fmt.Sprintf("item %d not found", itemID)}
+				}
+				return err
+			}
+			items[line.ID] = &item
+			bySupplier[item.SupplierID] = append(bySupplier[item.SupplierID], line)
+		}
+
+		supplierIDs := make([]uint, 0, len(bySupplier))
+		for supplierID := range bySupplier {
+			supplierIDs = append(supplierIDs, supplierID)
+		}
+		sort.Slice(supplierIDs, func(i, j int) bool { return supplierIDs[i] < supplierIDs[j] })
+
+		now := time.Now()
+		departmentID := requisition.DepartmentID
+		for _, supplierID := range supplierIDs {
+			supplierLines := bySupplier[supplierID]
+
+			details := make([]models.PurchasingDetail, 0, len(supplierLines))
+			for _, line := range supplierLines {
+				item := items[line.ID]
+				unitPrice, priceID, err := r.priceRepo.ResolveUnitPriceWithTx(tx, supplierID, item, line.Qty, now)
+				if err != nil {
+					return err
+				}
+
+				lineID := line.ID
+				details = append(details, models.PurchasingDetail{
+					ItemID:            item.ID,
+					Qty:               line.Qty,
+					UnitID:            item.BaseUnitID,
+					UnitFactor:        1,
+					UnitPrice:         unitPrice,
+					SupplierPriceID:   priceID,
+					TaxCodeID:         item.TaxCodeID,
+					WithholdingCodeID: item.WithholdingCodeID,
+					RequisitionLineID: &lineID,
+				})
+			}
+
+			warehouseID := conversion.WarehouseID
+			neededBy := requisition.NeededBy
+			purchasing := models.Purchasing{
+				Date:         now,
+				SupplierID:   supplierID,
+				UserID:       userID,
+				WarehouseID:  &warehouseID,
+				DepartmentID: &departmentID,
+				Status:       models.PurchasingStatusDraft,
+				Origin:       models.PurchasingOriginRequisition,
+			}
+			// The department needs the goods by then, so that is when the supplier is expected to deliver
+			purchasing.ExpectedDeliveryDate = &neededBy
+			if err := r.purchasingRepo.CreatePurchasingWithTx(tx, &purchasing, details, afterCreateFn); err != nil {
+				return err
+			}
+
+			for i, line := range supplierLines {
+				line.Status = models.RequisitionLineConverted
+				line.ItemID = &details[i].ItemID
+				line.PurchasingID = &purchasing.ID
+				line.PurchasingDetailID = &details[i].ID
+				if err := tx.Model(line).Updates(map[string]interface{}{
+					"status":               line.Status,
+					"item_id":              *line.ItemID,
+					"purchasing_id":        purchasing.ID,
+					"purchasing_detail_id": details[i].ID,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			purchasing.PurchasingDetails = details
+			purchasings = append(purchasings, purchasing)
+		}
+
+		// The requisition is done once no approved line is left
+		for _, line := range requisition.Lines {
+			if line.Status == models.RequisitionLineApproved {
+				return nil
+			}
+		}
+		requisition.Status = models.RequisitionStatusConverted
+		requisition.UpdatedAt = now
+		return tx.Model(requisition).Updates(map[string]interface{}{
+			"status":     requisition.Status,
+			"updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purchasings, nil
+}
+
+// selectConvertibleLines returns the approved lines with the given IDs, or all approved lines when none are given
+func selectConvertibleLines(lines []models.PurchaseRequisitionLine, lineIDs []uint) ([]*models.PurchaseRequisitionLine, error) {
+	selected := []*models.PurchaseRequisitionLine{}
+	if len(lineIDs) == 0 {
+		for i := range lines {
+			if lines[i].Status == models.RequisitionLineApproved {
+				selected = append(selected, &lines[i])
+			}
+		}
+		if len(selected) == 0 {
+			return nil, ErrRequisitionNothingToConvert
+		}
+		return selected, nil
+	}
+
+	byID := make(map[uint]*models.PurchaseRequisitionLine, len(lines))
+	for i := range lines {
+		byID[lines[i].ID] = &lines[i]
+	}
+	seen := make(map[uint]bool, len(lineIDs))
+	for _, lineID := range lineIDs {
+		line, ok := byID[lineID]
+		if !ok {
+			return nil, &RequisitionLineError{LineID: lineID, Message: "is not a line of this requisition"}
+		}
+		if line.Status != models.RequisitionLineApproved {
+			return nil, &RequisitionLineError{LineID: lineID, Message: "is " + line.Status + ", only approved lines can be converted"}
+		}
+		if !seen[lineID] {
+			seen[lineID] = true
+			selected = append(selected, line)
+		}
+	}
+	return selected, nil
+}
+
+// findForUpdateWithTx locks a requisition that may move to the given status and loads its lines
+// A RequisitionStatusError naming the action is returned when its status does not allow it. An
+// approved requisition stays convertible until all its approved lines are converted.
+func (r *PurchaseRequisitionRepository) findForUpdateWithTx(tx *gorm.DB, id uint, status, action string) (*models.PurchaseRequisition, error) {
+	var requisition models.PurchaseRequisition
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&requisition, id).Error; err != nil {
+		return nil, err
+	}
+	if !requisition.CanTransitionTo(status) {
+		return nil, &RequisitionStatusError{CurrentStatus: requisition.Status, Action: action}
+	}
+	if err := tx.Where("requisition_id = ?", requisition.ID).Order("id ASC").Find(&requisition.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &requisition, nil
+}
+
+// decideWithTx records the review decision on a submitted requisition
+func (r *PurchaseRequisitionRepository) decideWithTx(tx *gorm.DB, requisition *models.PurchaseRequisition, status string, userID uint, comment string) error {
+	now := time.Now()
+	requisition.Status = status
+	requisition.DecidedBy = &userID
+	requisition.DecisionComment = comment
+	requisition.DecidedAt = &now
+	requisition.UpdatedAt = now
+	return tx.Model(requisition).Updates(map[string]interface{}{
+		"status":           status,
+		"decided_by":       userID,
+		"decision_comment": comment,
+		"decided_at":       now,
+		"updated_at":       now,
+	}).Error
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalDeniedError is returned when a user may not decide on a purchasing
+type ApprovalDeniedError struct {
+	Reason string
+}
+
+func (e *ApprovalDeniedError) Error() string {
+	return e.Reason
+}
+
+// PurchasingApprovalRepository handles the submit/approve/reject workflow of purchasings
+type PurchasingApprovalRepository struct {
+	purchasingRepo *PurchasingRepository
+	ruleRepo       *ApprovalRuleRepository
+}
+
+// NewPurchasingApprovalRepository creates a new PurchasingApprovalRepository instance
+func NewPurchasingApprovalRepository() *PurchasingApprovalRepository {
+	return &PurchasingApprovalRepository{
+		purchasingRepo: NewPurchasingRepository(),
+		ruleRepo:       NewApprovalRuleRepository(),
+	}
+}
+
+// SubmitTransaction submits a draft purchasing and evaluates the approval rules against its BaseGrandTotal
+// The matching rule is copied onto the purchasing so later rule changes do not affect
+// orders already in review. If no rule matches, the purchasing is approved immediately.
+func (r *PurchasingApprovalRepository) SubmitTransaction(purchasingID, userID uint, note string) (*models.Purchasing, error) {
+	var purchasing *models.Purchasing
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purchasing, err = r.purchasingRepo.TransitionStatusWithTx(tx, purchasingID, models.PurchasingStatusSubmitted, userID, note)
+		if err != nil {
+			return err
+		}
+
+		rule, err := r.ruleRepo.FindApplicableWithTx(tx, purchasing.BaseGrandTotal)
+		if err != nil {
+			return err
+		}
+
+		purchasing.ApprovalRound++
+		purchasing.RequiredApprovals = 0
+		purchasing.ApproverRole = ""
+		if rule != nil {
+			purchasing.RequiredApprovals = rule.RequiredApprovals
+			purchasing.ApproverRole = rule.ApproverRole
+		}
+
+		if err := tx.Model(purchasing).Updates(map[string]interface{}{
+			"approval_round":     purchasing.ApprovalRound,
+			"required_approvals": purchasing.RequiredApprovals,
+			"approver_role":      purchasing.ApproverRole,
+		}).Error; err != nil {
+			return err
+		}
+
+		if purchasing.RequiredApprovals > 0 {
+			return nil
+		}
+
+		purchasing, err = r.purchasingRepo.TransitionStatusWithTx(tx, purchasingID, models.PurchasingStatusApproved, userID, "no approval required")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purchasing, nil
+}
+
+// ApproveTransaction records an approval and approves the purchasing once enough approvals are in
+func (r *PurchasingApprovalRepository) ApproveTransaction(purchasingID, approverID uint, approverRole, comment string) (*models.Purchasing, error) {
+	var purchasing *models.Purchasing
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purchasing, err = r.decideWithTx(tx, purchasingID, approverID, approverRole, models.ApprovalDecisionApproved, comment)
+		if err != nil {
+			return err
+		}
+
+		var approvals int64
+		if err := tx.Model(&models.PurchasingApproval{}).
+			Where("purchasing_id = ? AND round = ? AND decision = ?", purchasing.ID, purchasing.ApprovalRound, models.ApprovalDecisionApproved).
+			Count(&approvals).Error; err != nil {
+			return err
+		}
+
+		if int(approvals) < purchasing.RequiredApprovals {
+			return nil
+		}
+
+		note := fmt.Sprintf("approved (%d of %d)", approvals, purchasing.RequiredApprovals)
+		purchasing, err = r.purchasingRepo.TransitionStatusWithTx(tx, purchasing.ID, models.PurchasingStatusApproved, approverID, note)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purchasing, nil
+}
+
+// RejectTransaction records a rejection and sends the purchasing back to draft
+func (r *PurchasingApprovalRepository) RejectTransaction(purchasingID, approverID uint, approverRole, comment string) (*models.Purchasing, error) {
+	var purchasing *models.Purchasing
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purchasing, err = r.decideWithTx(tx, purchasingID, approverID, approverRole, models.ApprovalDecisionRejected, comment)
+		if err != nil {
+			return err
+		}
+
+		purchasing, err = r.purchasingRepo.TransitionStatusWithTx(tx, purchasing.ID, models.PurchasingStatusDraft, approverID, "rejected: "+comment)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purchasing, nil
+}
+
+// GetByPurchasing retrieves all approval decisions on a purchasing, oldest first
+func (r *PurchasingApprovalRepository) GetByPurchasing(purchasingID uint) ([]models.PurchasingApproval, error) {
+	var approvals []models.PurchasingApproval
+	result := config.DB.Where("purchasing_id = ?", purchasingID).Order("decided_at ASC, id ASC").Find(&approvals)
+	return approvals, result.Error
+}
+
+// GetPendingForApprover retrieves submitted purchasings the user may still decide on
+func (r *PurchasingApprovalRepository) GetPendingForApprover(approverID uint, approverRole string) ([]models.Purchasing, error) {
+	var purchasings []models.Purchasing
+
+	decided := config.DB.Model(&models.PurchasingApproval{}).
+		Select("1").
+		Where("purchasing_approvals.purchasing_id = purchasings.id").
+		Where("purchasing_approvals.round = purchasings.approval_round").
+		Where("purchasing_approvals.approver_id = ?", approverID)
+
+	query := config.DB.Preload("Supplier").
+		Where("status = ?", models.PurchasingStatusSubmitted).
+		Where("user_id <> ?", approverID).
+		Where("NOT EXISTS (?)", decided)
+
+	if approverRole != models.RoleAdmin {
+		query = query.Where("approver_role = ? OR approver_role = ''", approverRole)
+	}
+
+	result := query.Order("date ASC, id ASC").Find(&purchasings)
+	return purchasings, result.Error
+}
+
+// decideWithTx validates the approver and stores their decision for the current round
+func (r *PurchasingApprovalRepository) decideWithTx(tx *gorm.DB, purchasingID, approverID uint, approverRole, decision, comment string) (*models.Purchasing, error) {
+	purchasing, err := r.purchasingRepo.FindForUpdateWithTx(tx, purchasingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if purchasing.Status != models.PurchasingStatusSubmitted {
+		requested := models.PurchasingStatusApproved
+		if decision == models.ApprovalDecisionRejected {
+			requested = models.PurchasingStatusDraft
+		}
+		return nil, &InvalidTransitionError{
+			CurrentStatus:   purchasing.Status,
+			RequestedStatus: requested,
+		}
+	}
+
+	if purchasing.UserID == approverID {
+		return nil, &ApprovalDeniedError{Reason: "You cannot approve or reject your own purchasing"}
+	}
+
+	if purchasing.ApproverRole != "" && approverRole != models.RoleAdmin && approverRole != purchasing.ApproverRole {
+		return nil, &ApprovalDeniedError{Reason: fmt.Sprintf("Purchasing requires approval by role %s", purchasing.ApproverRole)}
+	}
+
+	var existing int64
+	if err := tx.Model(&models.PurchasingApproval{}).
+		Where("purchasing_id = ? AND round = ? AND approver_id = ?", purchasing.ID, purchasing.ApprovalRound, approverID).
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, &ApprovalDeniedError{Reason: "You have already decided on this purchasing"}
+	}
+
+	approval := models.PurchasingApproval{
+		PurchasingID: purchasing.ID,
+		Round:        purchasing.ApprovalRound,
+		ApproverID:   approverID,
+		Decision:     decision,
+		Comment:      comment,
+		DecidedAt:    time.Now(),
+	}
+	if err := tx.Create(&approval).Error; err != nil {
+		return nil, err
+	}
+
+	return purchasing, nil
+}
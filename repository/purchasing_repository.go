@@ -1,23 +1,67 @@
 package repository
 
 import (
+	"fmt"
+	"time"
+
 	"procurement-system/config"
 	"procurement-system/models"
+	"procurement-system/utils"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// InvalidTransitionError is returned when a purchasing cannot move to the requested status
+type InvalidTransitionError struct {
+	CurrentStatus   string
+	RequestedStatus string
+}
+
+func (e *InvalidTransitionError) Error() string {
+	return fmt.Sprintf("cannot change purchasing status from %s to %s", e.CurrentStatus, e.RequestedStatus)
+}
+
+// PurchasingFilter holds the optional criteria for listing purchasings
+// Zero values mean "no filter" for that field.
+type PurchasingFilter struct {
+	SupplierID   uint
+	UserID       uint
+	WarehouseID  uint
+	DepartmentID uint
+	Status       string
+	Origin       string
+	Currency     string
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	MinTotal     *decimal.Decimal // base currency
+	MaxTotal     *decimal.Decimal // base currency
+	OffContract  bool             // only purchasings with lines deviating from a contract
+}
+
 // PurchasingRepository handles purchasing transaction operations
-type PurchasingRepository struct{}
+type PurchasingRepository struct {
+	rateRepo     *ExchangeRateRepository
+	budgetRepo   *BudgetRepository
+	contractRepo *ContractRepository
+}
 
 // NewPurchasingRepository creates a new PurchasingRepository instance
 func NewPurchasingRepository() *PurchasingRepository {
-	return &PurchasingRepository{}
+	return &PurchasingRepository{
+		rateRepo:     NewExchangeRateRepository(),
+		budgetRepo:   NewBudgetRepository(),
+		contractRepo: NewContractRepository(),
+	}
 }
 
-// CreatePurchasingTransaction creates a purchasing transaction with details and updates stock
+// CreatePurchasingTransaction creates a purchasing transaction with its details
+// Stock is not touched here; it moves when goods are received (see GoodsReceiptRepository).
+// afterCreateFn runs inside the same transaction once header and details are written,
+// e.g. to queue webhook events in the outbox; it may be nil.
 // This function uses GORM transaction to ensure ACID properties:
-// - Atomicity: All operations (Insert Header, Insert Details, Update Stock) succeed or all fail
+// - Atomicity: All operations (Insert Header, Insert Details, afterCreateFn) succeed or all fail
 // - Consistency: Database remains in a valid state
 // - Isolation: Concurrent transactions don't interfere
 // - Durability: Committed changes are permanent
@@ -25,35 +69,447 @@ func NewPurchasingRepository() *PurchasingRepository {
 func (r *PurchasingRepository) CreatePurchasingTransaction(
 	purchasing *models.Purchasing,
 	details []models.PurchasingDetail,
-	updateStockFn func(tx *gorm.DB, itemID uint, qty int) error,
+	afterCreateFn func(tx *gorm.DB, purchasing *models.Purchasing) error,
 ) error {
 	return config.DB.Transaction(func(tx *gorm.DB) error {
-		// Step 1: Insert Purchasing Header
-		// If this fails, transaction will rollback
-		if err := tx.Create(purchasing).Error; err != nil {
+		return r.CreatePurchasingWithTx(tx, purchasing, details, afterCreateFn)
+	})
+}
+
+// CreatePurchasingWithTx creates a purchasing with its details using the provided transaction
+// See CreatePurchasingTransaction; the caller commits or rolls back. The lines' subtotals, taxes
+// and the purchasing's cost breakdown are computed here from the unit prices, discounts and tax
+// codes (see calculatePurchasingTotals); ErrInvalidDiscount or ErrInvalidTaxCode is returned for
+// a discount or tax code that cannot be applied. The purchasing is in the supplier's currency and
+// its base currency total is converted at the rate of its date; a MissingExchangeRateError is
+// returned when there is no such rate. The lines are checked against the supplier contracts in
+// force (see ContractRepository.CheckWithTx); a ContractViolationError is returned for a line a
+// contract refuses.
+func (r *PurchasingRepository) CreatePurchasingWithTx(
+	tx *gorm.DB,
+	purchasing *models.Purchasing,
+	details []models.PurchasingDetail,
+	afterCreateFn func(tx *gorm.DB, purchasing *models.Purchasing) error,
+) error {
+	// Step 1: Insert Purchasing Header
+	// If this fails, transaction will rollback
+	if purchasing.Status == "" {
+		purchasing.Status = models.PurchasingStatusDraft
+	}
+	if purchasing.Origin == "" {
+		purchasing.Origin = models.PurchasingOriginManual
+	}
+	if err := applyTaxCodesWithTx(tx, details); err != nil {
+		return err
+	}
+	if err := calculatePurchasingTotals(purchasing, details); err != nil {
+		return err
+	}
+	if err := r.convertToBaseWithTx(tx, purchasing); err != nil {
+		return err
+	}
+	if err := r.contractRepo.CheckWithTx(tx, purchasing, details); err != nil {
+		return err
+	}
+	if err := r.budgetRepo.CheckWithTx(tx, purchasing); err != nil {
+		return err
+	}
+	if err := tx.Create(purchasing).Error; err != nil {
+		return err
+	}
+
+	// Record the initial status so the history starts at creation
+	if err := r.recordStatusWithTx(tx, purchasing.ID, "", purchasing.Status, purchasing.UserID, "created"); err != nil {
+		return err
+	}
+
+	// Step 2: Insert Purchasing Details
+	// All operations must succeed, otherwise entire transaction rolls back
+	for i := range details {
+		details[i].PurchasingID = purchasing.ID
+
+		// Insert detail record
+		if err := tx.Create(&details[i]).Error; err != nil {
+			return err // Rollback entire transaction
+		}
+	}
+
+	// Step 3: Side effects that must commit together with the purchasing
+	if afterCreateFn != nil {
+		if err := afterCreateFn(tx, purchasing); err != nil {
+			return err // Rollback entire transaction
+		}
+	}
+
+	// Everything is committed together by the caller's transaction
+	return nil
+}
+
+// convertToBaseWithTx sets the purchasing's currency to its supplier's and fills in the rate and base total
+func (r *PurchasingRepository) convertToBaseWithTx(tx *gorm.DB, purchasing *models.Purchasing) error {
+	var supplier models.Supplier
+	if err := tx.Select("id", "currency").First(&supplier, purchasing.SupplierID).Error; err != nil {
+		return err
+	}
+	purchasing.Currency = supplier.Currency
+	if purchasing.Currency == "" {
+		purchasing.Currency = config.BaseCurrency
+	}
+
+	rate, err := r.rateRepo.RateOnWithTx(tx, purchasing.Currency, purchasing.Date)
+	if err != nil {
+		return err
+	}
+	purchasing.ExchangeRate = rate
+	purchasing.BaseGrandTotal = purchasing.GrandTotal.Mul(rate).Round(2)
+	return nil
+}
+
+// FindByID finds a purchasing by ID
+func (r *PurchasingRepository) FindByID(id uint) (*models.Purchasing, error) {
+	var purchasing models.Purchasing
+	result := config.DB.First(&purchasing, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &purchasing, nil
+}
+
+// FindByIDWithDetails finds a purchasing by ID with supplier, user, warehouse and details (with items) preloaded
+func (r *PurchasingRepository) FindByIDWithDetails(id uint) (*models.Purchasing, error) {
+	var purchasing models.Purchasing
+	result := config.DB.
+		Preload("Supplier").
+		Preload("User").
+		Preload("Warehouse").
+		Preload("Department").
+		Preload("PurchasingDetails").
+		Preload("PurchasingDetails.Item").
+		Preload("PurchasingDetails.Unit").
+		Preload("PurchasingDetails.TaxCode").
+		Preload("PurchasingDetails.WithholdingCode").
+		First(&purchasing, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &purchasing, nil
+}
+
+// purchasingSortColumns maps the accepted sort keys of purchasing listings to indexed columns
+var purchasingSortColumns = map[string]string{
+	"id":             "id",
+	"date":           "date",
+	"grandTotal":     "grand_total",
+	"baseGrandTotal": "base_grand_total",
+	"status":         "status",
+}
+
+// List retrieves one page of purchasings matching the filter, newest first by default
+func (r *PurchasingRepository) List(filter PurchasingFilter, params ListParams) ([]models.Purchasing, PageInfo, error) {
+	query := config.DB.Model(&models.Purchasing{})
+
+	if filter.SupplierID != 0 {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.WarehouseID != 0 {
+		query = query.Where("warehouse_id = ?", filter.WarehouseID)
+	}
+	if filter.DepartmentID != 0 {
+		query = query.Where("department_id = ?", filter.DepartmentID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Origin != "" {
+		query = query.Where("origin = ?", filter.Origin)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("date < ?", *filter.DateTo)
+	}
+	if filter.MinTotal != nil {
+		query = query.Where("base_grand_total >= ?", *filter.MinTotal)
+	}
+	if filter.MaxTotal != nil {
+		query = query.Where("base_grand_total <= ?", *filter.MaxTotal)
+	}
+	if filter.OffContract {
+		query = query.Where("off_contract = ?", true)
+	}
+
+	query = query.Preload("Supplier").Preload("User")
+
+	return paginate(query, params, purchasingSortColumns, "date", func(p *models.Purchasing) (interface{}, uint) {
+		switch params.SortBy {
+		case "id":
+			return p.ID, p.ID
+		case "grandTotal":
+			return p.GrandTotal.String(), p.ID
+		case "baseGrandTotal":
+			return p.BaseGrandTotal.String(), p.ID
+		case "status":
+			return p.Status, p.ID
+		default:
+			// Formatted the way MySQL stores datetime values, in the connection's time zone
+			return p.Date.Format("2006-01-02 15:04:05"), p.ID
+		}
+	})
+}
+
+// CancelTransaction cancels a purchasing and reverses the stock effect of goods already received
+// The status change and every stock reversal happen in one transaction, so a purchasing is
+// never cancelled while its received goods are still counted in stock (or the other way round).
+// A purchasing with pending or approved supplier invoices is not cancelled (ErrPurchasingInvoiced):
+// its bills would stay payable for goods that were sent back.
+func (r *PurchasingRepository) CancelTransaction(
+	id uint,
+	userID uint,
+	note string,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) (*models.Purchasing, error) {
+	var purchasing *models.Purchasing
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purchasing, err = r.TransitionStatusWithTx(tx, id, models.PurchasingStatusCancelled, userID, note)
+		if err != nil {
+			return err
+		}
+
+		// The purchasing row is locked, so no invoice can be entered or decided meanwhile
+		var invoiced int64
+		if err := tx.Model(&models.SupplierInvoice{}).
+			Where("purchasing_id = ? AND status IN ?", id,
+				[]string{models.SupplierInvoiceStatusPending, models.SupplierInvoiceStatusApproved}).
+			Count(&invoiced).Error; err != nil {
+			return err
+		}
+		if invoiced > 0 {
+			return ErrPurchasingInvoiced
+		}
+
+		var details []models.PurchasingDetail
+		if err := tx.Where("purchasing_id = ? AND received_qty > 0", id).Find(&details).Error; err != nil {
 			return err
 		}
 
-		// Step 2: Insert Purchasing Details and Update Stock for each detail
-		// All operations must succeed, otherwise entire transaction rolls back
-		for i := range details {
-			details[i].PurchasingID = purchasing.ID
-			
-			// Insert detail record
-			if err := tx.Create(&details[i]).Error; err != nil {
-				return err // Rollback entire transaction
+		// Received quantities per detail and warehouse, as put away by the receipts
+		var received []struct {
+			PurchasingDetailID uint
+			WarehouseID        *uint
+			Quantity           int
+		}
+		if err := tx.Table("goods_receipt_lines").
+			Select("goods_receipt_lines.purchasing_detail_id, goods_receipts.warehouse_id, SUM(goods_receipt_lines.received_qty) AS quantity").
+			Joins("JOIN goods_receipts ON goods_receipts.id = goods_receipt_lines.goods_receipt_id").
+			Where("goods_receipts.purchasing_id = ?", id).
+			Group("goods_receipt_lines.purchasing_detail_id, goods_receipts.warehouse_id").
+			Scan(&received).Error; err != nil {
+			return err
+		}
+
+		// Take received goods back out of the warehouses they went into, using the same path
+		// that put them in. Quantities received before receipts existed come out of the
+		// purchasing's warehouse. Received quantities are in the line's unit, stock in the base unit.
+		for _, detail := range details {
+			remaining := detail.ReceivedQty
+			for _, put := range received {
+				if put.PurchasingDetailID != detail.ID || put.Quantity <= 0 {
+					continue
+				}
+				if err := updateStockFn(tx, &models.StockMovement{
+					ItemID:      detail.ItemID,
+					WarehouseID: put.WarehouseID,
+					Delta:       -detail.BaseQty(put.Quantity),
+					Reason:      models.StockMovementPurchasingCancel,
+					SourceType:  models.StockSourcePurchasing,
+					SourceID:    &id,
+					UserID:      &userID,
+				}); err != nil {
+					return err
+				}
+				remaining -= put.Quantity
 			}
 
-			// Update item stock (increase stock when purchasing)
-			// This uses the same transaction context to ensure atomicity
-			if err := updateStockFn(tx, details[i].ItemID, details[i].Qty); err != nil {
-				return err // Rollback entire transaction
+			if remaining > 0 {
+				if err := updateStockFn(tx, &models.StockMovement{
+					ItemID:      detail.ItemID,
+					WarehouseID: purchasing.WarehouseID,
+					Delta:       -detail.BaseQty(remaining),
+					Reason:      models.StockMovementPurchasingCancel,
+					SourceType:  models.StockSourcePurchasing,
+					SourceID:    &id,
+					UserID:      &userID,
+				}); err != nil {
+					return err
+				}
 			}
 		}
 
-		// If all operations succeed, transaction will commit automatically
-		// Returning nil commits the transaction
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return purchasing, nil
+}
+
+// FindForUpdateWithTx loads a purchasing and locks its row until the transaction ends
+func (r *PurchasingRepository) FindForUpdateWithTx(tx *gorm.DB, id uint) (*models.Purchasing, error) {
+	var purchasing models.Purchasing
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&purchasing, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &purchasing, nil
+}
+
+// TransitionStatus moves a purchasing to a new status in its own transaction
+func (r *PurchasingRepository) TransitionStatus(id uint, status string, userID uint, note string) (*models.Purchasing, error) {
+	var purchasing *models.Purchasing
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purchasing, err = r.TransitionStatusWithTx(tx, id, status, userID, note)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purchasing, nil
+}
+
+// TransitionStatusWithTx moves a purchasing to a new status using the provided transaction
+// The purchasing row is locked first so concurrent transitions are serialized, and the
+// transition is rejected with InvalidTransitionError if the state machine does not allow it.
+// Every successful transition is recorded in the status history with the acting user.
+func (r *PurchasingRepository) TransitionStatusWithTx(tx *gorm.DB, id uint, status string, userID uint, note string) (*models.Purchasing, error) {
+	purchasing, err := r.FindForUpdateWithTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !purchasing.CanTransitionTo(status) {
+		return nil, &InvalidTransitionError{
+			CurrentStatus:   purchasing.Status,
+			RequestedStatus: status,
+		}
+	}
+
+	from := purchasing.Status
+	if err := tx.Model(purchasing).Update("status", status).Error; err != nil {
+		return nil, err
+	}
+	purchasing.Status = status
+
+	// Approval commits the purchasing against its department's budget; cancelling or closing it
+	// gives back what was not invoiced
+	switch status {
+	case models.PurchasingStatusApproved:
+		err = r.budgetRepo.EncumberWithTx(tx, purchasing)
+	case models.PurchasingStatusCancelled, models.PurchasingStatusClosed:
+		err = r.budgetRepo.ReleaseWithTx(tx, purchasing)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.recordStatusWithTx(tx, purchasing.ID, from, status, userID, note); err != nil {
+		return nil, err
+	}
+
+	return purchasing, nil
+}
+
+// OrderTransaction marks an approved purchasing as ordered
+// When expectedDelivery is given it replaces the day the supplier is expected to deliver. A
+// purchasing ordered without one that has none yet is expected config.ScorecardDefaultLeadDays
+// after its date, so every ordered purchasing can be measured by supplier scorecards.
+func (r *PurchasingRepository) OrderTransaction(id, userID uint, note string, expectedDelivery *time.Time) (*models.Purchasing, error) {
+	var purchasing *models.Purchasing
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		purchasing, err = r.TransitionStatusWithTx(tx, id, models.PurchasingStatusOrdered, userID, note)
+		if err != nil {
+			return err
+		}
+		if expectedDelivery == nil {
+			if purchasing.ExpectedDeliveryDate != nil {
+				return nil
+			}
+			expected := utils.DateOf(purchasing.Date).AddDate(0, 0, config.ScorecardDefaultLeadDays)
+			expectedDelivery = &expected
+		}
+		purchasing.ExpectedDeliveryDate = expectedDelivery
+		return tx.Model(purchasing).Update("expected_delivery_date", *expectedDelivery).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return purchasing, nil
+}
+
+// GetStatusHistory retrieves the status transitions of a purchasing, oldest first
+func (r *PurchasingRepository) GetStatusHistory(purchasingID uint) ([]models.PurchasingStatusHistory, error) {
+	var history []models.PurchasingStatusHistory
+	result := config.DB.Where("purchasing_id = ?", purchasingID).Order("changed_at ASC, id ASC").Find(&history)
+	return history, result.Error
+}
+
+// BackfillLegacyStatus marks purchasings created before statuses existed as received
+// Those orders already moved stock when they were created, so they are treated as fulfilled
+// and their details are marked as fully received.
+func (r *PurchasingRepository) BackfillLegacyStatus() error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		legacy := tx.Model(&models.Purchasing{}).Select("id").Where("status = ?", "")
+		if err := tx.Model(&models.PurchasingDetail{}).
+			Where("purchasing_id IN (?)", legacy).
+			Update("received_qty", gorm.Expr("qty")).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Purchasing{}).
+			Where("status = ?", "").
+			Update("status", models.PurchasingStatusReceived).Error
+	})
 }
 
+// recordStatusWithTx inserts a status history entry using the provided transaction
+func (r *PurchasingRepository) recordStatusWithTx(tx *gorm.DB, purchasingID uint, from, to string, userID uint, note string) error {
+	entry := models.PurchasingStatusHistory{
+		PurchasingID: purchasingID,
+		FromStatus:   from,
+		ToStatus:     to,
+		UserID:       userID,
+		Note:         note,
+		ChangedAt:    time.Now(),
+	}
+	return tx.Create(&entry).Error
+}
+
+// BackfillTotals fills in the cost breakdown of purchasings created before it was stored
+// Those had neither discounts nor taxes, so their net amounts are their subtotals.
+// Safe to run on every startup.
+func (r *PurchasingRepository) BackfillTotals() error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.PurchasingDetail{}).
+			Where("net_amount = 0 AND sub_total <> 0 AND discount_amount = 0 AND header_discount = 0").
+			Update("net_amount", gorm.Expr("sub_total")).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Purchasing{}).
+			Where("sub_total = 0 AND shipping_amount = 0 AND grand_total <> 0").
+			Updates(map[string]interface{}{
+				"sub_total": gorm.Expr("grand_total"),
+				"net_total": gorm.Expr("grand_total"),
+			}).Error
+	})
+}
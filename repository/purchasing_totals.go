@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"procurement-system/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Purchasing pricing errors
+var (
+	ErrInvalidDiscount = errors.New("discount must be a percentage from 0 to 100 or an amount from 0 up to the amount it applies to")
+	ErrInvalidTaxCode  = errors.New("tax code does not exist, is inactive or is of the wrong kind")
+)
+
+// hundred turns percentages into fractions
+var hundred = decimal.NewFromInt(100)
+
+// applyTaxCodesWithTx copies the current rate of each line's tax and withholding code onto the line
+// A code that does not exist, is inactive or is of the wrong kind for its field is refused with
+// ErrInvalidTaxCode.
+func applyTaxCodesWithTx(tx *gorm.DB, details []models.PurchasingDetail) error {
+	codes := map[uint]models.TaxCode{}
+	rateOf := func(id *uint, kind string) (decimal.Decimal, error) {
+		if id == nil {
+			return decimal.Zero, nil
+		}
+		code, ok := codes[*id]
+		if !ok {
+			if err := tx.Limit(1).Find(&code, *id).Error; err != nil {
+				return decimal.Zero, err
+			}
+			codes[*id] = code
+		}
+		if code.ID == 0 || !code.Active || code.Kind != kind {
+			return decimal.Zero, fmt.Errorf("tax code %d: %w", *id, ErrInvalidTaxCode)
+		}
+		return code.Rate, nil
+	}
+
+	for i := range details {
+		d := &details[i]
+		rate, err := rateOf(d.TaxCodeID, models.TaxKindVAT)
+		if err != nil {
+			return err
+		}
+		d.TaxRate = rate
+
+		rate, err = rateOf(d.WithholdingCodeID, models.TaxKindWithholding)
+		if err != nil {
+			return err
+		}
+		d.WithholdingRate = rate
+	}
+	return nil
+}
+
+// calculatePurchasingTotals prices the lines and fills in the purchasing's cost breakdown
+// The lines need their unit price, quantity, discount and tax rates; the purchasing its discount
+// and shipping. The rounding rules are:
+// - Every amount is rounded to 2 decimals, halves away from zero, as soon as it is computed
+// - A line's discount applies to its subtotal (unit price × quantity)
+// - The purchasing's discount applies to the lines after their discounts and is shared out over them
+// - Taxes are computed per line on its net amount, after both discounts
+// - Shipping is not taxed
+// - Header totals are sums of the rounded line amounts, so the lines always add up to them
+//
+// Each line's share of the purchasing's discount is proportional to its amount; the rounding
+// difference goes to the largest line, so the shares add up to the discount exactly.
+func calculatePurchasingTotals(purchasing *models.Purchasing, details []models.PurchasingDetail) error {
+	subTotal := decimal.Zero
+	lineDiscounts := decimal.Zero
+	for i := range details {
+		d := &details[i]
+		d.SubTotal = d.UnitPrice.Mul(decimal.NewFromInt(int64(d.Qty))).Round(2)
+
+		discount, err := discountAmount(d.DiscountType, d.DiscountValue, d.SubTotal)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		d.DiscountAmount = discount
+		d.NetAmount = d.SubTotal.Sub(discount)
+
+		subTotal = subTotal.Add(d.SubTotal)
+		lineDiscounts = lineDiscounts.Add(discount)
+	}
+
+	discounted := subTotal.Sub(lineDiscounts)
+	headerDiscount, err := discountAmount(purchasing.DiscountType, purchasing.DiscountValue, discounted)
+	if err != nil {
+		return err
+	}
+	allocateHeaderDiscount(details, headerDiscount, discounted)
+
+	netTotal := decimal.Zero
+	taxTotal := decimal.Zero
+	withholdingTotal := decimal.Zero
+	for i := range details {
+		d := &details[i]
+		d.NetAmount = d.NetAmount.Sub(d.HeaderDiscount)
+		d.TaxAmount = d.NetAmount.Mul(d.TaxRate).Div(hundred).Round(2)
+		d.WithholdingAmount = d.NetAmount.Mul(d.WithholdingRate).Div(hundred).Round(2)
+
+		netTotal = netTotal.Add(d.NetAmount)
+		taxTotal = taxTotal.Add(d.TaxAmount)
+		withholdingTotal = withholdingTotal.Add(d.WithholdingAmount)
+	}
+
+	purchasing.ShippingAmount = purchasing.ShippingAmount.Round(2)
+	purchasing.SubTotal = subTotal
+	purchasing.DiscountTotal = lineDiscounts.Add(headerDiscount)
+	purchasing.NetTotal = netTotal
+	purchasing.TaxTotal = taxTotal
+	purchasing.WithholdingTotal = withholdingTotal
+	purchasing.GrandTotal = netTotal.Add(taxTotal).Sub(withholdingTotal).Add(purchasing.ShippingAmount)
+	return nil
+}
+
+// discountAmount is the discount of the given type and value on amount, rounded to 2 decimals
+// An empty type is no discount.
+func discountAmount(discountType string, value, amount decimal.Decimal) (decimal.Decimal, error) {
+	switch discountType {
+	case "":
+		if !value.IsZero() {
+			return decimal.Zero, ErrInvalidDiscount
+		}
+		return decimal.Zero, nil
+	case models.DiscountTypePercent:
+		if value.IsNegative() || value.GreaterThan(hundred) {
+			return decimal.Zero, ErrInvalidDiscount
+		}
+		return amount.Mul(value).Div(hundred).Round(2), nil
+	case models.DiscountTypeAmount:
+		discount := value.Round(2)
+		if discount.IsNegative() || discount.GreaterThan(amount) {
+			return decimal.Zero, ErrInvalidDiscount
+		}
+		return discount, nil
+	default:
+		return decimal.Zero, ErrInvalidDiscount
+	}
+}
+
+// allocateHeaderDiscount sets each line's share of the purchasing's discount in proportion to
+// its net amount, giving the rounding difference to the largest line
+func allocateHeaderDiscount(details []models.PurchasingDetail, discount, base decimal.Decimal) {
+	if discount.IsZero() || !base.IsPositive() {
+		for i := range details {
+			details[i].HeaderDiscount = decimal.Zero
+		}
+		return
+	}
+
+	largest := 0
+	allocated := decimal.Zero
+	for i := range details {
+		d := &details[i]
+		d.HeaderDiscount = discount.Mul(d.NetAmount).Div(base).Round(2)
+		allocated = allocated.Add(d.HeaderDiscount)
+		if d.NetAmount.GreaterThan(details[largest].NetAmount) {
+			largest = i
+		}
+	}
+	details[largest].HeaderDiscount = details[largest].HeaderDiscount.Add(discount.Sub(allocated))
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// openPurchasingStatuses are the statuses of purchasings whose outstanding quantities are still on order
+var openPurchasingStatuses = []string{
+	models.PurchasingStatusDraft,
+	models.PurchasingStatusSubmitted,
+	models.PurchasingStatusApproved,
+	models.PurchasingStatusOrdered,
+	models.PurchasingStatusPartiallyReceived,
+}
+
+// ReorderSuggestion is an item at or below its reorder point with the quantity suggested to order
+// Position is what the decision is based on: stock on hand, plus stock in transit between
+// warehouses, plus what open purchasings (drafts included) have yet to deliver. Counting drafts
+// keeps the planner from suggesting the same shortage twice. Quantities are in the item's base
+// unit, UnitID.
+type ReorderSuggestion struct {
+	ItemID       uint   `json:"itemId"`
+	ItemName     string `json:"itemName"`
+	SupplierID   uint   `json:"supplierId"`
+	UnitID       *uint  `json:"unitId"`
+	Stock        int    `json:"stock"`
+	InTransit    int    `json:"inTransit"`
+	OnOrder      int    `json:"onOrder"`
+	Position     int    `json:"position"`
+	ReorderPoint int    `json:"reorderPoint"`
+	ReorderQty   int    `json:"reorderQty"`
+	MaxStock     int    `json:"maxStock"`
+	SuggestedQty int    `json:"suggestedQty"`
+
+	// Price of the suggested quantity today, from the supplier's price list when it has one
+	UnitPrice       decimal.Decimal `json:"unitPrice"`
+	SupplierPriceID *uint           `json:"supplierPriceId"`
+
+	// Taxes the draft line is charged: the item's default codes
+	TaxCodeID         *uint `json:"taxCodeId"`
+	WithholdingCodeID *uint `json:"withholdingCodeId"`
+}
+
+// ReorderRun is the outcome of drafting purchasings for the reorder suggestions
+type ReorderRun struct {
+	Drafts  []models.Purchasing `json:"drafts"`
+	Skipped []ReorderSkip       `json:"skipped"`
+}
+
+// ReorderSkip is a supplier whose items were not drafted on this run, and why
+type ReorderSkip struct {
+	SupplierID uint   `json:"supplierId"`
+	Reason     string `json:"reason"`
+}
+
+// ReorderRepository finds items that need reordering and drafts purchasings for them
+type ReorderRepository struct {
+	purchasingRepo *PurchasingRepository
+	warehouseRepo  *WarehouseRepository
+	priceRepo      *SupplierPriceRepository
+}
+
+// NewReorderRepository creates a new ReorderRepository instance
+func NewReorderRepository() *ReorderRepository {
+	return &ReorderRepository{
+		purchasingRepo: NewPurchasingRepository(),
+		warehouseRepo:  NewWarehouseRepository(),
+		priceRepo:      NewSupplierPriceRepository(),
+	}
+}
+
+// FindSuggestions lists the items that need reordering, grouped by supplier (0 for every supplier)
+func (r *ReorderRepository) FindSuggestions(supplierID uint) ([]ReorderSuggestion, error) {
+	return r.findSuggestionsWithTx(config.DB, supplierID, false)
+}
+
+// CreateDraftsTransaction drafts one purchasing per supplier for every item that needs reordering
+// The drafts go to the default warehouse, priced from the suppliers' price lists, created by userID
+// and marked with the reorder origin. The items with reorder settings stay locked until commit,
+// so concurrent runs wait for each other and the second sees the first's drafts as on order.
+// afterCreateFn runs for each draft inside the transaction, as in CreatePurchasingTransaction.
+// Suppliers whose currency has no exchange rate today, or whose items default to a tax code that
+// was deactivated, are skipped and drafted on a later run.
+func (r *ReorderRepository) CreateDraftsTransaction(
+	userID uint,
+	afterCreateFn func(tx *gorm.DB, purchasing *models.Purchasing) error,
+) (*ReorderRun, error) {
+	run := ReorderRun{Drafts: []models.Purchasing{}, Skipped: []ReorderSkip{}}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		suggestions, err := r.findSuggestionsWithTx(tx, 0, true)
+		if err != nil {
+			return err
+		}
+		if len(suggestions) == 0 {
+			return nil
+		}
+
+		warehouse, err := r.warehouseRepo.FindDefaultWithTx(tx)
+		if err != nil {
+			return err
+		}
+
+		// Suggestions are ordered by supplier, so each run of the same supplier is one draft
+		for start := 0; start < len(suggestions); {
+			end := start
+			for end < len(suggestions) && suggestions[end].SupplierID == suggestions[start].SupplierID {
+				end++
+			}
+
+			details := make([]models.PurchasingDetail, 0, end-start)
+			for _, s := range suggestions[start:end] {
+				details = append(details, models.PurchasingDetail{
+					ItemID:            s.ItemID,
+					Qty:               s.SuggestedQty,
+					UnitID:            s.UnitID,
+					UnitFactor:        1,
+					UnitPrice:         s.UnitPrice,
+					SupplierPriceID:   s.SupplierPriceID,
+					TaxCodeID:         s.TaxCodeID,
+					WithholdingCodeID: s.WithholdingCodeID,
+				})
+			}
+
+			purchasing := models.Purchasing{
+				Date:        time.Now(),
+				SupplierID:  suggestions[start].SupplierID,
+				UserID:      userID,
+				WarehouseID: &warehouse.ID,
+				Status:      models.PurchasingStatusDraft,
+				Origin:      models.PurchasingOriginReorder,
+			}
+			// The rate and contracts are checked before anything is written, so a skipped supplier leaves nothing behind
+			err := r.purchasingRepo.CreatePurchasingWithTx(tx, &purchasing, details, afterCreateFn)
+			var rateErr *MissingExchangeRateError
+			var contractErr *ContractViolationError
+			if errors.As(err, &rateErr) || errors.As(err, &contractErr) || errors.Is(err, ErrInvalidTaxCode) {
+				run.Skipped = append(run.Skipped, ReorderSkip{SupplierID: purchasing.SupplierID, Reason: err.Error()})
+				start = end
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			purchasing.PurchasingDetails = details
+			run.Drafts = append(run.Drafts, purchasing)
+
+			start = end
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// findSuggestionsWithTx computes the inventory position of every item with a reorder point and
+// keeps those at or below it, optionally locking the items for the rest of the transaction
+func (r *ReorderRepository) findSuggestionsWithTx(tx *gorm.DB, supplierID uint, lock bool) ([]ReorderSuggestion, error) {
+	query := tx.Where("reorder_point IS NOT NULL")
+	if supplierID != 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var items []models.Item
+	if err := query.Order("supplier_id ASC, id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return []ReorderSuggestion{}, nil
+	}
+
+	itemIDs := make([]uint, len(items))
+	for i := range items {
+		itemIDs[i] = items[i].ID
+	}
+
+	type itemQuantity struct {
+		ItemID   uint
+		Quantity int
+	}
+
+	var onOrder []itemQuantity
+	err := tx.Model(&models.PurchasingDetail{}).
+		Select("purchasing_details.item_id, SUM((purchasing_details.qty - purchasing_details.received_qty) * purchasing_details.unit_factor) AS quantity").
+		Joins("JOIN purchasings ON purchasings.id = purchasing_details.purchasing_id").
+		Where("purchasings.status IN ? AND purchasing_details.qty > purchasing_details.received_qty", openPurchasingStatuses).
+		Where("purchasing_details.item_id IN ?", itemIDs).
+		Group("purchasing_details.item_id").
+		Scan(&onOrder).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var inTransit []itemQuantity
+	err = tx.Model(&models.StockTransferLine{}).
+		Select("stock_transfer_lines.item_id, SUM(stock_transfer_lines.quantity) AS quantity").
+		Joins("JOIN stock_transfers ON stock_transfers.id = stock_transfer_lines.stock_transfer_id").
+		Where("stock_transfers.status = ? AND stock_transfer_lines.item_id IN ?", models.StockTransferStatusInTransit, itemIDs).
+		Group("stock_transfer_lines.item_id").
+		Scan(&inTransit).Error
+	if err != nil {
+		return nil, err
+	}
+
+	onOrderByItem := make(map[uint]int, len(onOrder))
+	for _, q := range onOrder {
+		onOrderByItem[q.ItemID] = q.Quantity
+	}
+	inTransitByItem := make(map[uint]int, len(inTransit))
+	for _, q := range inTransit {
+		inTransitByItem[q.ItemID] = q.Quantity
+	}
+
+	now := time.Now()
+	suggestions := []ReorderSuggestion{}
+	for _, item := range items {
+		position := item.Stock + inTransitByItem[item.ID] + onOrderByItem[item.ID]
+		if position > *item.ReorderPoint {
+			continue
+		}
+
+		qty := suggestedReorderQty(&item, position)
+		if qty <= 0 {
+			continue
+		}
+
+		unitPrice, priceID, err := r.priceRepo.ResolveUnitPriceWithTx(tx, item.SupplierID, &item, qty, now)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, ReorderSuggestion{
+			ItemID:       item.ID,
+			ItemName:     item.Name,
+			SupplierID:   item.SupplierID,
+			UnitID:       item.BaseUnitID,
+			Stock:        item.Stock,
+			InTransit:    inTransitByItem[item.ID],
+			OnOrder:      onOrderByItem[item.ID],
+			Position:     position,
+			ReorderPoint: *item.ReorderPoint,
+			ReorderQty:   item.ReorderQty,
+			MaxStock:     item.MaxStock,
+			SuggestedQty: qty,
+
+			UnitPrice:       unitPrice,
+			SupplierPriceID: priceID,
+
+			TaxCodeID:         item.TaxCodeID,
+			WithholdingCodeID: item.WithholdingCodeID,
+		})
+	}
+	return suggestions, nil
+}
+
+// suggestedReorderQty is what brings the item up to MaxStock when that is set, otherwise the
+// smallest multiple of ReorderQty that lifts the position back above the reorder point
+func suggestedReorderQty(item *models.Item, position int) int {
+	if item.MaxStock > 0 {
+		return item.MaxStock - position
+	}
+	if item.ReorderQty <= 0 {
+		return 0
+	}
+	batches := (*item.ReorderPoint-position)/item.ReorderQty + 1
+	return batches * item.ReorderQty
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidGroupBy is returned when a report is requested with an unknown grouping
+var ErrInvalidGroupBy = errors.New("invalid groupBy")
+
+// ReportRepository computes reports over purchasings and supplier invoices
+// Amounts in other currencies are reported in the base currency at the rate stored on each
+// purchasing, the rate of its order date, so a report never changes when rates are updated.
+type ReportRepository struct{}
+
+// NewReportRepository creates a new ReportRepository instance
+func NewReportRepository() *ReportRepository {
+	return &ReportRepository{}
+}
+
+// PurchasingReportFilter holds the criteria of a purchasing summary
+// Without Statuses every purchasing except drafts and cancelled ones is included.
+type PurchasingReportFilter struct {
+	GroupBy  string // supplier, currency or month
+	DateFrom *time.Time
+	DateTo   *time.Time // exclusive
+	Statuses []string
+}
+
+// CurrencyTotal is an amount in one transaction currency
+type CurrencyTotal struct {
+	Currency string          `json:"currency"`
+	Total    decimal.Decimal `json:"total"`
+}
+
+// PurchasingSummaryRow is one group of a purchasing summary
+// BaseTotal is in the base currency; Totals lists the same purchasings in their own currencies.
+type PurchasingSummaryRow struct {
+	Key       string          `json:"key"`
+	Label     string          `json:"label"`
+	Count     int64           `json:"count"`
+	BaseTotal decimal.Decimal `json:"baseTotal"`
+	Totals    []CurrencyTotal `json:"totals"`
+}
+
+// purchasingReportGroups maps the accepted groupings to the key and label expressions
+var purchasingReportGroups = map[string]struct{ key, label string }{
+	"supplier": {"CAST(purchasings.supplier_id AS CHAR)", "suppliers.name"},
+	"currency": {"purchasings.currency", "purchasings.currency"},
+	"month":    {"DATE_FORMAT(purchasings.date, '%Y-%m')", "DATE_FORMAT(purchasings.date, '%Y-%m')"},
+}
+
+// PurchasingSummary totals the purchasings matching the filter per group, ordered by key
+func (r *ReportRepository) PurchasingSummary(filter PurchasingReportFilter) ([]PurchasingSummaryRow, error) {
+	group, ok := purchasingReportGroups[filter.GroupBy]
+	if !ok {
+		return nil, ErrInvalidGroupBy
+	}
+
+	query := config.DB.Model(&models.Purchasing{}).
+		Select(group.key + " AS group_key, " + group.label + " AS label, purchasings.currency, " +
+			"COUNT(*) AS count, SUM(purchasings.grand_total) AS total, SUM(purchasings.base_grand_total) AS base_total").
+		Joins("JOIN suppliers ON suppliers.id = purchasings.supplier_id")
+	if len(filter.Statuses) > 0 {
+		query = query.Where("purchasings.status IN ?", filter.Statuses)
+	} else {
+		query = query.Where("purchasings.status NOT IN ?", []string{models.PurchasingStatusDraft, models.PurchasingStatusCancelled})
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("purchasings.date >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("purchasings.date < ?", *filter.DateTo)
+	}
+
+	var rows []struct {
+		GroupKey  string
+		Label     string
+		Currency  string
+		Count     int64
+		Total     decimal.Decimal
+		BaseTotal decimal.Decimal
+	}
+	err := query.
+		Group("group_key, label, purchasings.currency").
+		Order("group_key ASC, purchasings.currency ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows come ordered by key, so the currencies of a group are adjacent
+	summary := []PurchasingSummaryRow{}
+	for _, row := range rows {
+		if n := len(summary); n == 0 || summary[n-1].Key != row.GroupKey {
+			summary = append(summary, PurchasingSummaryRow{
+				Key:       row.GroupKey,
+				Label:     row.Label,
+				BaseTotal: decimal.Zero,
+				Totals:    []CurrencyTotal{},
+			})
+		}
+		current := &summary[len(summary)-1]
+		current.Count += row.Count
+		current.BaseTotal = current.BaseTotal.Add(row.BaseTotal)
+		current.Totals = append(current.Totals, CurrencyTotal{Currency: row.Currency, Total: row.Total})
+	}
+	return summary, nil
+}
+
+// AgedPayablesRow is what is owed to one supplier, split by how long it is past due
+// The buckets and BaseTotal are in the base currency; Totals lists the same balances in the
+// invoices' own currencies.
+type AgedPayablesRow struct {
+	SupplierID   uint            `json:"supplierId"`
+	SupplierName string          `json:"supplierName"`
+	InvoiceCount int64           `json:"invoiceCount"`
+	Current      decimal.Decimal `json:"current"`
+	Days1To30    decimal.Decimal `json:"days1To30"`
+	Days31To60   decimal.Decimal `json:"days31To60"`
+	Days61To90   decimal.Decimal `json:"days61To90"`
+	Over90       decimal.Decimal `json:"over90"`
+	BaseTotal    decimal.Decimal `json:"baseTotal"`
+	Totals       []CurrencyTotal `json:"totals"`
+}
+
+// AgedPayables ages the balances of approved invoices per supplier as of a day
+// An invoice is current until its due date and then falls into the 1-30, 31-60, 61-90 or 90+
+// days past due bucket, counted in calendar days. Invoices dated after asOf are left out, and
+// balances only deduct the payments (and discounts taken with them) made by the end of asOf,
+// so a past asOf reproduces what was owed then. asOf is an instant, like the invoice dates.
+// supplierID 0 means every supplier. Rows are ordered by supplier name.
+func (r *ReportRepository) AgedPayables(asOf time.Time, supplierID uint) ([]AgedPayablesRow, error) {
+	day := utils.DateOf(asOf)
+	endOfDay := utils.StartOfDate(day).AddDate(0, 0, 1)
+	settled := config.DB.Model(&models.SupplierPayment{}).
+		Select("supplier_payments.invoice_id, SUM(supplier_payments.amount + supplier_payments.discount_taken) AS settled").
+		Where("supplier_payments.payment_date < ?", endOfDay).
+		Group("supplier_payments.invoice_id")
+
+	query := config.DB.Model(&models.SupplierInvoice{}).
+		Select("supplier_invoices.supplier_id, suppliers.name AS supplier_name, supplier_invoices.currency, "+
+			"supplier_invoices.due_date, purchasings.exchange_rate, "+
+			"supplier_invoices.total - COALESCE(settled.settled, 0) AS balance").
+		Joins("JOIN suppliers ON suppliers.id = supplier_invoices.supplier_id").
+		Joins("JOIN purchasings ON purchasings.id = supplier_invoices.purchasing_id").
+		Joins("LEFT JOIN (?) AS settled ON settled.invoice_id = supplier_invoices.id", settled).
+		Where("supplier_invoices.status = ?", models.SupplierInvoiceStatusApproved).
+		Where("supplier_invoices.invoice_date < ?", endOfDay)
+	if supplierID != 0 {
+		query = query.Where("supplier_invoices.supplier_id = ?", supplierID)
+	}
+
+	var invoices []struct {
+		SupplierID   uint
+		SupplierName string
+		Currency     string
+		DueDate      *time.Time
+		ExchangeRate decimal.Decimal
+		Balance      decimal.Decimal
+	}
+	if err := query.Order("suppliers.name ASC, supplier_invoices.supplier_id ASC, supplier_invoices.currency ASC").Scan(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	// Invoices come ordered by supplier, so the invoices of a supplier are adjacent
+	report := []AgedPayablesRow{}
+	for _, invoice := range invoices {
+		if !invoice.Balance.IsPositive() {
+			continue
+		}
+		if n := len(report); n == 0 || report[n-1].SupplierID != invoice.SupplierID {
+			report = append(report, AgedPayablesRow{
+				SupplierID:   invoice.SupplierID,
+				SupplierName: invoice.SupplierName,
+				Current:      decimal.Zero,
+				Days1To30:    decimal.Zero,
+				Days31To60:   decimal.Zero,
+				Days61To90:   decimal.Zero,
+				Over90:       decimal.Zero,
+				BaseTotal:    decimal.Zero,
+				Totals:       []CurrencyTotal{},
+			})
+		}
+		row := &report[len(report)-1]
+		row.InvoiceCount++
+
+		base := invoice.Balance.Mul(invoice.ExchangeRate).Round(2)
+		daysPastDue := 0
+		if invoice.DueDate != nil {
+			daysPastDue = int(day.Sub(utils.DateOf(*invoice.DueDate)).Hours() / 24)
+		}
+		switch {
+		case daysPastDue <= 0:
+			row.Current = row.Current.Add(base)
+		case daysPastDue <= 30:
+			row.Days1To30 = row.Days1To30.Add(base)
+		case daysPastDue <= 60:
+			row.Days31To60 = row.Days31To60.Add(base)
+		case daysPastDue <= 90:
+			row.Days61To90 = row.Days61To90.Add(base)
+		default:
+			row.Over90 = row.Over90.Add(base)
+		}
+		row.BaseTotal = row.BaseTotal.Add(base)
+
+		if n := len(row.Totals); n > 0 && row.Totals[n-1].Currency == invoice.Currency {
+			row.Totals[n-1].Total = row.Totals[n-1].Total.Add(invoice.Balance)
+		} else {
+			row.Totals = append(row.Totals, CurrencyTotal{Currency: invoice.Currency, Total: invoice.Balance})
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,609 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RFQ errors
+var (
+	ErrSupplierNotInvited = errors.New("supplier was not invited to quote for this RFQ")
+	ErrRFQPastDue         = errors.New("the RFQ is past its due date and no longer takes quotes")
+	ErrQuoteIncomplete    = errors.New("the supplier's quote does not price every line with a price that is still valid")
+)
+
+// RFQStatusError is returned when an RFQ is not in a status that allows the action
+type RFQStatusError struct {
+	CurrentStatus string
+	Action        string
+}
+
+func (e *RFQStatusError) Error() string {
+	return fmt.Sprintf("cannot %s an RFQ that is %s", e.Action, e.CurrentStatus)
+}
+
+// RFQLineError is returned when a quote line does not fit the RFQ
+type RFQLineError struct {
+	RFQLineID uint
+	Message   string
+}
+
+func (e *RFQLineError) Error() string {
+	return fmt.Sprintf("RFQ line %d: %s", e.RFQLineID, e.Message)
+}
+
+// RFQComparison sets the quotes of an RFQ side by side on a day
+// Quotes are ranked from best to worst; Lines show every supplier's offer for each RFQ line.
+type RFQComparison struct {
+	RFQID    uint                `json:"rfqId"`
+	AsOf     time.Time           `json:"asOf"`
+	Currency string              `json:"currency"`
+	Quotes   []RFQQuoteSummary   `json:"quotes"`
+	Lines    []RFQLineComparison `json:"lines"`
+}
+
+// RFQQuoteSummary is one supplier's quote as a whole
+// Total is the goods plus shipping in the quote's currency; LandedCost is the same in the base
+// currency at the exchange rate of the day. Quotes are ranked by landed cost, then by the longest
+// lead time of their lines. Only complete quotes, pricing every line with a price still valid, whose
+// currency has a rate, are ranked; the others have rank 0 and say why in Note.
+type RFQQuoteSummary struct {
+	Rank            int              `json:"rank"`
+	SupplierID      uint             `json:"supplierId"`
+	SupplierName    string           `json:"supplierName"`
+	Currency        string           `json:"currency"`
+	QuotedLines     int              `json:"quotedLines"`
+	Complete        bool             `json:"complete"`
+	GoodsTotal      decimal.Decimal  `json:"goodsTotal"`
+	ShippingAmount  decimal.Decimal  `json:"shippingAmount"`
+	Total           decimal.Decimal  `json:"total"`
+	ExchangeRate    *decimal.Decimal `json:"exchangeRate"`
+	LandedCost      *decimal.Decimal `json:"landedCost"`
+	MaxLeadTimeDays int              `json:"maxLeadTimeDays"`
+	ValidUntil      *time.Time       `json:"validUntil"`
+	Note            string           `json:"note,omitempty"`
+}
+
+// RFQLineComparison is every supplier's offer for one RFQ line
+type RFQLineComparison struct {
+	RFQLineID uint           `json:"rfqLineId"`
+	ItemID    uint           `json:"itemId"`
+	ItemName  string         `json:"itemName"`
+	Qty       int            `json:"qty"`
+	Offers    []RFQLineOffer `json:"offers"`
+}
+
+// RFQLineOffer is a supplier's price for an RFQ line
+// BaseUnitPrice is UnitPrice in the base currency. Best marks the lowest valid base price, the
+// shortest lead time breaking ties.
+type RFQLineOffer struct {
+	SupplierID    uint             `json:"supplierId"`
+	UnitPrice     decimal.Decimal  `json:"unitPrice"`
+	BaseUnitPrice *decimal.Decimal `json:"baseUnitPrice"`
+	LeadTimeDays  int              `json:"leadTimeDays"`
+	ValidUntil    time.Time        `json:"validUntil"`
+	Expired       bool             `json:"expired"`
+	Best          bool             `json:"best"`
+}
+
+// RFQRepository handles requests for quotation, their quotes and awards
+type RFQRepository struct {
+	purchasingRepo *PurchasingRepository
+	rateRepo       *ExchangeRateRepository
+}
+
+// NewRFQRepository creates a new RFQRepository instance
+func NewRFQRepository() *RFQRepository {
+	return &RFQRepository{
+		purchasingRepo: NewPurchasingRepository(),
+		rateRepo:       NewExchangeRateRepository(),
+	}
+}
+
+// FindByID finds an RFQ with its lines, invited suppliers and quotes
+func (r *RFQRepository) FindByID(id uint) (*models.RFQ, error) {
+	var rfq models.RFQ
+	result := config.DB.
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Lines.Item").
+		Preload("Suppliers").
+		Preload("Suppliers.Supplier").
+		Preload("Quotes").
+		Preload("Quotes.Supplier").
+		Preload("Quotes.Lines").
+		First(&rfq, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &rfq, nil
+}
+
+// RFQFilter holds the optional criteria for listing RFQs
+// Zero values mean "no filter" for that field.
+type RFQFilter struct {
+	Status     string
+	SupplierID uint // RFQs the supplier was invited to
+}
+
+// rfqSortColumns maps the accepted sort keys of RFQ listings to indexed columns
+var rfqSortColumns = map[string]string{
+	"id": "id",
+}
+
+// List retrieves one page of RFQs matching the filter
+func (r *RFQRepository) List(filter RFQFilter, params ListParams) ([]models.RFQ, PageInfo, error) {
+	query := config.DB.Model(&models.RFQ{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SupplierID != 0 {
+		query = query.Where("id IN (?)", config.DB.Model(&models.RFQSupplier{}).Select("rfq_id").Where("supplier_id = ?", filter.SupplierID))
+	}
+	query = query.Preload("Suppliers")
+
+	return paginate(query, params, rfqSortColumns, "id", func(rfq *models.RFQ) (interface{}, uint) {
+		return rfq.ID, rfq.ID
+	})
+}
+
+// CreateTransaction creates an open RFQ with its lines and invites the suppliers
+func (r *RFQRepository) CreateTransaction(rfq *models.RFQ, lines []models.RFQLine, supplierIDs []uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		rfq.Status = models.RFQStatusOpen
+		if err := tx.Omit("Lines", "Suppliers", "Quotes").Create(rfq).Error; err != nil {
+			return err
+		}
+
+		for i := range lines {
+			lines[i].RFQID = rfq.ID
+			if err := tx.Omit("Item").Create(&lines[i]).Error; err != nil {
+				return err
+			}
+		}
+		rfq.Lines = lines
+
+		invited, err := r.inviteWithTx(tx, rfq, supplierIDs, rfq.CreatedBy)
+		if err != nil {
+			return err
+		}
+		rfq.Suppliers = invited
+		return nil
+	})
+}
+
+// InviteTransaction invites more suppliers to an open RFQ; suppliers already invited are skipped
+func (r *RFQRepository) InviteTransaction(id uint, supplierIDs []uint, userID uint) ([]models.RFQSupplier, error) {
+	var invited []models.RFQSupplier
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		rfq, err := r.findOpenForUpdateWithTx(tx, id, "invite suppliers to")
+		if err != nil {
+			return err
+		}
+		invited, err = r.inviteWithTx(tx, rfq, supplierIDs, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invited, nil
+}
+
+// SaveQuoteTransaction enters a supplier's quote for an open RFQ, replacing its earlier quote
+// The supplier must have been invited and the RFQ must not be past its due date. Every quote line
+// must price a different line of the RFQ; lines the supplier does not quote for may be left out.
+func (r *RFQRepository) SaveQuoteTransaction(id uint, quote *models.RFQQuote, lines []models.RFQQuoteLine) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		rfq, err := r.findOpenForUpdateWithTx(tx, id, "quote for")
+		if err != nil {
+			return err
+		}
+		if rfq.DueDate != nil && utils.Today().After(*rfq.DueDate) {
+			return ErrRFQPastDue
+		}
+
+		var invited int64
+		if err := tx.Model(&models.RFQSupplier{}).
+			Where("rfq_id = ? AND supplier_id = ?", rfq.ID, quote.SupplierID).
+			Count(&invited).Error; err != nil {
+			return err
+		}
+		if invited == 0 {
+			return ErrSupplierNotInvited
+		}
+
+		var rfqLines []models.RFQLine
+		if err := tx.Where("rfq_id = ?", rfq.ID).Find(&rfqLines).Error; err != nil {
+			return err
+		}
+		known := make(map[uint]bool, len(rfqLines))
+		for _, line := range rfqLines {
+			known[line.ID] = true
+		}
+		seen := make(map[uint]bool, len(lines))
+		for _, line := range lines {
+			if !known[line.RFQLineID] {
+				return &RFQLineError{RFQLineID: line.RFQLineID, Message: "is not a line of this RFQ"}
+			}
+			if seen[line.RFQLineID] {
+				return &RFQLineError{RFQLineID: line.RFQLineID, Message: "is quoted more than once"}
+			}
+			seen[line.RFQLineID] = true
+		}
+
+		var supplier models.Supplier
+		if err := tx.Select("id", "currency").First(&supplier, quote.SupplierID).Error; err != nil {
+			return err
+		}
+		quote.Currency = supplier.Currency
+		if quote.Currency == "" {
+			quote.Currency = config.BaseCurrency
+		}
+
+		// Replace the supplier's earlier quote, keeping when it was first entered
+		var existing models.RFQQuote
+		result := tx.Where("rfq_id = ? AND supplier_id = ?", rfq.ID, quote.SupplierID).Limit(1).Find(&existing)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			if err := tx.Where("quote_id = ?", existing.ID).Delete(&models.RFQQuoteLine{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&existing).Error; err != nil {
+				return err
+			}
+			quote.CreatedAt = existing.CreatedAt
+		}
+
+		quote.RFQID = rfq.ID
+		if err := tx.Omit("Supplier", "Lines").Create(quote).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i].QuoteID = quote.ID
+			if err := tx.Create(&lines[i]).Error; err != nil {
+				return err
+			}
+		}
+		quote.Lines = lines
+		return nil
+	})
+}
+
+// Compare sets the RFQ's quotes side by side as of the day given
+// asOf is an instant; quote validity is checked against its local calendar day.
+func (r *RFQRepository) Compare(id uint, asOf time.Time) (*RFQComparison, error) {
+	rfq, err := r.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	day := utils.DateOf(asOf)
+	comparison := RFQComparison{
+		RFQID:    rfq.ID,
+		AsOf:     day,
+		Currency: config.BaseCurrency,
+		Quotes:   []RFQQuoteSummary{},
+		Lines:    make([]RFQLineComparison, 0, len(rfq.Lines)),
+	}
+
+	lineIndex := make(map[uint]int, len(rfq.Lines))
+	for i, line := range rfq.Lines {
+		lineIndex[line.ID] = i
+		comparison.Lines = append(comparison.Lines, RFQLineComparison{
+			RFQLineID: line.ID,
+			ItemID:    line.ItemID,
+			ItemName:  line.Item.Name,
+			Qty:       line.Qty,
+			Offers:    []RFQLineOffer{},
+		})
+	}
+
+	for _, quote := range rfq.Quotes {
+		summary := RFQQuoteSummary{
+			SupplierID:     quote.SupplierID,
+			SupplierName:   quote.Supplier.Name,
+			Currency:       quote.Currency,
+			ShippingAmount: quote.ShippingAmount,
+			GoodsTotal:     decimal.Zero,
+		}
+
+		var rate *decimal.Decimal
+		if value, err := r.rateRepo.RateOnWithTx(config.DB, quote.Currency, asOf); err == nil {
+			rate = &value
+		} else {
+			var rateErr *MissingExchangeRateError
+			if !errors.As(err, &rateErr) {
+				return nil, err
+			}
+		}
+		summary.ExchangeRate = rate
+
+		valid := 0
+		for _, quoteLine := range quote.Lines {
+			i, ok := lineIndex[quoteLine.RFQLineID]
+			if !ok {
+				continue
+			}
+			line := &comparison.Lines[i]
+
+			offer := RFQLineOffer{
+				SupplierID:   quote.SupplierID,
+				UnitPrice:    quoteLine.UnitPrice,
+				LeadTimeDays: quoteLine.LeadTimeDays,
+				ValidUntil:   quoteLine.ValidUntil,
+				Expired:      quoteLine.ValidUntil.Before(day),
+			}
+			if rate != nil {
+				basePrice := quoteLine.UnitPrice.Mul(*rate).Round(2)
+				offer.BaseUnitPrice = &basePrice
+			}
+			line.Offers = append(line.Offers, offer)
+
+			summary.QuotedLines++
+			summary.GoodsTotal = summary.GoodsTotal.Add(quoteLine.UnitPrice.Mul(decimal.NewFromInt(int64(line.Qty))))
+			if quoteLine.LeadTimeDays > summary.MaxLeadTimeDays {
+				summary.MaxLeadTimeDays = quoteLine.LeadTimeDays
+			}
+			if summary.ValidUntil == nil || quoteLine.ValidUntil.Before(*summary.ValidUntil) {
+				validUntil := quoteLine.ValidUntil
+				summary.ValidUntil = &validUntil
+			}
+			if !offer.Expired {
+				valid++
+			}
+		}
+
+		summary.GoodsTotal = summary.GoodsTotal.Round(2)
+		summary.Total = summary.GoodsTotal.Add(summary.ShippingAmount)
+		summary.Complete = valid == len(rfq.Lines)
+		if rate != nil {
+			landed := summary.Total.Mul(*rate).Round(2)
+			summary.LandedCost = &landed
+		}
+
+		switch {
+		case summary.QuotedLines < len(rfq.Lines):
+			summary.Note = "does not quote every line"
+		case !summary.Complete:
+			summary.Note = "has prices that are no longer valid"
+		case rate == nil:
+			summary.Note = "no exchange rate for " + quote.Currency
+		}
+		comparison.Quotes = append(comparison.Quotes, summary)
+	}
+
+	rankQuotes(comparison.Quotes)
+	for i := range comparison.Lines {
+		markBestOffer(comparison.Lines[i].Offers)
+	}
+	return &comparison, nil
+}
+
+// AwardTransaction awards an open RFQ to a supplier and creates a draft purchasing from its quote
+// The quote must price every line with a price still valid today (ErrQuoteIncomplete). The
+// purchasing orders the RFQ lines in the items' base units at the quoted prices, with the quoted
+// shipping and the items' default tax codes, is charged to the RFQ's department and is expected
+// to be delivered after the longest quoted lead time. afterCreateFn runs inside the transaction, as
+// in CreatePurchasingTransaction.
+func (r *RFQRepository) AwardTransaction(
+	id, supplierID, userID, warehouseID uint,
+	afterCreateFn func(tx *gorm.DB, purchasing *models.Purchasing) error,
+) (*models.RFQ, *models.Purchasing, error) {
+	var rfq *models.RFQ
+	var purchasing models.Purchasing
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rfq, err = r.findOpenForUpdateWithTx(tx, id, "award")
+		if err != nil {
+			return err
+		}
+
+		var quote models.RFQQuote
+		result := tx.Preload("Lines").Where("rfq_id = ? AND supplier_id = ?", rfq.ID, supplierID).Limit(1).Find(&quote)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrQuoteIncomplete
+		}
+
+		var rfqLines []models.RFQLine
+		if err := tx.Preload("Item").Where("rfq_id = ?", rfq.ID).Order("id ASC").Find(&rfqLines).Error; err != nil {
+			return err
+		}
+
+		today := utils.Today()
+		quoted := make(map[uint]models.RFQQuoteLine, len(quote.Lines))
+		for _, line := range quote.Lines {
+			if !line.ValidUntil.Before(today) {
+				quoted[line.RFQLineID] = line
+			}
+		}
+
+		// The supplier is expected to deliver within the longest lead time it quoted
+		leadTime := 0
+		details := make([]models.PurchasingDetail, 0, len(rfqLines))
+		for _, line := range rfqLines {
+			quoteLine, ok := quoted[line.ID]
+			if !ok {
+				return ErrQuoteIncomplete
+			}
+			if quoteLine.LeadTimeDays > leadTime {
+				leadTime = quoteLine.LeadTimeDays
+			}
+			details = append(details, models.PurchasingDetail{
+				ItemID:            line.ItemID,
+				Qty:               line.Qty,
+				UnitID:            line.Item.BaseUnitID,
+				UnitFactor:        1,
+				UnitPrice:         quoteLine.UnitPrice,
+				TaxCodeID:         line.Item.TaxCodeID,
+				WithholdingCodeID: line.Item.WithholdingCodeID,
+			})
+		}
+
+		purchasing = models.Purchasing{
+			Date:           time.Now(),
+			SupplierID:     supplierID,
+			UserID:         userID,
+			WarehouseID:    &warehouseID,
+			DepartmentID:   rfq.DepartmentID,
+			ShippingAmount: quote.ShippingAmount,
+			Status:         models.PurchasingStatusDraft,
+			Origin:         models.PurchasingOriginRFQ,
+		}
+		expected := today.AddDate(0, 0, leadTime)
+		purchasing.ExpectedDeliveryDate = &expected
+		if err := r.purchasingRepo.CreatePurchasingWithTx(tx, &purchasing, details, afterCreateFn); err != nil {
+			return err
+		}
+		purchasing.PurchasingDetails = details
+
+		now := time.Now()
+		rfq.Status = models.RFQStatusAwarded
+		rfq.AwardedSupplierID = &supplierID
+		rfq.PurchasingID = &purchasing.ID
+		rfq.AwardedBy = &userID
+		rfq.AwardedAt = &now
+		rfq.UpdatedAt = now
+		return tx.Model(rfq).Updates(map[string]interface{}{
+			"status":              rfq.Status,
+			"awarded_supplier_id": supplierID,
+			"purchasing_id":       purchasing.ID,
+			"awarded_by":          userID,
+			"awarded_at":          now,
+			"updated_at":          now,
+		}).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rfq, &purchasing, nil
+}
+
+// CancelTransaction cancels an open RFQ
+func (r *RFQRepository) CancelTransaction(id uint) (*models.RFQ, error) {
+	var rfq *models.RFQ
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rfq, err = r.findOpenForUpdateWithTx(tx, id, "cancel")
+		if err != nil {
+			return err
+		}
+
+		rfq.Status = models.RFQStatusCancelled
+		rfq.UpdatedAt = time.Now()
+		return tx.Model(rfq).Updates(map[string]interface{}{
+			"status":     rfq.Status,
+			"updated_at": rfq.UpdatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rfq, nil
+}
+
+// inviteWithTx invites the suppliers to the RFQ, skipping those already invited
+func (r *RFQRepository) inviteWithTx(tx *gorm.DB, rfq *models.RFQ, supplierIDs []uint, userID uint) ([]models.RFQSupplier, error) {
+	var existing []uint
+	if err := tx.Model(&models.RFQSupplier{}).Where("rfq_id = ?", rfq.ID).Pluck("supplier_id", &existing).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[uint]bool, len(existing)+len(supplierIDs))
+	for _, supplierID := range existing {
+		done[supplierID] = true
+	}
+
+	now := time.Now()
+	invited := []models.RFQSupplier{}
+	for _, supplierID := range supplierIDs {
+		if done[supplierID] {
+			continue
+		}
+		done[supplierID] = true
+
+		invitation := models.RFQSupplier{
+			RFQID:      rfq.ID,
+			SupplierID: supplierID,
+			InvitedBy:  userID,
+			InvitedAt:  now,
+		}
+		if err := tx.Omit("Supplier").Create(&invitation).Error; err != nil {
+			return nil, err
+		}
+		invited = append(invited, invitation)
+	}
+	return invited, nil
+}
+
+// findOpenForUpdateWithTx locks an RFQ, returning an RFQStatusError naming the action unless it is open
+func (r *RFQRepository) findOpenForUpdateWithTx(tx *gorm.DB, id uint, action string) (*models.RFQ, error) {
+	var rfq models.RFQ
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rfq, id).Error; err != nil {
+		return nil, err
+	}
+	if rfq.Status != models.RFQStatusOpen {
+		return nil, &RFQStatusError{CurrentStatus: rfq.Status, Action: action}
+	}
+	return &rfq, nil
+}
+
+// rankQuotes orders the quotes best first and numbers the ones that can be compared
+func rankQuotes(quotes []RFQQuoteSummary) {
+	sort.SliceStable(quotes, func(i, j int) bool {
+		a, b := quotes[i], quotes[j]
+		if (a.Note == "") != (b.Note == "") {
+			return a.Note == ""
+		}
+		if a.LandedCost != nil && b.LandedCost != nil && !a.LandedCost.Equal(*b.LandedCost) {
+			return a.LandedCost.LessThan(*b.LandedCost)
+		}
+		if a.MaxLeadTimeDays != b.MaxLeadTimeDays {
+			return a.MaxLeadTimeDays < b.MaxLeadTimeDays
+		}
+		return a.SupplierID < b.SupplierID
+	})
+
+	for i := range quotes {
+		if quotes[i].Note == "" {
+			quotes[i].Rank = i + 1
+		}
+	}
+}
+
+// markBestOffer marks the valid offer with the lowest base price, the shortest lead time breaking ties
+func markBestOffer(offers []RFQLineOffer) {
+	best := -1
+	for i, offer := range offers {
+		if offer.Expired || offer.BaseUnitPrice == nil {
+			continue
+		}
+		if best < 0 {
+			best = i
+			continue
+		}
+		current := offers[best]
+		if offer.BaseUnitPrice.LessThan(*current.BaseUnitPrice) ||
+			(offer.BaseUnitPrice.Equal(*current.BaseUnitPrice) && offer.LeadTimeDays < current.LeadTimeDays) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		offers[best].Best = true
+	}
+}
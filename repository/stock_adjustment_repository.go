@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockStatusError is returned when a stock adjustment or cycle count is not in a status that allows the action
+type StockStatusError struct {
+	Document      string
+	CurrentStatus string
+	Action        string
+}
+
+func (e *StockStatusError) Error() string {
+	return fmt.Sprintf("cannot %s a %s that is %s", e.Action, e.Document, e.CurrentStatus)
+}
+
+// StockAdjustmentRepository handles stock adjustments and their approval
+type StockAdjustmentRepository struct{}
+
+// NewStockAdjustmentRepository creates a new StockAdjustmentRepository instance
+func NewStockAdjustmentRepository() *StockAdjustmentRepository {
+	return &StockAdjustmentRepository{}
+}
+
+// FindByID finds a stock adjustment by ID with its item
+func (r *StockAdjustmentRepository) FindByID(id uint) (*models.StockAdjustment, error) {
+	var adjustment models.StockAdjustment
+	result := config.DB.Preload("Item").First(&adjustment, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &adjustment, nil
+}
+
+// StockAdjustmentFilter holds the optional criteria for listing stock adjustments
+// Zero values mean "no filter" for that field.
+type StockAdjustmentFilter struct {
+	Status       string
+	Reason       string
+	ItemID       uint
+	WarehouseID  uint
+	CycleCountID uint
+}
+
+// stockAdjustmentSortColumns maps the accepted sort keys of adjustment listings to indexed columns
+var stockAdjustmentSortColumns = map[string]string{
+	"id": "id",
+}
+
+// List retrieves one page of stock adjustments matching the filter
+func (r *StockAdjustmentRepository) List(filter StockAdjustmentFilter, params ListParams) ([]models.StockAdjustment, PageInfo, error) {
+	query := config.DB.Model(&models.StockAdjustment{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+	if filter.ItemID != 0 {
+		query = query.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.WarehouseID != 0 {
+		query = query.Where("warehouse_id = ?", filter.WarehouseID)
+	}
+	if filter.CycleCountID != 0 {
+		query = query.Where("cycle_count_id = ?", filter.CycleCountID)
+	}
+	query = query.Preload("Item")
+
+	return paginate(query, params, stockAdjustmentSortColumns, "id", func(adjustment *models.StockAdjustment) (interface{}, uint) {
+		return adjustment.ID, adjustment.ID
+	})
+}
+
+// Create records a pending stock adjustment
+func (r *StockAdjustmentRepository) Create(adjustment *models.StockAdjustment) error {
+	return r.CreateWithTx(config.DB, adjustment)
+}
+
+// CreateWithTx records a pending stock adjustment using the provided transaction
+func (r *StockAdjustmentRepository) CreateWithTx(tx *gorm.DB, adjustment *models.StockAdjustment) error {
+	adjustment.Status = models.StockAdjustmentStatusPending
+	if adjustment.CreatedAt.IsZero() {
+		adjustment.CreatedAt = time.Now()
+	}
+	return tx.Omit("Item").Create(adjustment).Error
+}
+
+// ApproveTransaction approves a pending adjustment and posts its quantity to stock
+// Adjustments of a cycle count are approved with the count, and nobody approves their own.
+func (r *StockAdjustmentRepository) ApproveTransaction(
+	id uint,
+	userID uint,
+	comment string,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) (*models.StockAdjustment, error) {
+	var adjustment *models.StockAdjustment
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		adjustment, err = r.findStandaloneForDecisionWithTx(tx, id, userID, "approve")
+		if err != nil {
+			return err
+		}
+		return r.approveWithTx(tx, adjustment, userID, comment, updateStockFn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// RejectTransaction rejects a pending adjustment without touching stock
+func (r *StockAdjustmentRepository) RejectTransaction(id uint, userID uint, comment string) (*models.StockAdjustment, error) {
+	var adjustment *models.StockAdjustment
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		adjustment, err = r.findStandaloneForDecisionWithTx(tx, id, userID, "reject")
+		if err != nil {
+			return err
+		}
+		return r.rejectWithTx(tx, adjustment, userID, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// findStandaloneForDecisionWithTx locks a pending adjustment that is not part of a cycle count
+func (r *StockAdjustmentRepository) findStandaloneForDecisionWithTx(tx *gorm.DB, id, userID uint, action string) (*models.StockAdjustment, error) {
+	var adjustment models.StockAdjustment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&adjustment, id).Error; err != nil {
+		return nil, err
+	}
+
+	if adjustment.Status != models.StockAdjustmentStatusPending {
+		return nil, &StockStatusError{Document: "stock adjustment", CurrentStatus: adjustment.Status, Action: action}
+	}
+	if adjustment.CycleCountID != nil {
+		return nil, &ApprovalDeniedError{Reason: fmt.Sprintf("adjustment belongs to cycle count %d and is decided with it", *adjustment.CycleCountID)}
+	}
+	if adjustment.RequestedBy == userID {
+		return nil, &ApprovalDeniedError{Reason: "you cannot decide on your own stock adjustment"}
+	}
+	return &adjustment, nil
+}
+
+// approveWithTx posts the adjustment to stock and marks it approved
+func (r *StockAdjustmentRepository) approveWithTx(
+	tx *gorm.DB,
+	adjustment *models.StockAdjustment,
+	userID uint,
+	comment string,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) error {
+	if err := updateStockFn(tx, &models.StockMovement{
+		ItemID:      adjustment.ItemID,
+		WarehouseID: adjustment.WarehouseID,
+		Delta:       adjustment.Quantity,
+		Reason:      models.StockMovementAdjustment,
+		SourceType:  models.StockSourceAdjustment,
+		SourceID:    &adjustment.ID,
+		UserID:      &userID,
+	}); err != nil {
+		return err
+	}
+	return r.decideWithTx(tx, adjustment, models.StockAdjustmentStatusApproved, userID, comment)
+}
+
+// rejectWithTx marks the adjustment rejected
+func (r *StockAdjustmentRepository) rejectWithTx(tx *gorm.DB, adjustment *models.StockAdjustment, userID uint, comment string) error {
+	return r.decideWithTx(tx, adjustment, models.StockAdjustmentStatusRejected, userID, comment)
+}
+
+// decideWithTx records the decision on the adjustment
+func (r *StockAdjustmentRepository) decideWithTx(tx *gorm.DB, adjustment *models.StockAdjustment, status string, userID uint, comment string) error {
+	now := time.Now()
+	adjustment.Status = status
+	adjustment.DecidedBy = &userID
+	adjustment.DecisionComment = comment
+	adjustment.DecidedAt = &now
+	return tx.Model(adjustment).Updates(map[string]interface{}{
+		"status":           status,
+		"decided_by":       userID,
+		"decision_comment": comment,
+		"decided_at":       now,
+	}).Error
+}
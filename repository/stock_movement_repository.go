@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+)
+
+// StockMovementRepository handles the append-only stock ledger
+// There are deliberately no update or delete operations.
+type StockMovementRepository struct{}
+
+// NewStockMovementRepository creates a new StockMovementRepository instance
+func NewStockMovementRepository() *StockMovementRepository {
+	return &StockMovementRepository{}
+}
+
+// CreateWithTx appends a movement to the ledger using the provided transaction
+func (r *StockMovementRepository) CreateWithTx(tx *gorm.DB, movement *models.StockMovement) error {
+	if movement.CreatedAt.IsZero() {
+		movement.CreatedAt = time.Now()
+	}
+	return tx.Create(movement).Error
+}
+
+// stockMovementSortColumns maps the accepted sort keys of movement listings to indexed columns
+var stockMovementSortColumns = map[string]string{
+	"id": "id",
+}
+
+// ListByItem retrieves one page of an item's movements, optionally only those with the given
+// reason or in the given warehouse
+func (r *StockMovementRepository) ListByItem(itemID uint, reason string, warehouseID uint, params ListParams) ([]models.StockMovement, PageInfo, error) {
+	query := config.DB.Model(&models.StockMovement{}).Where("item_id = ?", itemID)
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	query = query.Preload("User")
+
+	return paginate(query, params, stockMovementSortColumns, "id", func(movement *models.StockMovement) (interface{}, uint) {
+		return movement.ID, movement.ID
+	})
+}
+
+// StockDiscrepancy is an item whose stock does not match the sum of its ledger
+type StockDiscrepancy struct {
+	ItemID      uint   `json:"itemId"`
+	ItemName    string `json:"itemName"`
+	Stock       int    `json:"stock"`
+	LedgerStock int    `json:"ledgerStock"`
+	Difference  int    `json:"difference"`
+}
+
+// FindDiscrepancies returns every item whose stock differs from the sum of its movements
+func (r *StockMovementRepository) FindDiscrepancies() ([]StockDiscrepancy, error) {
+	var discrepancies []StockDiscrepancy
+	err := config.DB.Table("items").
+		Select("items.id AS item_id, items.name AS item_name, items.stock AS stock, COALESCE(SUM(stock_movements.delta), 0) AS ledger_stock").
+		Joins("LEFT JOIN stock_movements ON stock_movements.item_id = items.id").
+		Group("items.id, items.name, items.stock").
+		Having("items.stock <> COALESCE(SUM(stock_movements.delta), 0)").
+		Order("items.id ASC").
+		Scan(&discrepancies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range discrepancies {
+		discrepancies[i].Difference = discrepancies[i].Stock - discrepancies[i].LedgerStock
+	}
+	return discrepancies, nil
+}
+
+// WarehouseStockDiscrepancy is a warehouse balance that does not match the sum of the item's
+// ledger in that warehouse
+type WarehouseStockDiscrepancy struct {
+	ItemID         uint `json:"itemId"`
+	WarehouseID    uint `json:"warehouseId"`
+	Quantity       int  `json:"quantity"`
+	LedgerQuantity int  `json:"ledgerQuantity"`
+	Difference     int  `json:"difference"`
+}
+
+// FindWarehouseDiscrepancies returns every warehouse balance that differs from the sum of the
+// item's movements in that warehouse
+func (r *StockMovementRepository) FindWarehouseDiscrepancies() ([]WarehouseStockDiscrepancy, error) {
+	var discrepancies []WarehouseStockDiscrepancy
+	err := config.DB.Table("warehouse_stocks").
+		Select("warehouse_stocks.item_id, warehouse_stocks.warehouse_id, warehouse_stocks.quantity, COALESCE(SUM(stock_movements.delta), 0) AS ledger_quantity").
+		Joins("LEFT JOIN stock_movements ON stock_movements.item_id = warehouse_stocks.item_id AND stock_movements.warehouse_id = warehouse_stocks.warehouse_id").
+		Group("warehouse_stocks.item_id, warehouse_stocks.warehouse_id, warehouse_stocks.quantity").
+		Having("warehouse_stocks.quantity <> COALESCE(SUM(stock_movements.delta), 0)").
+		Order("warehouse_stocks.item_id ASC, warehouse_stocks.warehouse_id ASC").
+		Scan(&discrepancies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range discrepancies {
+		discrepancies[i].Difference = discrepancies[i].Quantity - discrepancies[i].LedgerQuantity
+	}
+	return discrepancies, nil
+}
+
+// BackfillOpeningBalances records an opening balance for items that have stock but no movements
+// Items created before the ledger existed get their current stock as the starting point,
+// so the ledger of every item sums to its stock from then on. Safe to run on every startup.
+func (r *StockMovementRepository) BackfillOpeningBalances() error {
+	var items []models.Item
+	err := config.DB.
+		Where("stock <> 0 AND NOT EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.item_id = items.id)").
+		Find(&items).Error
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, item := range items {
+		itemID := item.ID
+		movement := models.StockMovement{
+			ItemID:       item.ID,
+			Delta:        item.Stock,
+			Reason:       models.StockMovementOpeningBalance,
+			SourceType:   models.StockSourceItem,
+			SourceID:     &itemID,
+			BalanceAfter: item.Stock,
+			CreatedAt:    now,
+		}
+		if err := r.CreateWithTx(config.DB, &movement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockTransferRepository handles transfers of stock between warehouses
+// Shipping and receiving move stock through the ledger like any other stock change, one
+// movement per line and warehouse.
+type StockTransferRepository struct{}
+
+// NewStockTransferRepository creates a new StockTransferRepository instance
+func NewStockTransferRepository() *StockTransferRepository {
+	return &StockTransferRepository{}
+}
+
+// FindByID finds a transfer by ID with its warehouses, lines and their items
+func (r *StockTransferRepository) FindByID(id uint) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	result := config.DB.
+		Preload("FromWarehouse").
+		Preload("ToWarehouse").
+		Preload("Lines.Item").
+		First(&transfer, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &transfer, nil
+}
+
+// StockTransferFilter holds the optional criteria for listing transfers
+// Zero values mean "no filter" for that field.
+type StockTransferFilter struct {
+	Status      string
+	WarehouseID uint // either side of the transfer
+	ItemID      uint
+}
+
+// stockTransferSortColumns maps the accepted sort keys of transfer listings to indexed columns
+var stockTransferSortColumns = map[string]string{
+	"id": "id",
+}
+
+// List retrieves one page of transfers matching the filter
+func (r *StockTransferRepository) List(filter StockTransferFilter, params ListParams) ([]models.StockTransfer, PageInfo, error) {
+	query := config.DB.Model(&models.StockTransfer{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.WarehouseID != 0 {
+		query = query.Where("from_warehouse_id = ? OR to_warehouse_id = ?", filter.WarehouseID, filter.WarehouseID)
+	}
+	if filter.ItemID != 0 {
+		query = query.Where("id IN (?)", config.DB.Model(&models.StockTransferLine{}).Select("stock_transfer_id").Where("item_id = ?", filter.ItemID))
+	}
+	query = query.Preload("FromWarehouse").Preload("ToWarehouse")
+
+	return paginate(query, params, stockTransferSortColumns, "id", func(transfer *models.StockTransfer) (interface{}, uint) {
+		return transfer.ID, transfer.ID
+	})
+}
+
+// Create records a draft transfer with its lines
+func (r *StockTransferRepository) Create(transfer *models.StockTransfer) error {
+	transfer.Status = models.StockTransferStatusDraft
+	transfer.CreatedAt = time.Now()
+	return config.DB.Omit("FromWarehouse", "ToWarehouse").Create(transfer).Error
+}
+
+// ShipTransaction takes a draft transfer's quantities out of the source warehouse and puts it in transit
+// If any line is short of stock nothing is shipped.
+func (r *StockTransferRepository) ShipTransaction(
+	id uint,
+	userID uint,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) (*models.StockTransfer, error) {
+	return r.moveTransaction(id, models.StockTransferStatusDraft, "ship", func(tx *gorm.DB, transfer *models.StockTransfer, lines []models.StockTransferLine) error {
+		if err := r.postLinesWithTx(tx, transfer, lines, transfer.FromWarehouseID, -1, models.StockMovementTransferOut, userID, updateStockFn); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transfer.Status = models.StockTransferStatusInTransit
+		transfer.ShippedBy = &userID
+		transfer.ShippedAt = &now
+		return tx.Model(transfer).Updates(map[string]interface{}{
+			"status":     transfer.Status,
+			"shipped_by": userID,
+			"shipped_at": now,
+		}).Error
+	})
+}
+
+// ReceiveTransaction adds the quantities of a transfer in transit to the destination warehouse
+func (r *StockTransferRepository) ReceiveTransaction(
+	id uint,
+	userID uint,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) (*models.StockTransfer, error) {
+	return r.moveTransaction(id, models.StockTransferStatusInTransit, "receive", func(tx *gorm.DB, transfer *models.StockTransfer, lines []models.StockTransferLine) error {
+		if err := r.postLinesWithTx(tx, transfer, lines, transfer.ToWarehouseID, 1, models.StockMovementTransferIn, userID, updateStockFn); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		transfer.Status = models.StockTransferStatusReceived
+		transfer.ReceivedBy = &userID
+		transfer.ReceivedAt = &now
+		return tx.Model(transfer).Updates(map[string]interface{}{
+			"status":      transfer.Status,
+			"received_by": userID,
+			"received_at": now,
+		}).Error
+	})
+}
+
+// CancelTransaction cancels a draft transfer, or one in transit by returning its goods to the source warehouse
+func (r *StockTransferRepository) CancelTransaction(
+	id uint,
+	userID uint,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) (*models.StockTransfer, error) {
+	var transfer *models.StockTransfer
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = r.findForUpdateWithTx(tx, id)
+		if err != nil {
+			return err
+		}
+
+		switch transfer.Status {
+		case models.StockTransferStatusDraft:
+		case models.StockTransferStatusInTransit:
+			var lines []models.StockTransferLine
+			if err := tx.Where("stock_transfer_id = ?", id).Find(&lines).Error; err != nil {
+				return err
+			}
+			if err := r.postLinesWithTx(tx, transfer, lines, transfer.FromWarehouseID, 1, models.StockMovementTransferReturn, userID, updateStockFn); err != nil {
+				return err
+			}
+		default:
+			return &StockStatusError{Document: "stock transfer", CurrentStatus: transfer.Status, Action: "cancel"}
+		}
+
+		now := time.Now()
+		transfer.Status = models.StockTransferStatusCancelled
+		transfer.CancelledBy = &userID
+		transfer.CancelledAt = &now
+		return tx.Model(transfer).Updates(map[string]interface{}{
+			"status":       transfer.Status,
+			"cancelled_by": userID,
+			"cancelled_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// moveTransaction locks a transfer in the expected status with its lines and runs the step
+func (r *StockTransferRepository) moveTransaction(
+	id uint,
+	status string,
+	action string,
+	step func(tx *gorm.DB, transfer *models.StockTransfer, lines []models.StockTransferLine) error,
+) (*models.StockTransfer, error) {
+	var transfer *models.StockTransfer
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = r.findForUpdateWithTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != status {
+			return &StockStatusError{Document: "stock transfer", CurrentStatus: transfer.Status, Action: action}
+		}
+
+		var lines []models.StockTransferLine
+		if err := tx.Where("stock_transfer_id = ?", id).Find(&lines).Error; err != nil {
+			return err
+		}
+		return step(tx, transfer, lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// postLinesWithTx moves every line's quantity into (sign 1) or out of (sign -1) a warehouse
+func (r *StockTransferRepository) postLinesWithTx(
+	tx *gorm.DB,
+	transfer *models.StockTransfer,
+	lines []models.StockTransferLine,
+	warehouseID uint,
+	sign int,
+	reason string,
+	userID uint,
+	updateStockFn func(tx *gorm.DB, movement *models.StockMovement) error,
+) error {
+	for _, line := range lines {
+		if err := updateStockFn(tx, &models.StockMovement{
+			ItemID:      line.ItemID,
+			WarehouseID: &warehouseID,
+			Delta:       sign * line.Quantity,
+			Reason:      reason,
+			SourceType:  models.StockSourceTransfer,
+			SourceID:    &transfer.ID,
+			UserID:      &userID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findForUpdateWithTx locks the transfer row for the rest of the transaction
+func (r *StockTransferRepository) findForUpdateWithTx(tx *gorm.DB, id uint) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transfer, id).Error; err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
@@ -0,0 +1,555 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Supplier invoice errors
+var (
+	ErrPurchasingNotInvoiceable = errors.New("only ordered, received or closed purchasings can be invoiced")
+	ErrPurchasingInvoiced       = errors.New("purchasing has pending or approved supplier invoices; reject the pending ones first")
+	ErrInvoiceNumberTaken       = errors.New("invoice number already recorded for this supplier")
+	ErrInvoiceBlocked           = errors.New("invoice is blocked by its three-way match and cannot be approved")
+	ErrInvoiceVariance          = errors.New("invoice has price variances; approve with acceptVariance and a comment")
+	ErrInvoicePaid              = errors.New("invoice is already paid in full")
+	ErrPaymentExceedsBalance    = errors.New("payment is more than the balance of the invoice")
+	ErrEarlyDiscountUnavailable = errors.New("the invoice has no early payment discount or its discount period has passed")
+	ErrEarlyDiscountAmount      = errors.New("a payment taking the early payment discount must settle the balance less the discount")
+)
+
+// invoiceablePurchasingStatuses are the statuses of purchasings suppliers may bill for
+var invoiceablePurchasingStatuses = []string{
+	models.PurchasingStatusOrdered,
+	models.PurchasingStatusPartiallyReceived,
+	models.PurchasingStatusReceived,
+	models.PurchasingStatusClosed,
+}
+
+// InvoiceStatusError is returned when a supplier invoice is not in a status that allows the action
+// PurchasingStatus is set when it is the invoice's purchasing that no longer allows it.
+type InvoiceStatusError struct {
+	CurrentStatus    string
+	PurchasingStatus string
+	Action           string
+}
+
+func (e *InvoiceStatusError) Error() string {
+	if e.PurchasingStatus != "" {
+		return fmt.Sprintf("cannot %s an invoice of a purchasing that is %s", e.Action, e.PurchasingStatus)
+	}
+	return fmt.Sprintf("cannot %s an invoice that is %s", e.Action, e.CurrentStatus)
+}
+
+// InvoiceLineError is returned when an invoice line cannot be accepted as submitted
+type InvoiceLineError struct {
+	PurchasingDetailID uint
+	Message            string
+}
+
+func (e *InvoiceLineError) Error() string {
+	return fmt.Sprintf("purchasing detail %d: %s", e.PurchasingDetailID, e.Message)
+}
+
+// SupplierInvoiceRepository handles supplier invoices and their three-way match
+type SupplierInvoiceRepository struct {
+	purchasingRepo *PurchasingRepository
+	budgetRepo     *BudgetRepository
+}
+
+// NewSupplierInvoiceRepository creates a new SupplierInvoiceRepository instance
+func NewSupplierInvoiceRepository() *SupplierInvoiceRepository {
+	return &SupplierInvoiceRepository{
+		purchasingRepo: NewPurchasingRepository(),
+		budgetRepo:     NewBudgetRepository(),
+	}
+}
+
+// FindByID finds an invoice by ID with its supplier, lines and payments
+func (r *SupplierInvoiceRepository) FindByID(id uint) (*models.SupplierInvoice, error) {
+	var invoice models.SupplierInvoice
+	result := config.DB.Preload("Supplier").Preload("Lines").Preload("Lines.Item").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("payment_date ASC, id ASC") }).
+		First(&invoice, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &invoice, nil
+}
+
+// SupplierInvoiceFilter holds the optional criteria for listing supplier invoices
+// Zero values mean "no filter" for that field.
+type SupplierInvoiceFilter struct {
+	SupplierID    uint
+	PurchasingID  uint
+	Status        string
+	MatchStatus   string
+	PaymentStatus string
+}
+
+// supplierInvoiceSortColumns maps the accepted sort keys of invoice listings to indexed columns
+var supplierInvoiceSortColumns = map[string]string{
+	"id":          "id",
+	"invoiceDate": "invoice_date",
+}
+
+// List retrieves one page of invoices matching the filter
+func (r *SupplierInvoiceRepository) List(filter SupplierInvoiceFilter, params ListParams) ([]models.SupplierInvoice, PageInfo, error) {
+	query := config.DB.Model(&models.SupplierInvoice{})
+	if filter.SupplierID != 0 {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if filter.PurchasingID != 0 {
+		query = query.Where("purchasing_id = ?", filter.PurchasingID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.MatchStatus != "" {
+		query = query.Where("match_status = ?", filter.MatchStatus)
+	}
+	if filter.PaymentStatus != "" {
+		query = query.Where("payment_status = ?", filter.PaymentStatus)
+	}
+	query = query.Preload("Supplier")
+
+	return paginate(query, params, supplierInvoiceSortColumns, "id", func(invoice *models.SupplierInvoice) (interface{}, uint) {
+		if params.SortBy == "invoiceDate" {
+			// Formatted the way MySQL stores datetime values, in the connection's time zone
+			return invoice.InvoiceDate.Format("2006-01-02 15:04:05"), invoice.ID
+		}
+		return invoice.ID, invoice.ID
+	})
+}
+
+// GetByPurchasing retrieves all invoices billed against a purchasing
+func (r *SupplierInvoiceRepository) GetByPurchasing(purchasingID uint) ([]models.SupplierInvoice, error) {
+	invoices := []models.SupplierInvoice{}
+	result := config.DB.Preload("Lines").Preload("Lines.Item").
+		Where("purchasing_id = ?", purchasingID).
+		Order("invoice_date ASC, id ASC").
+		Find(&invoices)
+	return invoices, result.Error
+}
+
+// CreateTransaction records an invoice against its purchasing and matches it
+// The purchasing row is locked so invoices of the same purchasing are matched one at a time.
+// Every line must bill a different line of the purchasing; otherwise an InvoiceLineError is
+// returned and nothing is written. The invoice takes the purchasing's supplier and currency,
+// and its due dates from the supplier's payment terms. ErrInvoiceNumberTaken is returned when the
+// supplier already sent an invoice with the same number.
+func (r *SupplierInvoiceRepository) CreateTransaction(invoice *models.SupplierInvoice, lines []models.SupplierInvoiceLine) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		purchasing, err := r.purchasingRepo.FindForUpdateWithTx(tx, invoice.PurchasingID)
+		if err != nil {
+			return err
+		}
+		if !isInvoiceable(purchasing) {
+			return ErrPurchasingNotInvoiceable
+		}
+
+		invoice.SupplierID = purchasing.SupplierID
+		invoice.Currency = purchasing.Currency
+
+		var sameNumber int64
+		if err := tx.Model(&models.SupplierInvoice{}).
+			Where("supplier_id = ? AND invoice_number = ?", invoice.SupplierID, invoice.InvoiceNumber).
+			Count(&sameNumber).Error; err != nil {
+			return err
+		}
+		if sameNumber > 0 {
+			return ErrInvoiceNumberTaken
+		}
+
+		invoice.Status = models.SupplierInvoiceStatusPending
+		invoice.SubTotal = decimal.Zero
+		seen := make(map[uint]bool, len(lines))
+		for i := range lines {
+			if seen[lines[i].PurchasingDetailID] {
+				return &InvoiceLineError{PurchasingDetailID: lines[i].PurchasingDetailID, Message: "is billed more than once"}
+			}
+			seen[lines[i].PurchasingDetailID] = true
+
+			lines[i].Amount = lines[i].UnitPrice.Mul(decimal.NewFromInt(int64(lines[i].Qty))).Round(2)
+			invoice.SubTotal = invoice.SubTotal.Add(lines[i].Amount)
+		}
+		invoice.Total = invoice.SubTotal.Add(invoice.TaxAmount)
+
+		var supplier models.Supplier
+		if err := tx.First(&supplier, purchasing.SupplierID).Error; err != nil {
+			return err
+		}
+		applyPaymentTerms(invoice, &supplier)
+
+		if err := r.matchLinesWithTx(tx, invoice, lines); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		invoice.CreatedAt = now
+		invoice.UpdatedAt = now
+		if err := tx.Omit("Supplier", "Purchasing", "Lines").Create(invoice).Error; err != nil {
+			// Another invoice with the number was recorded since the check
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrInvoiceNumberTaken
+			}
+			return err
+		}
+		for i := range lines {
+			lines[i].InvoiceID = invoice.ID
+			if err := tx.Omit("PurchasingDetail", "Item").Create(&lines[i]).Error; err != nil {
+				return err
+			}
+		}
+		invoice.Lines = lines
+		return nil
+	})
+}
+
+// MatchTransaction matches a pending invoice again, e.g. after more goods were received
+func (r *SupplierInvoiceRepository) MatchTransaction(id uint) (*models.SupplierInvoice, error) {
+	var invoice *models.SupplierInvoice
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invoice, err = r.findPendingForUpdateWithTx(tx, id, "match")
+		if err != nil {
+			return err
+		}
+		return r.rematchWithTx(tx, invoice)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// ApproveTransaction matches a pending invoice once more and approves it for payment
+// - A blocked invoice is never approved (ErrInvoiceBlocked)
+// - An invoice with price variances is only approved when the approver accepts them with a comment (ErrInvoiceVariance)
+// - Nobody approves an invoice they entered
+func (r *SupplierInvoiceRepository) ApproveTransaction(id, userID uint, comment string, acceptVariance bool) (*models.SupplierInvoice, error) {
+	var invoice *models.SupplierInvoice
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invoice, err = r.findPendingForUpdateWithTx(tx, id, "approve")
+		if err != nil {
+			return err
+		}
+		if invoice.CreatedBy == userID {
+			return &ApprovalDeniedError{Reason: "you cannot approve an invoice you entered"}
+		}
+
+		if err := r.rematchWithTx(tx, invoice); err != nil {
+			return err
+		}
+		switch invoice.MatchStatus {
+		case models.InvoiceMatchBlocked:
+			return ErrInvoiceBlocked
+		case models.InvoiceMatchVariance:
+			if !acceptVariance || comment == "" {
+				return ErrInvoiceVariance
+			}
+		}
+
+		// The approved bill is what the purchasing actually costs its department's budget
+		baseTotal := invoice.Total.Mul(invoice.Purchasing.ExchangeRate).Round(2)
+		if err := r.budgetRepo.ConsumeWithTx(tx, &invoice.Purchasing, baseTotal); err != nil {
+			return err
+		}
+
+		return r.decideWithTx(tx, invoice, models.SupplierInvoiceStatusApproved, userID, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// RejectTransaction rejects a pending invoice; its quantities no longer count as invoiced
+func (r *SupplierInvoiceRepository) RejectTransaction(id, userID uint, comment string) (*models.SupplierInvoice, error) {
+	var invoice *models.SupplierInvoice
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invoice, err = r.findPendingForUpdateWithTx(tx, id, "reject")
+		if err != nil {
+			return err
+		}
+		return r.decideWithTx(tx, invoice, models.SupplierInvoiceStatusRejected, userID, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// RecordPaymentTransaction records a full or partial payment of an approved invoice
+// - The payment may not be more than the invoice's balance (ErrPaymentExceedsBalance)
+// - With takeDiscount the early payment discount is deducted, only within the discount period (ErrEarlyDiscountUnavailable)
+// - A payment taking the discount must settle the rest of the balance (ErrEarlyDiscountAmount)
+// The invoice becomes partially_paid, or paid once nothing is owed.
+func (r *SupplierInvoiceRepository) RecordPaymentTransaction(payment *models.SupplierPayment, takeDiscount bool) (*models.SupplierInvoice, error) {
+	var invoice models.SupplierInvoice
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, payment.InvoiceID).Error; err != nil {
+			return err
+		}
+		if invoice.Status != models.SupplierInvoiceStatusApproved {
+			return &InvoiceStatusError{CurrentStatus: invoice.Status, Action: "pay"}
+		}
+		if invoice.PaymentStatus == models.InvoicePaymentPaid {
+			return ErrInvoicePaid
+		}
+
+		balance := invoice.Balance()
+		payment.DiscountTaken = decimal.Zero
+		if takeDiscount {
+			if invoice.DiscountDueDate == nil || !invoice.EarlyPaymentDiscount.IsPositive() || payment.PaymentDate.After(*invoice.DiscountDueDate) {
+				return ErrEarlyDiscountUnavailable
+			}
+			payment.DiscountTaken = decimal.Min(invoice.EarlyPaymentDiscount, balance)
+			if !payment.Amount.Equal(balance.Sub(payment.DiscountTaken)) {
+				return ErrEarlyDiscountAmount
+			}
+		} else if payment.Amount.GreaterThan(balance) {
+			return ErrPaymentExceedsBalance
+		}
+
+		payment.SupplierID = invoice.SupplierID
+		payment.CreatedAt = time.Now()
+		if err := tx.Omit("Supplier").Create(payment).Error; err != nil {
+			return err
+		}
+
+		invoice.PaidAmount = invoice.PaidAmount.Add(payment.Amount)
+		invoice.DiscountTaken = invoice.DiscountTaken.Add(payment.DiscountTaken)
+		invoice.PaymentStatus = models.InvoicePaymentPartiallyPaid
+		if !invoice.Balance().IsPositive() {
+			invoice.PaymentStatus = models.InvoicePaymentPaid
+			invoice.PaidAt = &payment.PaymentDate
+		}
+		invoice.UpdatedAt = payment.CreatedAt
+		return tx.Model(&invoice).Updates(map[string]interface{}{
+			"paid_amount":    invoice.PaidAmount,
+			"discount_taken": invoice.DiscountTaken,
+			"payment_status": invoice.PaymentStatus,
+			"paid_at":        invoice.PaidAt,
+			"updated_at":     invoice.UpdatedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetPayments retrieves the payments of an invoice in the order they were made
+func (r *SupplierInvoiceRepository) GetPayments(invoiceID uint) ([]models.SupplierPayment, error) {
+	payments := []models.SupplierPayment{}
+	result := config.DB.Where("invoice_id = ?", invoiceID).Order("payment_date ASC, id ASC").Find(&payments)
+	return payments, result.Error
+}
+
+// BackfillPaymentTerms gives invoices entered before payment terms existed their supplier's terms
+// Their due date is the invoice date plus the supplier's due days; no early payment discount is
+// offered on them. Safe to run on every startup.
+func (r *SupplierInvoiceRepository) BackfillPaymentTerms() error {
+	return config.DB.Exec(
+		"UPDATE supplier_invoices JOIN suppliers ON suppliers.id = supplier_invoices.supplier_id " +
+			"SET supplier_invoices.payment_terms = suppliers.payment_terms, " +
+			"supplier_invoices.due_date = DATE_ADD(supplier_invoices.invoice_date, INTERVAL suppliers.payment_due_days DAY) " +
+			"WHERE supplier_invoices.due_date IS NULL",
+	).Error
+}
+
+// findPendingForUpdateWithTx locks a pending invoice, its purchasing and loads its lines
+// Only rejecting is allowed once the purchasing can no longer be invoiced, e.g. after it was
+// cancelled and its received goods taken back out of stock.
+func (r *SupplierInvoiceRepository) findPendingForUpdateWithTx(tx *gorm.DB, id uint, action string) (*models.SupplierInvoice, error) {
+	var invoice models.SupplierInvoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, id).Error; err != nil {
+		return nil, err
+	}
+	if invoice.Status != models.SupplierInvoiceStatusPending {
+		return nil, &InvoiceStatusError{CurrentStatus: invoice.Status, Action: action}
+	}
+
+	purchasing, err := r.purchasingRepo.FindForUpdateWithTx(tx, invoice.PurchasingID)
+	if err != nil {
+		return nil, err
+	}
+	if action != "reject" && !isInvoiceable(purchasing) {
+		return nil, &InvoiceStatusError{CurrentStatus: invoice.Status, PurchasingStatus: purchasing.Status, Action: action}
+	}
+	invoice.Purchasing = *purchasing
+	if err := tx.Preload("Item").Where("invoice_id = ?", invoice.ID).Order("id ASC").Find(&invoice.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// rematchWithTx matches the invoice's lines again and stores the results
+func (r *SupplierInvoiceRepository) rematchWithTx(tx *gorm.DB, invoice *models.SupplierInvoice) error {
+	if err := r.matchLinesWithTx(tx, invoice, invoice.Lines); err != nil {
+		return err
+	}
+
+	for i := range invoice.Lines {
+		line := &invoice.Lines[i]
+		if err := tx.Model(line).Updates(map[string]interface{}{
+			"po_unit_price":  line.POUnitPrice,
+			"received_qty":   line.ReceivedQty,
+			"invoiced_qty":   line.InvoicedQty,
+			"price_variance": line.PriceVariance,
+			"match_status":   line.MatchStatus,
+			"match_note":     line.MatchNote,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	invoice.UpdatedAt = invoice.MatchedAt
+	return tx.Model(invoice).Updates(map[string]interface{}{
+		"match_status": invoice.MatchStatus,
+		"matched_at":   invoice.MatchedAt,
+		"updated_at":   invoice.UpdatedAt,
+	}).Error
+}
+
+// matchLinesWithTx compares each invoice line with its purchasing line and what was received
+// - Blocked when it bills, together with earlier invoices, more than was received plus the quantity tolerance
+// - Variance when its unit price exceeds the purchasing's net unit price by more than the price tolerance
+// - Matched otherwise; billing below the purchasing price is accepted
+// Goods that did not arrive are never paid for. Earlier invoices are the approved ones and the
+// pending ones entered before this invoice.
+// The invoice's match status becomes the worst status of its lines. Nothing is written.
+func (r *SupplierInvoiceRepository) matchLinesWithTx(tx *gorm.DB, invoice *models.SupplierInvoice, lines []models.SupplierInvoiceLine) error {
+	var details []models.PurchasingDetail
+	if err := tx.Where("purchasing_id = ?", invoice.PurchasingID).Find(&details).Error; err != nil {
+		return err
+	}
+	detailsByID := make(map[uint]*models.PurchasingDetail, len(details))
+	for i := range details {
+		detailsByID[details[i].ID] = &details[i]
+	}
+
+	var rows []struct {
+		PurchasingDetailID uint
+		Qty                int
+	}
+	query := tx.Model(&models.SupplierInvoiceLine{}).
+		Select("supplier_invoice_lines.purchasing_detail_id, SUM(supplier_invoice_lines.qty) AS qty").
+		Joins("JOIN supplier_invoices ON supplier_invoices.id = supplier_invoice_lines.invoice_id").
+		Where("supplier_invoices.purchasing_id = ?", invoice.PurchasingID)
+	if invoice.ID == 0 {
+		query = query.Where("supplier_invoices.status IN ?", []string{models.SupplierInvoiceStatusPending, models.SupplierInvoiceStatusApproved})
+	} else {
+		query = query.Where("supplier_invoices.status = ? OR (supplier_invoices.status = ? AND supplier_invoices.id < ?)",
+			models.SupplierInvoiceStatusApproved, models.SupplierInvoiceStatusPending, invoice.ID)
+	}
+	if err := query.Group("supplier_invoice_lines.purchasing_detail_id").Scan(&rows).Error; err != nil {
+		return err
+	}
+	invoicedByDetail := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		invoicedByDetail[row.PurchasingDetailID] = row.Qty
+	}
+
+	priceFactor := decimal.NewFromFloat(1 + config.InvoicePriceTolerance/100)
+	invoice.MatchStatus = models.InvoiceMatchMatched
+	for i := range lines {
+		line := &lines[i]
+		detail, ok := detailsByID[line.PurchasingDetailID]
+		if !ok {
+			return &InvoiceLineError{
+				PurchasingDetailID: line.PurchasingDetailID,
+				Message:            fmt.Sprintf("does not belong to purchasing %d", invoice.PurchasingID),
+			}
+		}
+
+		line.ItemID = detail.ItemID
+		line.ReceivedQty = detail.ReceivedQty
+		line.InvoicedQty = invoicedByDetail[detail.ID]
+		line.POUnitPrice = decimal.Zero
+		if detail.Qty > 0 {
+			line.POUnitPrice = detail.NetAmount.Div(decimal.NewFromInt(int64(detail.Qty))).Round(4)
+		}
+		line.PriceVariance = line.UnitPrice.Sub(line.POUnitPrice).Mul(decimal.NewFromInt(int64(line.Qty))).Round(2)
+
+		billed := line.InvoicedQty + line.Qty
+		switch {
+		case billed > maxInvoiceableQty(line.ReceivedQty):
+			line.MatchStatus = models.InvoiceMatchBlocked
+			line.MatchNote = fmt.Sprintf("billing %d brings the total invoiced to %d, but only %d received", line.Qty, billed, line.ReceivedQty)
+		case line.UnitPrice.GreaterThan(line.POUnitPrice.Mul(priceFactor)):
+			line.MatchStatus = models.InvoiceMatchVariance
+			line.MatchNote = fmt.Sprintf("unit price %s is above the purchasing price %s", line.UnitPrice.StringFixed(2), line.POUnitPrice.StringFixed(2))
+		default:
+			line.MatchStatus = models.InvoiceMatchMatched
+			line.MatchNote = ""
+		}
+		invoice.MatchStatus = models.WorseInvoiceMatch(invoice.MatchStatus, line.MatchStatus)
+	}
+	invoice.MatchedAt = time.Now()
+	return nil
+}
+
+// decideWithTx records the decision on the invoice
+func (r *SupplierInvoiceRepository) decideWithTx(tx *gorm.DB, invoice *models.SupplierInvoice, status string, userID uint, comment string) error {
+	now := time.Now()
+	invoice.Status = status
+	invoice.DecidedBy = &userID
+	invoice.DecisionComment = comment
+	invoice.DecidedAt = &now
+	invoice.UpdatedAt = now
+	return tx.Model(invoice).Updates(map[string]interface{}{
+		"status":           status,
+		"decided_by":       userID,
+		"decision_comment": comment,
+		"decided_at":       now,
+		"updated_at":       now,
+	}).Error
+}
+
+// applyPaymentTerms sets the invoice's due dates and early payment discount from the supplier's terms
+func applyPaymentTerms(invoice *models.SupplierInvoice, supplier *models.Supplier) {
+	dueDate := invoice.InvoiceDate.AddDate(0, 0, supplier.PaymentDueDays)
+	invoice.PaymentTerms = supplier.PaymentTerms
+	invoice.DueDate = &dueDate
+	invoice.DiscountDueDate = nil
+	invoice.EarlyPaymentDiscount = decimal.Zero
+	invoice.PaymentStatus = models.InvoicePaymentUnpaid
+
+	if supplier.EarlyPaymentDays > 0 && supplier.EarlyPaymentDiscount.IsPositive() {
+		discountDueDate := invoice.InvoiceDate.AddDate(0, 0, supplier.EarlyPaymentDays)
+		invoice.DiscountDueDate = &discountDueDate
+		invoice.EarlyPaymentDiscount = invoice.Total.Mul(supplier.EarlyPaymentDiscount).Div(hundred).Round(2)
+	}
+}
+
+// isInvoiceable reports whether suppliers may bill for the purchasing in its current status
+func isInvoiceable(purchasing *models.Purchasing) bool {
+	for _, status := range invoiceablePurchasingStatuses {
+		if purchasing.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// maxInvoiceableQty is the highest total quantity that may be invoiced for a received quantity
+func maxInvoiceableQty(received int) int {
+	factor := decimal.NewFromFloat(1 + config.InvoiceQtyTolerance/100)
+	return int(decimal.NewFromInt(int64(received)).Mul(factor).Floor().IntPart())
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/utils"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Price list errors
+var (
+	ErrSupplierPriceOverlap  = errors.New("the validity overlaps another price for the same item and minimum quantity")
+	ErrSupplierPriceInEffect = errors.New("the price is already in effect and is part of the price history")
+)
+
+// dateLayout is how calendar dates are written and compared (see utils.DateLayout)
+const dateLayout = utils.DateLayout
+
+// SupplierPriceRepository handles supplier price lists
+type SupplierPriceRepository struct{}
+
+// NewSupplierPriceRepository creates a new SupplierPriceRepository instance
+func NewSupplierPriceRepository() *SupplierPriceRepository {
+	return &SupplierPriceRepository{}
+}
+
+// FindByID finds a price list entry by ID
+func (r *SupplierPriceRepository) FindByID(id uint) (*models.SupplierPrice, error) {
+	var price models.SupplierPrice
+	result := config.DB.Preload("Item").First(&price, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &price, nil
+}
+
+// SupplierPriceFilter holds the optional criteria for listing price list entries
+// Zero values mean "no filter" for that field.
+type SupplierPriceFilter struct {
+	SupplierID uint
+	ItemID     uint
+	ActiveOn   *time.Time // entries valid on this date
+}
+
+// supplierPriceSortColumns maps the accepted sort keys of price listings to indexed columns
+var supplierPriceSortColumns = map[string]string{
+	"id":        "id",
+	"validFrom": "valid_from",
+}
+
+// List retrieves one page of price list entries matching the filter with their supplier and item
+func (r *SupplierPriceRepository) List(filter SupplierPriceFilter, params ListParams) ([]models.SupplierPrice, PageInfo, error) {
+	query := config.DB.Model(&models.SupplierPrice{})
+	if filter.SupplierID != 0 {
+		query = query.Where("supplier_id = ?", filter.SupplierID)
+	}
+	if filter.ItemID != 0 {
+		query = query.Where("item_id = ?", filter.ItemID)
+	}
+	if filter.ActiveOn != nil {
+		day := filter.ActiveOn.Format(dateLayout)
+		query = query.Where("valid_from <= ? AND (valid_to IS NULL OR valid_to >= ?)", day, day)
+	}
+	query = query.Preload("Supplier").Preload("Item")
+
+	return paginate(query, params, supplierPriceSortColumns, "validFrom", func(price *models.SupplierPrice) (interface{}, uint) {
+		if params.SortBy == "id" {
+			return price.ID, price.ID
+		}
+		return price.ValidFrom.Format(dateLayout), price.ID
+	})
+}
+
+// Create adds a price list entry
+// An open-ended entry ends the open-ended entry it follows for the same supplier, item and
+// minimum quantity the day before it starts; any other overlap is refused with
+// ErrSupplierPriceOverlap.
+func (r *SupplierPriceRepository) Create(price *models.SupplierPrice) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var existing []models.SupplierPrice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("supplier_id = ? AND item_id = ? AND min_qty = ?", price.SupplierID, price.ItemID, price.MinQty).
+			Find(&existing).Error
+		if err != nil {
+			return err
+		}
+
+		from := price.ValidFrom.Format(dateLayout)
+		for i := range existing {
+			e := &existing[i]
+			if price.ValidTo == nil && e.ValidTo == nil && e.ValidFrom.Format(dateLayout) < from {
+				dayBefore := price.ValidFrom.AddDate(0, 0, -1)
+				if err := tx.Model(e).Update("valid_to", dayBefore.Format(dateLayout)).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if datesOverlap(e.ValidFrom, e.ValidTo, price.ValidFrom, price.ValidTo) {
+				return ErrSupplierPriceOverlap
+			}
+		}
+
+		price.CreatedAt = time.Now()
+		return tx.Omit("Supplier", "Item").Create(price).Error
+	})
+}
+
+// Delete removes a price list entry that has not taken effect yet
+// If it had ended the entry before it, that entry becomes open-ended again.
+func (r *SupplierPriceRepository) Delete(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var price models.SupplierPrice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&price, id).Error; err != nil {
+			return err
+		}
+		if price.ValidFrom.Format(dateLayout) <= utils.Today().Format(dateLayout) {
+			return ErrSupplierPriceInEffect
+		}
+
+		if price.ValidTo == nil {
+			dayBefore := price.ValidFrom.AddDate(0, 0, -1).Format(dateLayout)
+			err := tx.Model(&models.SupplierPrice{}).
+				Where("supplier_id = ? AND item_id = ? AND min_qty = ? AND valid_to = ?", price.SupplierID, price.ItemID, price.MinQty, dayBefore).
+				Update("valid_to", nil).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&price).Error
+	})
+}
+
+// ResolveUnitPrice returns the unit price of qty units of the item from the supplier on date
+// See ResolveUnitPriceWithTx.
+func (r *SupplierPriceRepository) ResolveUnitPrice(supplierID uint, item *models.Item, qty int, date time.Time) (decimal.Decimal, *uint, error) {
+	return r.ResolveUnitPriceWithTx(config.DB, supplierID, item, qty, date)
+}
+
+// ResolveUnitPriceWithTx returns the unit price of qty units of the item from the supplier on date
+// Both are in the item's base unit. It is the price list entry valid on that date with the highest minimum quantity not above
+// qty, returned with its ID; without such an entry it is the item's own price and a nil ID.
+// date is an instant, such as the purchasing's date; the entry is the one valid on its local day.
+func (r *SupplierPriceRepository) ResolveUnitPriceWithTx(tx *gorm.DB, supplierID uint, item *models.Item, qty int, date time.Time) (decimal.Decimal, *uint, error) {
+	day := utils.DateOf(date).Format(dateLayout)
+
+	var price models.SupplierPrice
+	result := tx.
+		Where("supplier_id = ? AND item_id = ? AND min_qty <= ?", supplierID, item.ID, qty).
+		Where("valid_from <= ? AND (valid_to IS NULL OR valid_to >= ?)", day, day).
+		Order("min_qty DESC, valid_from DESC").
+		Limit(1).
+		Find(&price)
+	if result.Error != nil {
+		return decimal.Zero, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return item.Price, nil, nil
+	}
+	return price.Price, &price.ID, nil
+}
+
+// BackfillUnitPrices derives the unit price of purchasing lines created before it was stored
+// Safe to run on every startup.
+func (r *SupplierPriceRepository) BackfillUnitPrices() error {
+	return config.DB.Model(&models.PurchasingDetail{}).
+		Where("unit_price = 0 AND qty > 0 AND sub_total <> 0").
+		Update("unit_price", gorm.Expr("sub_total / qty")).Error
+}
+
+// datesOverlap reports whether two validity periods share a day; a nil end is open-ended
+func datesOverlap(fromA time.Time, toA *time.Time, fromB time.Time, toB *time.Time) bool {
+	aStartsAfterB := toB != nil && fromA.Format(dateLayout) > toB.Format(dateLayout)
+	bStartsAfterA := toA != nil && fromB.Format(dateLayout) > toA.Format(dateLayout)
+	return !aStartsAfterB && !bStartsAfterA
+}
@@ -1,16 +1,32 @@
 package repository
 
 import (
+	"errors"
+
 	"procurement-system/config"
 	"procurement-system/models"
+	"procurement-system/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Supplier errors
+var (
+	ErrSupplierCurrencyInUse = errors.New("the supplier's currency cannot change while it has items, price list entries, contracts or open purchasings priced in it")
 )
 
 // SupplierRepository handles supplier data operations
-type SupplierRepository struct{}
+// Supplier changes publish supplier.* webhook events in the same transaction.
+type SupplierRepository struct {
+	eventRepo *WebhookEventRepository
+}
 
 // NewSupplierRepository creates a new SupplierRepository instance
 func NewSupplierRepository() *SupplierRepository {
-	return &SupplierRepository{}
+	return &SupplierRepository{
+		eventRepo: NewWebhookEventRepository(),
+	}
 }
 
 // FindByID finds a supplier by ID
@@ -23,28 +39,112 @@ func (r *SupplierRepository) FindByID(id uint) (*models.Supplier, error) {
 	return &supplier, nil
 }
 
-// GetAll retrieves all suppliers
-func (r *SupplierRepository) GetAll() ([]models.Supplier, error) {
-	var suppliers []models.Supplier
-	result := config.DB.Find(&suppliers)
-	return suppliers, result.Error
+// SupplierFilter holds the optional criteria for listing suppliers
+type SupplierFilter struct {
+	Name  string
+	Email string
+}
+
+// supplierSortColumns maps the accepted sort keys of supplier listings to indexed columns
+var supplierSortColumns = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"email": "email",
+}
+
+// List retrieves one page of suppliers matching the filter
+func (r *SupplierRepository) List(filter SupplierFilter, params ListParams) ([]models.Supplier, PageInfo, error) {
+	query := config.DB.Model(&models.Supplier{})
+
+	if filter.Name != "" {
+		query = query.Where("name LIKE ?", "%"+escapeLike(filter.Name)+"%")
+	}
+	if filter.Email != "" {
+		query = query.Where("email LIKE ?", "%"+escapeLike(filter.Email)+"%")
+	}
+
+	return paginate(query, params, supplierSortColumns, "id", func(supplier *models.Supplier) (interface{}, uint) {
+		switch params.SortBy {
+		case "name":
+			return supplier.Name, supplier.ID
+		case "email":
+			return supplier.Email, supplier.ID
+		default:
+			return supplier.ID, supplier.ID
+		}
+	})
 }
 
 // Create creates a new supplier
 func (r *SupplierRepository) Create(supplier *models.Supplier) error {
-	result := config.DB.Create(supplier)
-	return result.Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(supplier).Error; err != nil {
+			return err
+		}
+		return r.eventRepo.PublishWithTx(tx, models.WebhookEventSupplierCreated, utils.NewEventPayload(models.WebhookEventSupplierCreated, supplier))
+	})
 }
 
 // Update updates an existing supplier
+// Its currency only changes while nothing is priced in it: item prices, price list entries,
+// contract prices and open purchasings are all in the supplier's currency, so the change would
+// silently re-denominate them (ErrSupplierCurrencyInUse).
 func (r *SupplierRepository) Update(supplier *models.Supplier) error {
-	result := config.DB.Save(supplier)
-	return result.Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.Supplier
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, supplier.ID).Error; err != nil {
+			return err
+		}
+		if current.Currency != supplier.Currency {
+			inUse, err := r.currencyInUseWithTx(tx, supplier.ID)
+			if err != nil {
+				return err
+			}
+			if inUse {
+				return ErrSupplierCurrencyInUse
+			}
+		}
+
+		if err := tx.Save(supplier).Error; err != nil {
+			return err
+		}
+		return r.eventRepo.PublishWithTx(tx, models.WebhookEventSupplierUpdated, utils.NewEventPayload(models.WebhookEventSupplierUpdated, supplier))
+	})
 }
 
 // Delete deletes a supplier by ID
+// The supplier's items are removed by the foreign key cascade.
 func (r *SupplierRepository) Delete(id uint) error {
-	result := config.DB.Delete(&models.Supplier{}, id)
-	return result.Error
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var supplier models.Supplier
+		if err := tx.First(&supplier, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Supplier{}, id).Error; err != nil {
+			return err
+		}
+		return r.eventRepo.PublishWithTx(tx, models.WebhookEventSupplierDeleted, utils.NewEventPayload(models.WebhookEventSupplierDeleted, supplier))
+	})
 }
 
+// currencyInUseWithTx reports whether anything is priced in the supplier's currency: its items,
+// price list entries, contracts, or purchasings not yet cancelled or closed
+func (r *SupplierRepository) currencyInUseWithTx(tx *gorm.DB, supplierID uint) (bool, error) {
+	queries := []*gorm.DB{
+		tx.Model(&models.Item{}).Where("supplier_id = ?", supplierID),
+		tx.Model(&models.SupplierPrice{}).Where("supplier_id = ?", supplierID),
+		tx.Model(&models.Contract{}).Where("supplier_id = ?", supplierID),
+		tx.Model(&models.Purchasing{}).Where("supplier_id = ? AND status NOT IN ?", supplierID,
+			[]string{models.PurchasingStatusCancelled, models.PurchasingStatusClosed}),
+	}
+	for _, query := range queries {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"sort"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// Weights of the scorecard metrics in a supplier's overall score
+var (
+	scoreWeightOnTime  = decimal.NewFromInt(40)
+	scoreWeightFill    = decimal.NewFromInt(30)
+	scoreWeightQuality = decimal.NewFromInt(20)
+	scoreWeightPrice   = decimal.NewFromInt(10)
+)
+
+// scorecardStatuses are the statuses of purchasings that were placed with the supplier and not cancelled
+var scorecardStatuses = []string{
+	models.PurchasingStatusOrdered,
+	models.PurchasingStatusPartiallyReceived,
+	models.PurchasingStatusReceived,
+	models.PurchasingStatusClosed,
+}
+
+// ScorecardWindow is the period supplier performance is measured over: the Days days up to and
+// including AsOf
+type ScorecardWindow struct {
+	AsOf time.Time
+	Days int
+}
+
+// bounds returns the instants the window starts and the day after it begins, for comparing with
+// datetime columns. AsOf is an instant; the window ends with its local calendar day.
+func (w ScorecardWindow) bounds() (time.Time, time.Time) {
+	end := utils.DateOf(w.AsOf).AddDate(0, 0, 1)
+	return utils.StartOfDate(end.AddDate(0, 0, -w.Days)), utils.StartOfDate(end)
+}
+
+// SupplierScorecard is how a supplier performed over a window
+// Rates are percentages, nil when there was nothing to measure:
+// - OnTimeRate: of the orders whose expected delivery date has come, or that were received in full
+// before it, the share received in full by that date
+// An order closed short counts as received on the day of its last goods receipt; the shortfall
+// still shows in FillRate.
+// - FillRate: of the quantity on orders that are due or complete, the share received
+// An order without an expected delivery date (ordered before one was always set) is expected
+// ScorecardDefaultLeadDays after its date, so an order never delivered always counts against the
+// supplier once that day has passed.
+// - RejectionRate: of the quantity delivered in the window, the share rejected at receipt
+// - PriceVariance: how far approved invoices in the window were priced above the purchasings
+// Orders count when they were placed in the window; quantities are in the items' base units and
+// amounts in the base currency. Score weighs the rates 40/30/20/10 in that order, over the ones
+// that could be measured; Rank orders the scored suppliers, best first.
+type SupplierScorecard struct {
+	Rank                int              `json:"rank"`
+	SupplierID          uint             `json:"supplierId"`
+	SupplierName        string           `json:"supplierName"`
+	OrderCount          int              `json:"orderCount"`
+	DueCount            int              `json:"dueCount"`
+	OnTimeCount         int              `json:"onTimeCount"`
+	OnTimeRate          *decimal.Decimal `json:"onTimeRate"`
+	OrderedQty          int64            `json:"orderedQty"`
+	FilledQty           int64            `json:"filledQty"`
+	FillRate            *decimal.Decimal `json:"fillRate"`
+	DeliveredQty        int64            `json:"deliveredQty"`
+	RejectedQty         int64            `json:"rejectedQty"`
+	RejectionRate       *decimal.Decimal `json:"rejectionRate"`
+	InvoicedValue       decimal.Decimal  `json:"invoicedValue"`
+	PriceVarianceAmount decimal.Decimal  `json:"priceVarianceAmount"`
+	PriceVariance       *decimal.Decimal `json:"priceVariance"`
+	Score               *decimal.Decimal `json:"score"`
+}
+
+// SupplierScorecardRepository measures supplier delivery, quantity, quality and price performance
+// from purchasings, goods receipts and supplier invoices
+type SupplierScorecardRepository struct{}
+
+// NewSupplierScorecardRepository creates a new SupplierScorecardRepository instance
+func NewSupplierScorecardRepository() *SupplierScorecardRepository {
+	return &SupplierScorecardRepository{}
+}
+
+// Scorecards returns the scorecards of the suppliers that had orders, receipts or approved
+// invoices in the window, ranked best first; suppliers without a score come last, by name
+func (r *SupplierScorecardRepository) Scorecards(window ScorecardWindow) ([]SupplierScorecard, error) {
+	cards, err := r.build(window)
+	if err != nil {
+		return nil, err
+	}
+
+	scorecards := make([]SupplierScorecard, 0, len(cards))
+	for _, card := range cards {
+		scorecards = append(scorecards, *card)
+	}
+	sort.SliceStable(scorecards, func(i, j int) bool {
+		a, b := scorecards[i], scorecards[j]
+		if (a.Score == nil) != (b.Score == nil) {
+			return a.Score != nil
+		}
+		if a.Score != nil && !a.Score.Equal(*b.Score) {
+			return a.Score.GreaterThan(*b.Score)
+		}
+		if a.OrderCount != b.OrderCount {
+			return a.OrderCount > b.OrderCount
+		}
+		if a.SupplierName != b.SupplierName {
+			return a.SupplierName < b.SupplierName
+		}
+		return a.SupplierID < b.SupplierID
+	})
+
+	for i := range scorecards {
+		if scorecards[i].Score != nil {
+			scorecards[i].Rank = i + 1
+		}
+	}
+	return scorecards, nil
+}
+
+// Scorecard returns the scorecard of one supplier, ranked among all suppliers
+// A supplier with nothing to measure in the window gets an empty scorecard.
+func (r *SupplierScorecardRepository) Scorecard(supplier *models.Supplier, window ScorecardWindow) (*SupplierScorecard, error) {
+	scorecards, err := r.Scorecards(window)
+	if err != nil {
+		return nil, err
+	}
+	for i := range scorecards {
+		if scorecards[i].SupplierID == supplier.ID {
+			return &scorecards[i], nil
+		}
+	}
+	return &SupplierScorecard{
+		SupplierID:          supplier.ID,
+		SupplierName:        supplier.Name,
+		InvoicedValue:       decimal.Zero,
+		PriceVarianceAmount: decimal.Zero,
+	}, nil
+}
+
+// build computes the unranked scorecards of every supplier with activity in the window
+func (r *SupplierScorecardRepository) build(window ScorecardWindow) (map[uint]*SupplierScorecard, error) {
+	from, to := window.bounds()
+	cards := make(map[uint]*SupplierScorecard)
+	card := func(supplierID uint) *SupplierScorecard {
+		if cards[supplierID] == nil {
+			cards[supplierID] = &SupplierScorecard{
+				SupplierID:          supplierID,
+				InvoicedValue:       decimal.Zero,
+				PriceVarianceAmount: decimal.Zero,
+			}
+		}
+		return cards[supplierID]
+	}
+	asOf := utils.DateOf(window.AsOf).Format(dateLayout)
+
+	// Orders placed in the window, with when they were first received in full by the end of it.
+	// An order closed short never becomes received; its last goods receipt stands in for that.
+	var orders []struct {
+		ID                   uint
+		SupplierID           uint
+		Status               string
+		Date                 time.Time
+		ExpectedDeliveryDate *time.Time
+		ReceivedAt           *time.Time
+	}
+	err := config.DB.Model(&models.Purchasing{}).
+		Select("purchasings.id, purchasings.supplier_id, purchasings.status, purchasings.date, purchasings.expected_delivery_date, "+
+			"COALESCE((SELECT MIN(h.changed_at) FROM purchasing_status_histories h WHERE h.purchasing_id = purchasings.id "+
+			"AND h.to_status = ? AND h.changed_at < ?), "+
+			"CASE WHEN purchasings.status = ? THEN (SELECT MAX(g.received_at) FROM goods_receipts g "+
+			"WHERE g.purchasing_id = purchasings.id AND g.received_at < ?) END) AS received_at",
+			models.PurchasingStatusReceived, to, models.PurchasingStatusClosed, to).
+		Where("purchasings.status IN ?", scorecardStatuses).
+		Where("purchasings.date >= ? AND purchasings.date < ?", from, to).
+		Scan(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Orders that are due or complete count towards the on-time and fill rates
+	supplierOf := make(map[uint]uint)
+	var settledIDs []uint
+	for _, order := range orders {
+		c := card(order.SupplierID)
+		c.OrderCount++
+
+		// Expected delivery dates are calendar dates; order dates and receipts are instants
+		expected := utils.DateOf(order.Date).AddDate(0, 0, config.ScorecardDefaultLeadDays).Format(dateLayout)
+		if order.ExpectedDeliveryDate != nil {
+			expected = order.ExpectedDeliveryDate.Format(dateLayout)
+		}
+		due := expected <= asOf
+		complete := order.ReceivedAt != nil || order.Status == models.PurchasingStatusClosed
+		if due || order.ReceivedAt != nil {
+			c.DueCount++
+			if order.ReceivedAt != nil && utils.DateOf(*order.ReceivedAt).Format(dateLayout) <= expected {
+				c.OnTimeCount++
+			}
+		}
+		if due || complete {
+			supplierOf[order.ID] = order.SupplierID
+			settledIDs = append(settledIDs, order.ID)
+		}
+	}
+
+	if len(settledIDs) > 0 {
+		var lines []struct {
+			PurchasingID uint
+			Qty          int64
+			UnitFactor   int64
+			ReceivedQty  int64
+		}
+		err := config.DB.Model(&models.PurchasingDetail{}).
+			Select("purchasing_details.purchasing_id, purchasing_details.qty, purchasing_details.unit_factor, "+
+				"(SELECT COALESCE(SUM(l.received_qty), 0) FROM goods_receipt_lines l "+
+				"JOIN goods_receipts g ON g.id = l.goods_receipt_id "+
+				"WHERE l.purchasing_detail_id = purchasing_details.id AND g.received_at < ?) AS received_qty", to).
+			Where("purchasing_details.purchasing_id IN ?", settledIDs).
+			Scan(&lines).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, line := range lines {
+			factor := line.UnitFactor
+			if factor <= 0 {
+				factor = 1
+			}
+			filled := line.ReceivedQty
+			if filled > line.Qty {
+				filled = line.Qty
+			}
+			c := card(supplierOf[line.PurchasingID])
+			c.OrderedQty += line.Qty * factor
+			c.FilledQty += filled * factor
+		}
+	}
+
+	// Quantities delivered in the window, accepted or rejected
+	var receipts []struct {
+		SupplierID  uint
+		ReceivedQty int64
+		RejectedQty int64
+	}
+	err = config.DB.Model(&models.GoodsReceiptLine{}).
+		Select("purchasings.supplier_id, "+
+			"SUM(goods_receipt_lines.received_qty * GREATEST(purchasing_details.unit_factor, 1)) AS received_qty, "+
+			"SUM(goods_receipt_lines.rejected_qty * GREATEST(purchasing_details.unit_factor, 1)) AS rejected_qty").
+		Joins("JOIN goods_receipts ON goods_receipts.id = goods_receipt_lines.goods_receipt_id").
+		Joins("JOIN purchasing_details ON purchasing_details.id = goods_receipt_lines.purchasing_detail_id").
+		Joins("JOIN purchasings ON purchasings.id = purchasing_details.purchasing_id").
+		Where("goods_receipts.received_at >= ? AND goods_receipts.received_at < ?", from, to).
+		Group("purchasings.supplier_id").
+		Scan(&receipts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, receipt := range receipts {
+		c := card(receipt.SupplierID)
+		c.DeliveredQty = receipt.ReceivedQty + receipt.RejectedQty
+		c.RejectedQty = receipt.RejectedQty
+	}
+
+	// Approved invoices of the window against the purchasing prices, at the purchasings' rates
+	var invoices []struct {
+		SupplierID    uint
+		InvoicedValue decimal.Decimal
+		Variance      decimal.Decimal
+	}
+	err = config.DB.Model(&models.SupplierInvoiceLine{}).
+		Select("supplier_invoices.supplier_id, "+
+			"SUM(supplier_invoice_lines.po_unit_price * supplier_invoice_lines.qty * purchasings.exchange_rate) AS invoiced_value, "+
+			"SUM(supplier_invoice_lines.price_variance * purchasings.exchange_rate) AS variance").
+		Joins("JOIN supplier_invoices ON supplier_invoices.id = supplier_invoice_lines.invoice_id").
+		Joins("JOIN purchasings ON purchasings.id = supplier_invoices.purchasing_id").
+		Where("supplier_invoices.status = ?", models.SupplierInvoiceStatusApproved).
+		Where("supplier_invoices.invoice_date >= ? AND supplier_invoices.invoice_date < ?", from, to).
+		Group("supplier_invoices.supplier_id").
+		Scan(&invoices).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, invoice := range invoices {
+		c := card(invoice.SupplierID)
+		c.InvoicedValue = invoice.InvoicedValue.Round(2)
+		c.PriceVarianceAmount = invoice.Variance.Round(2)
+	}
+
+	if len(cards) == 0 {
+		return cards, nil
+	}
+
+	ids := make([]uint, 0, len(cards))
+	for id := range cards {
+		ids = append(ids, id)
+	}
+	var suppliers []models.Supplier
+	if err := config.DB.Select("id", "name").Where("id IN ?", ids).Find(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	for _, supplier := range suppliers {
+		cards[supplier.ID].SupplierName = supplier.Name
+	}
+
+	for _, c := range cards {
+		c.score()
+	}
+	return cards, nil
+}
+
+// score works out the rates of the scorecard and its overall score
+func (c *SupplierScorecard) score() {
+	c.OnTimeRate = percentOf(decimal.NewFromInt(int64(c.OnTimeCount)), decimal.NewFromInt(int64(c.DueCount)))
+	c.FillRate = percentOf(decimal.NewFromInt(c.FilledQty), decimal.NewFromInt(c.OrderedQty))
+	c.RejectionRate = percentOf(decimal.NewFromInt(c.RejectedQty), decimal.NewFromInt(c.DeliveredQty))
+	c.PriceVariance = percentOf(c.PriceVarianceAmount, c.InvoicedValue)
+
+	total := decimal.Zero
+	weights := decimal.Zero
+	add := func(rate *decimal.Decimal, weight decimal.Decimal) {
+		if rate == nil {
+			return
+		}
+		total = total.Add(rate.Mul(weight))
+		weights = weights.Add(weight)
+	}
+	add(c.OnTimeRate, scoreWeightOnTime)
+	add(c.FillRate, scoreWeightFill)
+	if c.RejectionRate != nil {
+		quality := hundred.Sub(*c.RejectionRate)
+		add(&quality, scoreWeightQuality)
+	}
+	if c.PriceVariance != nil {
+		// Invoices at or below the purchasing price score in full
+		price := hundred.Sub(decimal.Min(decimal.Max(*c.PriceVariance, decimal.Zero), hundred))
+		add(&price, scoreWeightPrice)
+	}
+
+	if weights.IsPositive() {
+		score := total.Div(weights).Round(2)
+		c.Score = &score
+	}
+}
+
+// percentOf returns part as a percentage of whole, or nil when whole is not positive
+func percentOf(part, whole decimal.Decimal) *decimal.Decimal {
+	if !whole.IsPositive() {
+		return nil
+	}
+	percent := part.Mul(hundred).Div(whole).Round(2)
+	return &percent
+}
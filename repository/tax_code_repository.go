@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"errors"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrTaxCodeInUse is returned when a tax code that items or purchasing lines refer to is deleted
+var ErrTaxCodeInUse = errors.New("tax code is used by items or purchasings; deactivate it instead")
+
+// TaxCodeRepository handles tax codes
+type TaxCodeRepository struct{}
+
+// NewTaxCodeRepository creates a new TaxCodeRepository instance
+func NewTaxCodeRepository() *TaxCodeRepository {
+	return &TaxCodeRepository{}
+}
+
+// FindByID finds a tax code by ID
+func (r *TaxCodeRepository) FindByID(id uint) (*models.TaxCode, error) {
+	var code models.TaxCode
+	result := config.DB.First(&code, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &code, nil
+}
+
+// FindByCode finds a tax code by its code
+func (r *TaxCodeRepository) FindByCode(code string) (*models.TaxCode, error) {
+	var taxCode models.TaxCode
+	result := config.DB.Where("code = ?", code).First(&taxCode)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &taxCode, nil
+}
+
+// GetAll retrieves all tax codes ordered by code, optionally only the active ones
+func (r *TaxCodeRepository) GetAll(activeOnly bool) ([]models.TaxCode, error) {
+	query := config.DB.Order("code ASC")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+
+	codes := []models.TaxCode{}
+	result := query.Find(&codes)
+	return codes, result.Error
+}
+
+// Create creates a new tax code
+func (r *TaxCodeRepository) Create(code *models.TaxCode) error {
+	return config.DB.Create(code).Error
+}
+
+// Update updates an existing tax code
+// Purchasing lines keep the rate they were priced with, so a new rate only affects new purchasings.
+func (r *TaxCodeRepository) Update(code *models.TaxCode) error {
+	return config.DB.Save(code).Error
+}
+
+// Delete deletes a tax code that no item or purchasing line refers to
+// Otherwise ErrTaxCodeInUse is returned.
+func (r *TaxCodeRepository) Delete(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var code models.TaxCode
+		if err := tx.First(&code, id).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{&models.Item{}, &models.PurchasingDetail{}} {
+			var count int64
+			if err := tx.Model(model).Where("tax_code_id = ? OR withholding_code_id = ?", id, id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrTaxCodeInUse
+			}
+		}
+
+		return tx.Delete(&code).Error
+	})
+}
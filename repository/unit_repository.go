@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Unit of measure errors
+var (
+	ErrUnitInUse         = errors.New("unit is used by items or purchasings")
+	ErrUnitNotForItem    = errors.New("unit is neither the item's base unit nor one of its purchase units")
+	ErrBaseUnitImmutable = errors.New("the base unit can only change while the item has no stock and no open purchasings")
+)
+
+// UnitRepository handles units of measure and the units each item is purchased in
+type UnitRepository struct{}
+
+// NewUnitRepository creates a new UnitRepository instance
+func NewUnitRepository() *UnitRepository {
+	return &UnitRepository{}
+}
+
+// FindByID finds a unit by ID
+func (r *UnitRepository) FindByID(id uint) (*models.UnitOfMeasure, error) {
+	var unit models.UnitOfMeasure
+	result := config.DB.First(&unit, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &unit, nil
+}
+
+// FindByCode finds a unit by its code
+func (r *UnitRepository) FindByCode(code string) (*models.UnitOfMeasure, error) {
+	var unit models.UnitOfMeasure
+	result := config.DB.Where("code = ?", code).First(&unit)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &unit, nil
+}
+
+// GetAll retrieves all units ordered by code
+func (r *UnitRepository) GetAll() ([]models.UnitOfMeasure, error) {
+	units := []models.UnitOfMeasure{}
+	result := config.DB.Order("code ASC").Find(&units)
+	return units, result.Error
+}
+
+// Create creates a new unit
+func (r *UnitRepository) Create(unit *models.UnitOfMeasure) error {
+	return config.DB.Create(unit).Error
+}
+
+// Update updates an existing unit
+func (r *UnitRepository) Update(unit *models.UnitOfMeasure) error {
+	return config.DB.Save(unit).Error
+}
+
+// Delete deletes a unit that no item or purchasing line uses
+// Otherwise ErrUnitInUse is returned.
+func (r *UnitRepository) Delete(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var unit models.UnitOfMeasure
+		if err := tx.First(&unit, id).Error; err != nil {
+			return err
+		}
+
+		references := []struct {
+			model interface{}
+			where string
+		}{
+			{&models.Item{}, "base_unit_id = ?"},
+			{&models.ItemUnit{}, "unit_id = ?"},
+			{&models.PurchasingDetail{}, "unit_id = ?"},
+		}
+		for _, ref := range references {
+			var count int64
+			if err := tx.Model(ref.model).Where(ref.where, id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrUnitInUse
+			}
+		}
+
+		return tx.Delete(&unit).Error
+	})
+}
+
+// FindItemWithUnits finds an item with its base unit and purchase units
+func (r *UnitRepository) FindItemWithUnits(itemID uint) (*models.Item, error) {
+	var item models.Item
+	result := config.DB.Preload("BaseUnit").Preload("Units").Preload("Units.Unit").First(&item, itemID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &item, nil
+}
+
+// ReplaceItemUnitsTransaction sets an item's base unit and replaces its purchase units
+// Purchasing lines keep the factor they were created with, so changed factors only apply to
+// new lines. The base unit is what stock is counted in, so it only changes while the item has
+// no stock and no open purchasings; otherwise ErrBaseUnitImmutable is returned.
+func (r *UnitRepository) ReplaceItemUnitsTransaction(itemID, baseUnitID uint, units []models.ItemUnit) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var item models.Item
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, itemID).Error; err != nil {
+			return err
+		}
+
+		if item.BaseUnitID == nil || *item.BaseUnitID != baseUnitID {
+			if item.Stock != 0 {
+				return ErrBaseUnitImmutable
+			}
+			var open int64
+			if err := tx.Model(&models.PurchasingDetail{}).
+				Joins("JOIN purchasings ON purchasings.id = purchasing_details.purchasing_id").
+				Where("purchasing_details.item_id = ? AND purchasings.status IN ?", itemID, openPurchasingStatuses).
+				Count(&open).Error; err != nil {
+				return err
+			}
+			if open > 0 {
+				return ErrBaseUnitImmutable
+			}
+			if err := tx.Model(&item).Update("base_unit_id", baseUnitID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("item_id = ?", itemID).Delete(&models.ItemUnit{}).Error; err != nil {
+			return err
+		}
+		for i := range units {
+			units[i].ItemID = itemID
+			if err := tx.Omit("Unit").Create(&units[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ResolveUnit returns the unit a line of the item is ordered in and how many base units it holds
+// A nil unitID is the item's base unit. ErrUnitNotForItem is returned for a unit that is
+// neither the base unit nor one of the item's purchase units.
+func (r *UnitRepository) ResolveUnit(item *models.Item, unitID *uint) (*uint, int, error) {
+	if unitID == nil || (item.BaseUnitID != nil && *unitID == *item.BaseUnitID) {
+		return item.BaseUnitID, 1, nil
+	}
+
+	var itemUnit models.ItemUnit
+	result := config.DB.Where("item_id = ? AND unit_id = ?", item.ID, *unitID).Limit(1).Find(&itemUnit)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, 0, fmt.Errorf("unit %d for item %d: %w", *unitID, item.ID, ErrUnitNotForItem)
+	}
+	return unitID, itemUnit.Factor, nil
+}
+
+// BackfillBaseUnits gives items and purchasing lines from before units of measure the PCS unit
+// - The PCS unit is created when it does not exist
+// - Items without a base unit are counted in PCS
+// - Purchasing lines without a unit are in their item's base unit
+// Safe to run on every startup.
+func (r *UnitRepository) BackfillBaseUnits() error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var unit models.UnitOfMeasure
+		result := tx.Where("code = ?", models.DefaultUnitCode).Limit(1).Find(&unit)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			now := time.Now()
+			unit = models.UnitOfMeasure{
+				Code:      models.DefaultUnitCode,
+				Name:      "Pieces",
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := tx.Create(&unit).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Item{}).Where("base_unit_id IS NULL").Update("base_unit_id", unit.ID).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			"UPDATE purchasing_details SET unit_id = (SELECT items.base_unit_id FROM items WHERE items.id = purchasing_details.item_id) " +
+				"WHERE unit_id IS NULL",
+		).Error
+	})
+}
@@ -38,3 +38,20 @@ func (r *UserRepository) FindByID(id uint) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+// CountByRole counts users with the given role
+func (r *UserRepository) CountByRole(role string) (int64, error) {
+	var count int64
+	result := config.DB.Model(&models.User{}).Where("role = ?", role).Count(&count)
+	return count, result.Error
+}
+
+// FindFirstByRole finds the oldest user with the given role
+func (r *UserRepository) FindFirstByRole(role string) (*models.User, error) {
+	var user models.User
+	result := config.DB.Where("role = ?", role).Order("id ASC").First(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &user, nil
+}
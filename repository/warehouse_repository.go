@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrWarehouseInUse is returned when a warehouse that still holds stock or documents is deleted
+var ErrWarehouseInUse = errors.New("warehouse is the default, holds stock or is referenced by documents")
+
+// WarehouseRepository handles warehouses and the per-warehouse stock balances
+type WarehouseRepository struct{}
+
+// NewWarehouseRepository creates a new WarehouseRepository instance
+func NewWarehouseRepository() *WarehouseRepository {
+	return &WarehouseRepository{}
+}
+
+// FindByID finds a warehouse by ID
+func (r *WarehouseRepository) FindByID(id uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	result := config.DB.First(&warehouse, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &warehouse, nil
+}
+
+// FindByCode finds a warehouse by its code
+func (r *WarehouseRepository) FindByCode(code string) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	result := config.DB.Where("code = ?", code).First(&warehouse)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &warehouse, nil
+}
+
+// FindDefault finds the default warehouse
+func (r *WarehouseRepository) FindDefault() (*models.Warehouse, error) {
+	return r.FindDefaultWithTx(config.DB)
+}
+
+// FindDefaultWithTx finds the default warehouse using the provided transaction
+func (r *WarehouseRepository) FindDefaultWithTx(tx *gorm.DB) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	result := tx.Where("is_default = ?", true).Order("id ASC").First(&warehouse)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &warehouse, nil
+}
+
+// ResolveWithTx returns the given warehouse ID, or the default warehouse's ID when it is nil
+func (r *WarehouseRepository) ResolveWithTx(tx *gorm.DB, warehouseID *uint) (uint, error) {
+	if warehouseID != nil {
+		return *warehouseID, nil
+	}
+	warehouse, err := r.FindDefaultWithTx(tx)
+	if err != nil {
+		return 0, err
+	}
+	return warehouse.ID, nil
+}
+
+// GetAll retrieves all warehouses ordered by code
+func (r *WarehouseRepository) GetAll() ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	result := config.DB.Order("code ASC").Find(&warehouses)
+	return warehouses, result.Error
+}
+
+// Create creates a new warehouse
+// The first warehouse always becomes the default, and a new default replaces the old one.
+func (r *WarehouseRepository) Create(warehouse *models.Warehouse) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Warehouse{}).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			warehouse.IsDefault = true
+		}
+
+		if err := tx.Create(warehouse).Error; err != nil {
+			return err
+		}
+		return r.keepSingleDefaultWithTx(tx, warehouse)
+	})
+}
+
+// Update updates an existing warehouse
+// The default can be moved to another warehouse but not removed, so unsetting IsDefault on the
+// current default is ignored.
+func (r *WarehouseRepository) Update(warehouse *models.Warehouse) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if !warehouse.IsDefault {
+			var current models.Warehouse
+			if err := tx.First(&current, warehouse.ID).Error; err != nil {
+				return err
+			}
+			warehouse.IsDefault = current.IsDefault
+		}
+
+		if err := tx.Save(warehouse).Error; err != nil {
+			return err
+		}
+		return r.keepSingleDefaultWithTx(tx, warehouse)
+	})
+}
+
+// keepSingleDefaultWithTx clears the default flag on every other warehouse when warehouse is the default
+func (r *WarehouseRepository) keepSingleDefaultWithTx(tx *gorm.DB, warehouse *models.Warehouse) error {
+	if !warehouse.IsDefault {
+		return nil
+	}
+	return tx.Model(&models.Warehouse{}).
+		Where("id <> ? AND is_default = ?", warehouse.ID, true).
+		Update("is_default", false).Error
+}
+
+// Delete deletes a warehouse by ID
+// Only warehouses that are not the default, hold no stock and are named on no document can be
+// deleted; otherwise ErrWarehouseInUse is returned.
+func (r *WarehouseRepository) Delete(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		var warehouse models.Warehouse
+		if err := tx.First(&warehouse, id).Error; err != nil {
+			return err
+		}
+		if warehouse.IsDefault {
+			return ErrWarehouseInUse
+		}
+
+		var stocked int64
+		if err := tx.Model(&models.WarehouseStock{}).Where("warehouse_id = ? AND quantity <> 0", id).Count(&stocked).Error; err != nil {
+			return err
+		}
+		if stocked > 0 {
+			return ErrWarehouseInUse
+		}
+
+		references := []struct {
+			model interface{}
+			where string
+			args  []interface{}
+		}{
+			{&models.Purchasing{}, "warehouse_id = ?", []interface{}{id}},
+			{&models.GoodsReceipt{}, "warehouse_id = ?", []interface{}{id}},
+			{&models.StockAdjustment{}, "warehouse_id = ?", []interface{}{id}},
+			{&models.CycleCount{}, "warehouse_id = ?", []interface{}{id}},
+			{&models.StockTransfer{}, "from_warehouse_id = ? OR to_warehouse_id = ?", []interface{}{id, id}},
+		}
+		for _, ref := range references {
+			var count int64
+			if err := tx.Model(ref.model).Where(ref.where, ref.args...).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrWarehouseInUse
+			}
+		}
+
+		if err := tx.Where("warehouse_id = ?", id).Delete(&models.WarehouseStock{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Warehouse{}, id).Error
+	})
+}
+
+// StockQuantityWithTx returns the balance of an item in a warehouse, zero if it was never stocked there
+func (r *WarehouseRepository) StockQuantityWithTx(tx *gorm.DB, itemID, warehouseID uint) (int, error) {
+	var stock models.WarehouseStock
+	result := tx.Where("item_id = ? AND warehouse_id = ?", itemID, warehouseID).Limit(1).Find(&stock)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return stock.Quantity, nil
+}
+
+// BackfillDefaultWarehouse creates the default warehouse and moves stock from before warehouses into it
+// - When no warehouse exists, a MAIN warehouse is created as the default
+// - Items with stock but no warehouse balance get their whole stock as the default's balance
+// - Movements and documents recorded without a warehouse are attributed to the default
+// Safe to run on every startup.
+func (r *WarehouseRepository) BackfillDefaultWarehouse() error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		warehouse, err := r.FindDefaultWithTx(tx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			warehouse, err = r.ensureDefaultWithTx(tx)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec(
+			"INSERT INTO warehouse_stocks (item_id, warehouse_id, quantity) "+
+				"SELECT items.id, ?, items.stock FROM items "+
+				"WHERE items.stock <> 0 AND NOT EXISTS (SELECT 1 FROM warehouse_stocks WHERE warehouse_stocks.item_id = items.id)",
+			warehouse.ID,
+		).Error; err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{
+			&models.StockMovement{},
+			&models.Purchasing{},
+			&models.GoodsReceipt{},
+			&models.StockAdjustment{},
+			&models.CycleCount{},
+		} {
+			if err := tx.Model(model).Where("warehouse_id IS NULL").Update("warehouse_id", warehouse.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ensureDefaultWithTx makes the oldest warehouse the default, creating MAIN when there is none
+func (r *WarehouseRepository) ensureDefaultWithTx(tx *gorm.DB) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	result := tx.Order("id ASC").Limit(1).Find(&warehouse)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		now := time.Now()
+		warehouse = models.Warehouse{
+			Code:      models.DefaultWarehouseCode,
+			Name:      "Main Warehouse",
+			IsDefault: true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		return &warehouse, tx.Create(&warehouse).Error
+	}
+
+	warehouse.IsDefault = true
+	return &warehouse, tx.Model(&warehouse).Update("is_default", true).Error
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"procurement-system/config"
+	"procurement-system/models"
+)
+
+// WebhookDeliveryRepository handles the webhook delivery history
+type WebhookDeliveryRepository struct{}
+
+// NewWebhookDeliveryRepository creates a new WebhookDeliveryRepository instance
+func NewWebhookDeliveryRepository() *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{}
+}
+
+// Create records one delivery attempt
+func (r *WebhookDeliveryRepository) Create(delivery *models.WebhookDelivery) error {
+	result := config.DB.Create(delivery)
+	return result.Error
+}
+
+// webhookDeliverySortColumns maps the accepted sort keys of delivery listings to indexed columns
+var webhookDeliverySortColumns = map[string]string{
+	"id": "id",
+}
+
+// ListBySubscription retrieves one page of delivery attempts made for a subscription
+// A non-nil success restricts the page to successful or failed attempts.
+func (r *WebhookDeliveryRepository) ListBySubscription(subscriptionID uint, success *bool, params ListParams) ([]models.WebhookDelivery, PageInfo, error) {
+	query := config.DB.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if success != nil {
+		query = query.Where("success = ?", *success)
+	}
+
+	return paginate(query, params, webhookDeliverySortColumns, "id", func(delivery *models.WebhookDelivery) (interface{}, uint) {
+		return delivery.ID, delivery.ID
+	})
+}
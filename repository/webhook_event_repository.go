@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+	"procurement-system/utils"
+
+	"gorm.io/gorm"
+)
+
+// WebhookEventRepository handles the webhook outbox
+type WebhookEventRepository struct{}
+
+// NewWebhookEventRepository creates a new WebhookEventRepository instance
+func NewWebhookEventRepository() *WebhookEventRepository {
+	return &WebhookEventRepository{}
+}
+
+// EnqueueWithTx writes a pending webhook event for a single target URL using the provided transaction
+// The event only becomes visible to the dispatcher if the surrounding transaction commits.
+func (r *WebhookEventRepository) EnqueueWithTx(tx *gorm.DB, eventType, targetURL string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return r.createWithTx(tx, eventType, targetURL, nil, body)
+}
+
+// PublishWithTx queues the event for every active subscription that receives its event type
+// Like EnqueueWithTx, the events are only delivered if the surrounding transaction commits.
+func (r *WebhookEventRepository) PublishWithTx(tx *gorm.DB, eventType string, payload interface{}) error {
+	var subscriptions []models.WebhookSubscription
+	err := tx.
+		Joins("JOIN webhook_subscription_events ON webhook_subscription_events.subscription_id = webhook_subscriptions.id").
+		Where("webhook_subscriptions.paused = ? AND webhook_subscription_events.event_type = ?", false, eventType).
+		Find(&subscriptions).Error
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for i := range subscriptions {
+		if err := r.createWithTx(tx, eventType, subscriptions[i].URL, &subscriptions[i].ID, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishPurchasingWithTx queues a purchasing event for webhookURL (if any) and every subscription receiving it
+// The purchasing and its details are loaded inside the transaction so the payload matches what is committed.
+func (r *WebhookEventRepository) PublishPurchasingWithTx(tx *gorm.DB, eventType, webhookURL string, purchasingID uint) error {
+	var purchasing models.Purchasing
+	var details []models.PurchasingDetail
+
+	if err := tx.Preload("Supplier").Preload("User").First(&purchasing, purchasingID).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("purchasing_id = ?", purchasingID).Preload("Item").Find(&details).Error; err != nil {
+		return err
+	}
+
+	payload := utils.NewPurchasingWebhookPayload(eventType, &purchasing, details)
+	if webhookURL != "" {
+		if err := r.EnqueueWithTx(tx, eventType, webhookURL, payload); err != nil {
+			return err
+		}
+	}
+	return r.PublishWithTx(tx, eventType, payload)
+}
+
+// createWithTx inserts one pending event with a fresh delivery ID
+func (r *WebhookEventRepository) createWithTx(tx *gorm.DB, eventType, targetURL string, subscriptionID *uint, body []byte) error {
+	deliveryID, err := newDeliveryID()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	event := models.WebhookEvent{
+		DeliveryID:     deliveryID,
+		EventType:      eventType,
+		SubscriptionID: subscriptionID,
+		TargetURL:      targetURL,
+		Payload:        string(body),
+		Status:         models.WebhookEventStatusPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+	return tx.Create(&event).Error
+}
+
+// FindByID finds a webhook event by ID
+func (r *WebhookEventRepository) FindByID(id uint) (*models.WebhookEvent, error) {
+	var event models.WebhookEvent
+	result := config.DB.First(&event, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &event, nil
+}
+
+// FindDue retrieves pending events whose next attempt is due, oldest first
+// Events of paused subscriptions are held back until the subscription is resumed.
+func (r *WebhookEventRepository) FindDue(limit int) ([]models.WebhookEvent, error) {
+	var events []models.WebhookEvent
+	result := config.DB.
+		Joins("LEFT JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_events.subscription_id").
+		Where("webhook_events.status = ? AND webhook_events.next_attempt_at <= ?", models.WebhookEventStatusPending, time.Now()).
+		Where("webhook_events.subscription_id IS NULL OR webhook_subscriptions.paused = ?", false).
+		Order("webhook_events.next_attempt_at ASC, webhook_events.id ASC").
+		Limit(limit).
+		Find(&events)
+	return events, result.Error
+}
+
+// Claim leases a due event to the caller by pushing its next attempt into the future
+// It returns false if another dispatcher claimed or changed the event first, which makes
+// it safe to run several application instances against the same outbox.
+func (r *WebhookEventRepository) Claim(event *models.WebhookEvent, lease time.Duration) (bool, error) {
+	leaseUntil := time.Now().Add(lease)
+	result := config.DB.Model(&models.WebhookEvent{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", event.ID, models.WebhookEventStatusPending, event.NextAttemptAt).
+		Update("next_attempt_at", leaseUntil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	event.NextAttemptAt = leaseUntil
+	return true, nil
+}
+
+// MarkDelivered records a successful delivery
+func (r *WebhookEventRepository) MarkDelivered(event *models.WebhookEvent, statusCode int) error {
+	now := time.Now()
+	return config.DB.Model(event).Updates(map[string]interface{}{
+		"status":           models.WebhookEventStatusDelivered,
+		"attempts":         event.Attempts + 1,
+		"last_status_code": statusCode,
+		"last_error":       "",
+		"delivered_at":     now,
+	}).Error
+}
+
+// MarkFailed records a failed delivery attempt
+// With a nil nextAttempt the event is moved to the dead state; otherwise it is retried at that time.
+func (r *WebhookEventRepository) MarkFailed(event *models.WebhookEvent, statusCode int, deliveryErr string, nextAttempt *time.Time) error {
+	updates := map[string]interface{}{
+		"attempts":         event.Attempts + 1,
+		"last_status_code": statusCode,
+		"last_error":       deliveryErr,
+	}
+	if nextAttempt == nil {
+		updates["status"] = models.WebhookEventStatusDead
+	} else {
+		updates["next_attempt_at"] = *nextAttempt
+	}
+	return config.DB.Model(event).Updates(updates).Error
+}
+
+// Replay puts a dead (or delivered) event back in the queue with a fresh attempt budget
+func (r *WebhookEventRepository) Replay(event *models.WebhookEvent) error {
+	return config.DB.Model(event).Updates(map[string]interface{}{
+		"status":          models.WebhookEventStatusPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"last_error":      "",
+	}).Error
+}
+
+// webhookEventSortColumns maps the accepted sort keys of webhook event listings to indexed columns
+var webhookEventSortColumns = map[string]string{
+	"id": "id",
+}
+
+// WebhookEventFilter holds the optional criteria for listing webhook events
+// Zero values mean "no filter" for that field.
+type WebhookEventFilter struct {
+	Status         string
+	EventType      string
+	SubscriptionID uint
+}
+
+// List retrieves one page of webhook events matching the filter
+func (r *WebhookEventRepository) List(filter WebhookEventFilter, params ListParams) ([]models.WebhookEvent, PageInfo, error) {
+	query := config.DB.Model(&models.WebhookEvent{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.SubscriptionID != 0 {
+		query = query.Where("subscription_id = ?", filter.SubscriptionID)
+	}
+
+	return paginate(query, params, webhookEventSortColumns, "id", func(event *models.WebhookEvent) (interface{}, uint) {
+		return event.ID, event.ID
+	})
+}
+
+// newDeliveryID returns a random identifier for a webhook event
+func newDeliveryID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
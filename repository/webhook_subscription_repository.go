@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"time"
+
+	"procurement-system/config"
+	"procurement-system/models"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscriptionRepository handles webhook subscription data operations
+type WebhookSubscriptionRepository struct{}
+
+// NewWebhookSubscriptionRepository creates a new WebhookSubscriptionRepository instance
+func NewWebhookSubscriptionRepository() *WebhookSubscriptionRepository {
+	return &WebhookSubscriptionRepository{}
+}
+
+// FindByID finds a webhook subscription by ID with its event types
+func (r *WebhookSubscriptionRepository) FindByID(id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	result := config.DB.Preload("Events").First(&subscription, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &subscription, nil
+}
+
+// webhookSubscriptionSortColumns maps the accepted sort keys of subscription listings to indexed columns
+var webhookSubscriptionSortColumns = map[string]string{
+	"id": "id",
+}
+
+// List retrieves one page of webhook subscriptions, optionally only paused or only active ones
+func (r *WebhookSubscriptionRepository) List(paused *bool, params ListParams) ([]models.WebhookSubscription, PageInfo, error) {
+	query := config.DB.Model(&models.WebhookSubscription{})
+	if paused != nil {
+		query = query.Where("paused = ?", *paused)
+	}
+	query = query.Preload("Events")
+
+	return paginate(query, params, webhookSubscriptionSortColumns, "id", func(subscription *models.WebhookSubscription) (interface{}, uint) {
+		return subscription.ID, subscription.ID
+	})
+}
+
+// Create creates a new webhook subscription together with its event types
+func (r *WebhookSubscriptionRepository) Create(subscription *models.WebhookSubscription) error {
+	result := config.DB.Create(subscription)
+	return result.Error
+}
+
+// UpdateTransaction saves the subscription and replaces its event types in one transaction
+func (r *WebhookSubscriptionRepository) UpdateTransaction(subscription *models.WebhookSubscription, events []models.WebhookSubscriptionEvent) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Events").Save(subscription).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", subscription.ID).Delete(&models.WebhookSubscriptionEvent{}).Error; err != nil {
+			return err
+		}
+		for i := range events {
+			events[i].ID = 0
+			events[i].SubscriptionID = subscription.ID
+		}
+		if len(events) > 0 {
+			if err := tx.Create(&events).Error; err != nil {
+				return err
+			}
+		}
+		subscription.Events = events
+		return nil
+	})
+}
+
+// SetPaused pauses or resumes a subscription
+func (r *WebhookSubscriptionRepository) SetPaused(subscription *models.WebhookSubscription, paused bool) error {
+	subscription.Paused = paused
+	subscription.UpdatedAt = time.Now()
+	return config.DB.Model(subscription).Updates(map[string]interface{}{
+		"paused":     paused,
+		"updated_at": subscription.UpdatedAt,
+	}).Error
+}
+
+// DeleteTransaction deletes a subscription and dead-letters its undelivered events
+// Delivered events and the delivery history are kept for auditing.
+func (r *WebhookSubscriptionRepository) DeleteTransaction(id uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WebhookEvent{}).
+			Where("subscription_id = ? AND status = ?", id, models.WebhookEventStatusPending).
+			Updates(map[string]interface{}{
+				"status":     models.WebhookEventStatusDead,
+				"last_error": "subscription deleted",
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookSubscriptionEvent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookSubscription{}, id).Error
+	})
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"procurement-system/controllers"
 	"procurement-system/middleware"
+	"procurement-system/models"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm" // Pastikan import gorm ditambahkan
@@ -16,6 +17,27 @@ func RegisterRoutes(app *fiber.App, db *gorm.DB) {
     purchasingController := controllers.NewPurchasingController()
     itemController := controllers.NewItemController(db)
     supplierController := controllers.NewSupplierController(db)
+    goodsReceiptController := controllers.NewGoodsReceiptController()
+    approvalRuleController := controllers.NewApprovalRuleController()
+    webhookEventController := controllers.NewWebhookEventController()
+    webhookSubscriptionController := controllers.NewWebhookSubscriptionController()
+    stockAdjustmentController := controllers.NewStockAdjustmentController()
+    cycleCountController := controllers.NewCycleCountController()
+    warehouseController := controllers.NewWarehouseController()
+    stockTransferController := controllers.NewStockTransferController()
+    reorderController := controllers.NewReorderController()
+    supplierPriceController := controllers.NewSupplierPriceController()
+    exchangeRateController := controllers.NewExchangeRateController()
+    reportController := controllers.NewReportController()
+    taxCodeController := controllers.NewTaxCodeController()
+    unitController := controllers.NewUnitController()
+    supplierInvoiceController := controllers.NewSupplierInvoiceController()
+    departmentController := controllers.NewDepartmentController()
+    budgetController := controllers.NewBudgetController()
+    requisitionController := controllers.NewPurchaseRequisitionController()
+    rfqController := controllers.NewRFQController()
+    scorecardController := controllers.NewSupplierScorecardController()
+    contractController := controllers.NewContractController()
 
     // 1. Root Group
     api := app.Group("/api")
@@ -35,19 +57,207 @@ func RegisterRoutes(app *fiber.App, db *gorm.DB) {
         })
     })
 
+    // User management (admins create further admin and staff accounts)
+    protected.Post("/users", middleware.RequireRole(models.RoleAdmin), userController.Register)
+
+    // Every route below is guarded by a permission from the matrix in middleware/authorization.go
+
     // --- Master Data Endpoints (CRUD Items & Suppliers) ---
     items := protected.Group("/items")
-    items.Get("/", itemController.GetAll)
-    items.Post("/", itemController.Create)
-    items.Put("/:id", itemController.Update)
-    items.Delete("/:id", itemController.Delete)
+    items.Get("/", middleware.RequirePermission(middleware.PermItemsRead), itemController.GetAll)
+    items.Post("/", middleware.RequirePermission(middleware.PermItemsWrite), itemController.Create)
+    items.Get("/reconciliation", middleware.RequirePermission(middleware.PermStockReconcile), itemController.GetReconciliation)
+    items.Get("/:id/stock", middleware.RequirePermission(middleware.PermItemsRead), itemController.GetStock)
+    items.Get("/:id/movements", middleware.RequirePermission(middleware.PermItemsRead), itemController.GetMovements)
+    items.Get("/:id/prices", middleware.RequirePermission(middleware.PermSuppliersRead), supplierPriceController.GetByItem)
+    items.Put("/:id", middleware.RequirePermission(middleware.PermItemsWrite), itemController.Update)
+    items.Put("/:id/reorder", middleware.RequirePermission(middleware.PermItemsWrite), itemController.UpdateReorderSettings)
+    items.Put("/:id/taxes", middleware.RequirePermission(middleware.PermItemsWrite), itemController.UpdateTaxes)
+    items.Get("/:id/units", middleware.RequirePermission(middleware.PermItemsRead), itemController.GetUnits)
+    items.Put("/:id/units", middleware.RequirePermission(middleware.PermItemsWrite), itemController.UpdateUnits)
+    items.Delete("/:id", middleware.RequirePermission(middleware.PermItemsDelete), itemController.Delete)
+
+    // Units of measure (stock is counted in each item's base unit)
+    units := protected.Group("/units")
+    units.Get("/", middleware.RequirePermission(middleware.PermItemsRead), unitController.GetAll)
+    units.Post("/", middleware.RequirePermission(middleware.PermItemsWrite), unitController.Create)
+    units.Put("/:id", middleware.RequirePermission(middleware.PermItemsWrite), unitController.Update)
+    units.Delete("/:id", middleware.RequirePermission(middleware.PermItemsDelete), unitController.Delete)
 
     suppliers := protected.Group("/suppliers")
-    suppliers.Get("/", supplierController.GetAll)
-    suppliers.Post("/", supplierController.Create)
-    suppliers.Put("/:id", supplierController.Update)
-    suppliers.Delete("/:id", supplierController.Delete)
+    suppliers.Get("/", middleware.RequirePermission(middleware.PermSuppliersRead), supplierController.GetAll)
+    suppliers.Get("/scorecards", middleware.RequirePermission(middleware.PermSuppliersRead), scorecardController.GetAll)
+    suppliers.Post("/", middleware.RequirePermission(middleware.PermSuppliersWrite), supplierController.Create)
+    suppliers.Put("/:id", middleware.RequirePermission(middleware.PermSuppliersWrite), supplierController.Update)
+    suppliers.Delete("/:id", middleware.RequirePermission(middleware.PermSuppliersDelete), supplierController.Delete)
+
+    // Supplier price lists (quantity breaks with validity dates)
+    suppliers.Get("/:id/prices", middleware.RequirePermission(middleware.PermSuppliersRead), supplierPriceController.GetBySupplier)
+    suppliers.Post("/:id/prices", middleware.RequirePermission(middleware.PermSuppliersWrite), supplierPriceController.Create)
+    suppliers.Delete("/:id/prices/:priceId", middleware.RequirePermission(middleware.PermSuppliersWrite), supplierPriceController.Delete)
+
+    // Supplier performance over a rolling window (delivery, fill, quality and price)
+    suppliers.Get("/:id/scorecard", middleware.RequirePermission(middleware.PermSuppliersRead), scorecardController.GetBySupplier)
+
+    // Supplier contracts: agreed prices purchasings are checked against, committed spend and expiry alerts
+    contracts := protected.Group("/contracts")
+    contracts.Get("/", middleware.RequirePermission(middleware.PermSuppliersRead), contractController.GetAll)
+    contracts.Get("/expiring", middleware.RequirePermission(middleware.PermSuppliersRead), contractController.GetExpiring)
+    contracts.Post("/", middleware.RequirePermission(middleware.PermContractsWrite), contractController.Create)
+    contracts.Get("/:id", middleware.RequirePermission(middleware.PermSuppliersRead), contractController.GetByID)
+    contracts.Put("/:id", middleware.RequirePermission(middleware.PermContractsWrite), contractController.Update)
+    contracts.Delete("/:id", middleware.RequirePermission(middleware.PermContractsWrite), contractController.Delete)
+
+    warehouses := protected.Group("/warehouses")
+    warehouses.Get("/", middleware.RequirePermission(middleware.PermItemsRead), warehouseController.GetAll)
+    warehouses.Post("/", middleware.RequirePermission(middleware.PermWarehousesWrite), warehouseController.Create)
+    warehouses.Get("/:id", middleware.RequirePermission(middleware.PermItemsRead), warehouseController.GetByID)
+    warehouses.Put("/:id", middleware.RequirePermission(middleware.PermWarehousesWrite), warehouseController.Update)
+    warehouses.Delete("/:id", middleware.RequirePermission(middleware.PermWarehousesWrite), warehouseController.Delete)
+
+    // Exchange rates to the base currency (entered manually or imported from CSV)
+    exchangeRates := protected.Group("/exchange-rates")
+    exchangeRates.Get("/", middleware.RequirePermission(middleware.PermPurchasingsRead), exchangeRateController.GetAll)
+    exchangeRates.Post("/", middleware.RequirePermission(middleware.PermExchangeRatesWrite), exchangeRateController.Create)
+    exchangeRates.Post("/import", middleware.RequirePermission(middleware.PermExchangeRatesWrite), exchangeRateController.Import)
+    exchangeRates.Delete("/:id", middleware.RequirePermission(middleware.PermExchangeRatesWrite), exchangeRateController.Delete)
+
+    // Tax codes charged on purchasing lines (VAT such as PPN, withholding such as PPh 23)
+    taxCodes := protected.Group("/tax-codes")
+    taxCodes.Get("/", middleware.RequirePermission(middleware.PermPurchasingsRead), taxCodeController.GetAll)
+    taxCodes.Post("/", middleware.RequirePermission(middleware.PermTaxCodesWrite), taxCodeController.Create)
+    taxCodes.Put("/:id", middleware.RequirePermission(middleware.PermTaxCodesWrite), taxCodeController.Update)
+    taxCodes.Delete("/:id", middleware.RequirePermission(middleware.PermTaxCodesWrite), taxCodeController.Delete)
+
+    // Departments (cost centers) purchasings are charged to, and their budgets per period
+    departments := protected.Group("/departments")
+    departments.Get("/", middleware.RequirePermission(middleware.PermPurchasingsRead), departmentController.GetAll)
+    departments.Post("/", middleware.RequirePermission(middleware.PermDepartmentsWrite), departmentController.Create)
+    departments.Put("/:id", middleware.RequirePermission(middleware.PermDepartmentsWrite), departmentController.Update)
+    departments.Delete("/:id", middleware.RequirePermission(middleware.PermDepartmentsWrite), departmentController.Delete)
+
+    budgets := protected.Group("/budgets")
+    budgets.Get("/", middleware.RequirePermission(middleware.PermPurchasingsRead), budgetController.GetAll)
+    budgets.Get("/:id", middleware.RequirePermission(middleware.PermPurchasingsRead), budgetController.GetByID)
+    budgets.Post("/", middleware.RequirePermission(middleware.PermBudgetsWrite), budgetController.Create)
+    budgets.Put("/:id", middleware.RequirePermission(middleware.PermBudgetsWrite), budgetController.Update)
+    budgets.Delete("/:id", middleware.RequirePermission(middleware.PermBudgetsWrite), budgetController.Delete)
 
     // --- Purchasing Transaction ---
-    protected.Post("/purchasings", purchasingController.Create)
+    purchasings := protected.Group("/purchasings")
+    purchasings.Get("/", middleware.RequirePermission(middleware.PermPurchasingsRead), purchasingController.GetAll)
+    purchasings.Post("/", middleware.RequirePermission(middleware.PermPurchasingsCreate), purchasingController.Create)
+    purchasings.Get("/:id", middleware.RequirePermission(middleware.PermPurchasingsRead), purchasingController.GetByID)
+    purchasings.Get("/:id/history", middleware.RequirePermission(middleware.PermPurchasingsRead), purchasingController.GetHistory)
+    purchasings.Get("/:id/approvals", middleware.RequirePermission(middleware.PermPurchasingsRead), purchasingController.GetApprovals)
+
+    // Status transitions
+    purchasings.Post("/:id/submit", middleware.RequirePermission(middleware.PermPurchasingsSubmit), purchasingController.Submit)
+    purchasings.Post("/:id/approve", middleware.RequirePermission(middleware.PermPurchasingsApprove), purchasingController.Approve)
+    purchasings.Post("/:id/reject", middleware.RequirePermission(middleware.PermPurchasingsApprove), purchasingController.Reject)
+    purchasings.Post("/:id/order", middleware.RequirePermission(middleware.PermPurchasingsOrder), purchasingController.Order)
+    purchasings.Post("/:id/cancel", middleware.RequirePermission(middleware.PermPurchasingsCancel), purchasingController.Cancel)
+    purchasings.Post("/:id/close", middleware.RequirePermission(middleware.PermPurchasingsClose), purchasingController.Close)
+
+    // Goods receipts (stock moves here, not on purchasing creation)
+    purchasings.Get("/:id/receipts", middleware.RequirePermission(middleware.PermPurchasingsRead), goodsReceiptController.GetByPurchasing)
+    purchasings.Post("/:id/receipts", middleware.RequirePermission(middleware.PermReceiptsCreate), goodsReceiptController.Create)
+
+    // Supplier invoices billed against the purchasing
+    purchasings.Get("/:id/invoices", middleware.RequirePermission(middleware.PermInvoicesRead), supplierInvoiceController.GetByPurchasing)
+
+    // --- Purchase Requisitions (reviewed, then converted into draft purchasings) ---
+    requisitions := protected.Group("/requisitions")
+    requisitions.Get("/", middleware.RequirePermission(middleware.PermRequisitionsRead), requisitionController.GetAll)
+    requisitions.Post("/", middleware.RequirePermission(middleware.PermRequisitionsCreate), requisitionController.Create)
+    requisitions.Get("/:id", middleware.RequirePermission(middleware.PermRequisitionsRead), requisitionController.GetByID)
+    requisitions.Post("/:id/submit", middleware.RequirePermission(middleware.PermRequisitionsCreate), requisitionController.Submit)
+    requisitions.Post("/:id/approve", middleware.RequirePermission(middleware.PermRequisitionsApprove), requisitionController.Approve)
+    requisitions.Post("/:id/reject", middleware.RequirePermission(middleware.PermRequisitionsApprove), requisitionController.Reject)
+    requisitions.Post("/:id/cancel", middleware.RequirePermission(middleware.PermRequisitionsCreate), requisitionController.Cancel)
+    requisitions.Post("/:id/convert", middleware.RequirePermission(middleware.PermPurchasingsCreate), requisitionController.Convert)
+
+    // --- Requests for Quotation (quotes compared side by side, awarded into a draft purchasing) ---
+    rfqs := protected.Group("/rfqs")
+    rfqs.Get("/", middleware.RequirePermission(middleware.PermRFQsRead), rfqController.GetAll)
+    rfqs.Post("/", middleware.RequirePermission(middleware.PermRFQsWrite), rfqController.Create)
+    rfqs.Get("/:id", middleware.RequirePermission(middleware.PermRFQsRead), rfqController.GetByID)
+    rfqs.Get("/:id/comparison", middleware.RequirePermission(middleware.PermRFQsRead), rfqController.Compare)
+    rfqs.Post("/:id/suppliers", middleware.RequirePermission(middleware.PermRFQsWrite), rfqController.InviteSuppliers)
+    rfqs.Post("/:id/quotes", middleware.RequirePermission(middleware.PermRFQsWrite), rfqController.SubmitQuote)
+    rfqs.Post("/:id/award", middleware.RequirePermission(middleware.PermPurchasingsCreate), rfqController.Award)
+    rfqs.Post("/:id/cancel", middleware.RequirePermission(middleware.PermRFQsWrite), rfqController.Cancel)
+
+    // --- Supplier Invoices (three-way match against purchasing and receipts) ---
+    invoices := protected.Group("/invoices")
+    invoices.Get("/", middleware.RequirePermission(middleware.PermInvoicesRead), supplierInvoiceController.GetAll)
+    invoices.Post("/", middleware.RequirePermission(middleware.PermInvoicesCreate), supplierInvoiceController.Create)
+    invoices.Get("/:id", middleware.RequirePermission(middleware.PermInvoicesRead), supplierInvoiceController.GetByID)
+    invoices.Post("/:id/match", middleware.RequirePermission(middleware.PermInvoicesCreate), supplierInvoiceController.Match)
+    invoices.Post("/:id/approve", middleware.RequirePermission(middleware.PermInvoicesApprove), supplierInvoiceController.Approve)
+    invoices.Post("/:id/reject", middleware.RequirePermission(middleware.PermInvoicesApprove), supplierInvoiceController.Reject)
+    invoices.Get("/:id/payments", middleware.RequirePermission(middleware.PermInvoicesRead), supplierInvoiceController.GetPayments)
+    invoices.Post("/:id/payments", middleware.RequirePermission(middleware.PermInvoicesPay), supplierInvoiceController.RecordPayment)
+
+    // Reordering: items at their reorder point, drafted into purchasings per supplier
+    reorder := protected.Group("/reorder")
+    reorder.Get("/suggestions", middleware.RequirePermission(middleware.PermPurchasingsRead), reorderController.GetSuggestions)
+    reorder.Post("/run", middleware.RequirePermission(middleware.PermPurchasingsCreate), reorderController.Run)
+
+    // --- Reports ---
+    reports := protected.Group("/reports")
+    reports.Get("/purchasings", middleware.RequirePermission(middleware.PermPurchasingsRead), reportController.GetPurchasingSummary)
+    reports.Get("/aged-payables", middleware.RequirePermission(middleware.PermInvoicesRead), reportController.GetAgedPayables)
+
+    // --- Approvals ---
+    protected.Get("/approvals/pending", middleware.RequirePermission(middleware.PermPurchasingsApprove), purchasingController.GetPendingApprovals)
+
+    approvalRules := protected.Group("/approval-rules")
+    approvalRules.Get("/", middleware.RequirePermission(middleware.PermApprovalRulesRead), approvalRuleController.GetAll)
+    approvalRules.Post("/", middleware.RequirePermission(middleware.PermApprovalRulesWrite), approvalRuleController.Create)
+    approvalRules.Put("/:id", middleware.RequirePermission(middleware.PermApprovalRulesWrite), approvalRuleController.Update)
+    approvalRules.Delete("/:id", middleware.RequirePermission(middleware.PermApprovalRulesWrite), approvalRuleController.Delete)
+
+    // --- Stock Corrections & Transfers ---
+    stockAdjustments := protected.Group("/stock-adjustments")
+    stockAdjustments.Get("/", middleware.RequirePermission(middleware.PermItemsRead), stockAdjustmentController.GetAll)
+    stockAdjustments.Post("/", middleware.RequirePermission(middleware.PermStockAdjust), stockAdjustmentController.Create)
+    stockAdjustments.Get("/:id", middleware.RequirePermission(middleware.PermItemsRead), stockAdjustmentController.GetByID)
+    stockAdjustments.Post("/:id/approve", middleware.RequirePermission(middleware.PermStockApprove), stockAdjustmentController.Approve)
+    stockAdjustments.Post("/:id/reject", middleware.RequirePermission(middleware.PermStockApprove), stockAdjustmentController.Reject)
+
+    cycleCounts := protected.Group("/cycle-counts")
+    cycleCounts.Get("/", middleware.RequirePermission(middleware.PermItemsRead), cycleCountController.GetAll)
+    cycleCounts.Post("/", middleware.RequirePermission(middleware.PermStockAdjust), cycleCountController.Create)
+    cycleCounts.Get("/:id", middleware.RequirePermission(middleware.PermItemsRead), cycleCountController.GetByID)
+    cycleCounts.Put("/:id/counts", middleware.RequirePermission(middleware.PermStockAdjust), cycleCountController.RecordCounts)
+    cycleCounts.Post("/:id/submit", middleware.RequirePermission(middleware.PermStockAdjust), cycleCountController.Submit)
+    cycleCounts.Post("/:id/approve", middleware.RequirePermission(middleware.PermStockApprove), cycleCountController.Approve)
+    cycleCounts.Post("/:id/reject", middleware.RequirePermission(middleware.PermStockApprove), cycleCountController.Reject)
+    cycleCounts.Post("/:id/cancel", middleware.RequirePermission(middleware.PermStockAdjust), cycleCountController.Cancel)
+
+    stockTransfers := protected.Group("/stock-transfers")
+    stockTransfers.Get("/", middleware.RequirePermission(middleware.PermItemsRead), stockTransferController.GetAll)
+    stockTransfers.Post("/", middleware.RequirePermission(middleware.PermStockTransfer), stockTransferController.Create)
+    stockTransfers.Get("/:id", middleware.RequirePermission(middleware.PermItemsRead), stockTransferController.GetByID)
+    stockTransfers.Post("/:id/ship", middleware.RequirePermission(middleware.PermStockTransfer), stockTransferController.Ship)
+    stockTransfers.Post("/:id/receive", middleware.RequirePermission(middleware.PermStockTransfer), stockTransferController.Receive)
+    stockTransfers.Post("/:id/cancel", middleware.RequirePermission(middleware.PermStockTransfer), stockTransferController.Cancel)
+
+    // --- Webhooks ---
+    webhooks := protected.Group("/webhooks")
+    // Outbox (registered before /:id so "events" is not taken as a subscription ID)
+    webhooks.Get("/events", middleware.RequirePermission(middleware.PermWebhooksRead), webhookEventController.GetAll)
+    webhooks.Post("/events/:id/replay", middleware.RequirePermission(middleware.PermWebhooksWrite), webhookEventController.Replay)
+    webhooks.Get("/event-types", middleware.RequirePermission(middleware.PermWebhooksRead), webhookSubscriptionController.GetEventTypes)
+
+    // Subscriptions
+    webhooks.Get("/", middleware.RequirePermission(middleware.PermWebhooksRead), webhookSubscriptionController.GetAll)
+    webhooks.Post("/", middleware.RequirePermission(middleware.PermWebhooksWrite), webhookSubscriptionController.Create)
+    webhooks.Get("/:id", middleware.RequirePermission(middleware.PermWebhooksRead), webhookSubscriptionController.GetByID)
+    webhooks.Put("/:id", middleware.RequirePermission(middleware.PermWebhooksWrite), webhookSubscriptionController.Update)
+    webhooks.Delete("/:id", middleware.RequirePermission(middleware.PermWebhooksWrite), webhookSubscriptionController.Delete)
+    webhooks.Post("/:id/pause", middleware.RequirePermission(middleware.PermWebhooksWrite), webhookSubscriptionController.Pause)
+    webhooks.Post("/:id/resume", middleware.RequirePermission(middleware.PermWebhooksWrite), webhookSubscriptionController.Resume)
+    webhooks.Get("/:id/deliveries", middleware.RequirePermission(middleware.PermWebhooksRead), webhookSubscriptionController.GetDeliveries)
 }
\ No newline at end of file
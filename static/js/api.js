@@ -106,16 +106,40 @@ const ApiService = {
     });
   },
 
+  /**
+   * Fetch every page of a paginated listing by following nextCursor
+   * @param {string} url - Listing URL, may already contain query parameters
+   * @returns {Promise} Promise that resolves with all rows
+   */
+  getAllPages(url) {
+    const self = this;
+    const separator = url.includes("?") ? "&" : "?";
+    const rows = [];
+
+    function fetchPage(cursor) {
+      const cursorQuery = cursor ? `&cursor=${encodeURIComponent(cursor)}` : "";
+      return self
+        .request({
+          url: `${url}${separator}limit=${ApiConfig.pageLimit}${cursorQuery}`,
+          method: "GET",
+        })
+        .then((response) => {
+          rows.push(...(response.data || []));
+          const next = response.pagination && response.pagination.nextCursor;
+          return next ? fetchPage(next) : rows;
+        });
+    }
+
+    return fetchPage(null);
+  },
+
   /**
    * Get all items from API
    * @returns {Promise} Promise that resolves with items data
    */
   getItems(supplierId) {
     const query = supplierId ? `?supplierId=${supplierId}` : "";
-    return this.request({
-      url: ApiConfig.baseURL + ApiConfig.endpoints.items + query,
-      method: "GET",
-    }).then((response) => response.data || []);
+    return this.getAllPages(ApiConfig.baseURL + ApiConfig.endpoints.items + query);
   },
 
   /**
@@ -162,10 +186,7 @@ const ApiService = {
    * @returns {Promise} Promise that resolves with suppliers data
    */
   getSuppliers() {
-    return this.request({
-      url: ApiConfig.baseURL + ApiConfig.endpoints.suppliers,
-      method: "GET",
-    }).then((response) => response.data || []);
+    return this.getAllPages(ApiConfig.baseURL + ApiConfig.endpoints.suppliers);
   },
 
   /**
@@ -18,6 +18,9 @@ const ApiConfig = {
     
     // Request timeout in milliseconds
     timeout: 10000,
+
+    // Page size used when loading full listings (server maximum is 100)
+    pageLimit: 100,
     
     // Storage keys
     storageKeys: {
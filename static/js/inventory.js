@@ -73,7 +73,7 @@ $(document).ready(function () {
     $formTitle.text("Tambah Item");
     $itemNameInput.val("");
     $itemPriceInput.val("");
-    $itemStockInput.val("");
+    $itemStockInput.val("").prop("disabled", false);
     $itemSupplierSelect.val("");
     $resetFormBtn.addClass("hidden");
     clearErrors();
@@ -84,7 +84,8 @@ $(document).ready(function () {
     $formTitle.text(`Edit Item #${item.id}`);
     $itemNameInput.val(item.name);
     $itemPriceInput.val(item.price);
-    $itemStockInput.val(item.stock);
+    // Stock is corrected through stock adjustments, not by editing the item
+    $itemStockInput.val(item.stock).prop("disabled", true);
     $itemSupplierSelect.val(item.supplierId);
     $resetFormBtn.removeClass("hidden");
   }
@@ -192,6 +193,9 @@ $(document).ready(function () {
       stock: parseInt($itemStockInput.val() || "0", 10),
       supplierId: parseInt($itemSupplierSelect.val(), 10),
     };
+    if (editingItemId) {
+      delete payload.stock;
+    }
 
     setLoading(true);
 
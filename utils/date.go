@@ -0,0 +1,35 @@
+package utils
+
+import "time"
+
+// DateLayout is how calendar dates are written in requests, responses and queries
+const DateLayout = "2006-01-02"
+
+// Calendar dates (DATE columns such as price validity, budget periods or delivery dates) are held
+// as midnight UTC. The MySQL driver writes and reads times in its connection location, UTC unless
+// the DSN sets loc, so a date parsed at local midnight on a host ahead of UTC would be stored as
+// the day before. Instants (time.Now, DATETIME columns) are turned into the calendar date they
+// fall on in local time with DateOf before they are compared with dates.
+
+// ParseDate parses a YYYY-MM-DD calendar date
+func ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation(DateLayout, value, time.UTC)
+}
+
+// DateOf returns the calendar date the instant falls on in local time
+func DateOf(t time.Time) time.Time {
+	year, month, day := t.In(time.Local).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Today returns today's calendar date
+func Today() time.Time {
+	return DateOf(time.Now())
+}
+
+// StartOfDate returns the instant the calendar date begins in local time, for comparing it with
+// instants
+func StartOfDate(date time.Time) time.Time {
+	year, month, day := date.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
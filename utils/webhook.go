@@ -2,9 +2,8 @@ package utils
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
 	"time"
 
@@ -13,56 +12,83 @@ import (
 
 // WebhookPayload represents the data structure sent to webhook
 type WebhookPayload struct {
-	Event      string                      `json:"event"`
-	Timestamp  string                      `json:"timestamp"`
-	Purchasing models.Purchasing           `json:"purchasing"`
-	Details    []models.PurchasingDetail   `json:"details"`
+	Event      string                    `json:"event"`
+	Timestamp  string                    `json:"timestamp"`
+	Purchasing models.Purchasing         `json:"purchasing"`
+	Details    []models.PurchasingDetail `json:"details"`
 }
 
-// SendWebhook sends purchasing transaction data to external webhook URL
-// This function is called after successful database commit
-func SendWebhook(webhookURL string, purchasing *models.Purchasing, details []models.PurchasingDetail) error {
-	payload := WebhookPayload{
-		Event:     "purchasing.created",
-		Timestamp: time.Now().Format(time.RFC3339),
+// NewPurchasingWebhookPayload builds the payload describing a purchasing event
+func NewPurchasingWebhookPayload(event string, purchasing *models.Purchasing, details []models.PurchasingDetail) WebhookPayload {
+	return WebhookPayload{
+		Event:      event,
+		Timestamp:  time.Now().Format(time.RFC3339),
 		Purchasing: *purchasing,
 		Details:    details,
 	}
+}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+// EventPayload is the envelope of webhook events other than purchasing events
+type EventPayload struct {
+	Event     string      `json:"event"`
+	Timestamp string      `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// NewEventPayload wraps the event data in the webhook envelope
+func NewEventPayload(event string, data interface{}) EventPayload {
+	return EventPayload{
+		Event:     event,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Data:      data,
 	}
+}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(jsonData))
+// webhookClient is shared by all deliveries so connections are reused
+// It never uses a proxy and connects only to addresses allowed by the webhook target restrictions.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext:         dialWebhook,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: checkWebhookRedirect,
+}
+
+// DeliverWebhook POSTs an already encoded payload to the webhook URL with the extra headers
+// It returns the response status code (0 if no response was received) and an error
+// for transport failures and non-2xx responses, so the caller can decide to retry.
+// Targets refused by the webhook restrictions fail with ErrWebhookTargetNotAllowed.
+func DeliverWebhook(webhookURL string, body []byte, headers map[string]string) (int, error) {
+	if _, err := checkWebhookURL(webhookURL); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create webhook request: %w", err)
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "Procurement-System/1.0")
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
 
-	// Send request
-	resp, err := client.Do(req)
+	resp, err := webhookClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send webhook request: %w", err)
+		return 0, fmt.Errorf("failed to send webhook request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Log the response (non-blocking)
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		log.Printf("Webhook sent successfully to %s (Status: %d)", webhookURL, resp.StatusCode)
-	} else {
-		log.Printf("Webhook returned non-success status: %d for URL: %s", resp.StatusCode, webhookURL)
+	// Drain the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned non-success status %d", resp.StatusCode)
 	}
 
-	return nil
+	return resp.StatusCode, nil
 }
-
-
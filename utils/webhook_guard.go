@@ -0,0 +1,201 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"procurement-system/config"
+)
+
+// ErrWebhookTargetNotAllowed is returned when a webhook URL or the address it resolves to is refused
+var ErrWebhookTargetNotAllowed = errors.New("webhook target not allowed")
+
+// webhookResolveTimeout bounds the DNS lookup done when a webhook URL is validated
+const webhookResolveTimeout = 5 * time.Second
+
+// blockedWebhookNetworks are special-purpose ranges not covered by the net.IP predicates
+// used in checkWebhookIP: "this network", carrier-grade NAT, IETF protocol assignments,
+// benchmarking, reserved and NAT64.
+var blockedWebhookNetworks = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+)
+
+// webhookDialer opens the TCP connections of webhook deliveries
+var webhookDialer = &net.Dialer{
+	Timeout:   10 * time.Second,
+	KeepAlive: 30 * time.Second,
+}
+
+// ValidateWebhookURL checks a webhook URL against the configured scheme and host restrictions
+// The host is resolved and every address it resolves to must be allowed. Deliveries check
+// the addresses again on every connection, so a host that later resolves elsewhere is still refused.
+func ValidateWebhookURL(rawURL string) error {
+	parsed, err := checkWebhookURL(rawURL)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookResolveTimeout)
+	defer cancel()
+	_, err = resolveWebhookHost(ctx, parsed.Hostname())
+	return err
+}
+
+// checkWebhookURL checks the form and scheme of a webhook URL
+func checkWebhookURL(rawURL string) (*url.URL, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("%w: invalid URL", ErrWebhookTargetNotAllowed)
+	}
+	if parsed.User != nil {
+		return nil, fmt.Errorf("%w: credentials in URL", ErrWebhookTargetNotAllowed)
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if (scheme != "http" && scheme != "https") || !contains(config.WebhookAllowedSchemes, scheme) {
+		return nil, fmt.Errorf("%w: scheme %q", ErrWebhookTargetNotAllowed, parsed.Scheme)
+	}
+	return parsed, nil
+}
+
+// resolveWebhookHost resolves the host and returns its addresses if all of them are allowed
+func resolveWebhookHost(ctx context.Context, host string) ([]net.IP, error) {
+	host = strings.ToLower(host)
+	allowlisted := hostnameAllowed(host)
+
+	if ip := net.ParseIP(host); ip != nil {
+		if err := checkWebhookIP(ip, allowlisted); err != nil {
+			return nil, err
+		}
+		return []net.IP{ip}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return nil, fmt.Errorf("%w: cannot resolve host %s", ErrWebhookTargetNotAllowed, host)
+	}
+
+	ips := make([]net.IP, len(addrs))
+	for i, addr := range addrs {
+		if err := checkWebhookIP(addr.IP, allowlisted); err != nil {
+			return nil, err
+		}
+		ips[i] = addr.IP
+	}
+	return ips, nil
+}
+
+// checkWebhookIP decides whether a webhook may connect to the address
+// Addresses inside WEBHOOK_ALLOWED_HOSTS networks are always allowed, which is how internal
+// receivers are enabled. Otherwise the host must be allowlisted (or no allowlist configured)
+// and the address must be public.
+func checkWebhookIP(ip net.IP, hostAllowlisted bool) error {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	for _, network := range config.WebhookAllowedCIDRs {
+		if network.Contains(ip) {
+			return nil
+		}
+	}
+	if !hostAllowlisted {
+		return fmt.Errorf("%w: %s is not in the allowlist", ErrWebhookTargetNotAllowed, ip)
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s is not a public address", ErrWebhookTargetNotAllowed, ip)
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%w: %s is a reserved address", ErrWebhookTargetNotAllowed, ip)
+		}
+	}
+	return nil
+}
+
+// hostnameAllowed reports whether the host passes the hostname allowlist
+// With no allowlist configured every host passes; entries may be "*.example.com" wildcards.
+func hostnameAllowed(host string) bool {
+	if len(config.WebhookAllowedHostnames) == 0 && len(config.WebhookAllowedCIDRs) == 0 {
+		return true
+	}
+	for _, allowed := range config.WebhookAllowedHostnames {
+		if host == allowed {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dialWebhook resolves and checks the target itself and then connects to a checked address
+// Dialing the checked IP rather than the hostname stops DNS rebinding between check and connect.
+func dialWebhook(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	ips, err := resolveWebhookHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		conn, err := webhookDialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// checkWebhookRedirect applies the scheme restrictions to redirects and limits how many are followed
+// The redirect target's address is checked by dialWebhook like any other connection.
+func checkWebhookRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 3 {
+		return errors.New("stopped after 3 redirects")
+	}
+	_, err := checkWebhookURL(req.URL.String())
+	return err
+}
+
+// contains reports whether the list holds the value
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// mustParseCIDRs parses a fixed list of networks
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
@@ -0,0 +1,111 @@
+// Package webhooksig signs and verifies the webhooks sent by the procurement system.
+//
+// Every delivery carries three headers:
+//
+//	X-Webhook-Timestamp  Unix time (seconds) at which the delivery was signed
+//	X-Signature          "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))
+//	X-Delivery-ID        Identifier of the event, identical across retries
+//
+// Receivers should verify the signature with the subscription secret, reject timestamps
+// outside a small tolerance to stop replayed requests, and use the delivery ID to ignore
+// events they have already processed:
+//
+//	body, err := webhooksig.VerifyRequest(r, secret, webhooksig.DefaultTolerance)
+//	if err != nil {
+//		http.Error(w, err.Error(), http.StatusUnauthorized)
+//		return
+//	}
+//
+// The package only depends on the standard library so integrators can import it directly.
+package webhooksig
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header names used on webhook deliveries
+const (
+	HeaderSignature  = "X-Signature"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderDeliveryID = "X-Delivery-ID"
+)
+
+// signaturePrefix identifies the signing scheme inside the X-Signature header
+const signaturePrefix = "sha256="
+
+// DefaultTolerance is the recommended maximum age (and clock skew) of a delivery
+const DefaultTolerance = 5 * time.Minute
+
+// Verification errors
+var (
+	ErrMissingSignature = errors.New("webhooksig: missing signature or timestamp header")
+	ErrInvalidTimestamp = errors.New("webhooksig: invalid timestamp")
+	ErrStaleTimestamp   = errors.New("webhooksig: timestamp outside tolerance")
+	ErrInvalidSignature = errors.New("webhooksig: signature mismatch")
+)
+
+// Sign returns the X-Signature header value for a body sent at timestamp (Unix seconds)
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature and timestamp header pair against the body
+// The timestamp must be within tolerance of now, in either direction.
+func Verify(secret, signature, timestamp string, body []byte, tolerance time.Duration, now time.Time) error {
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	age := now.Sub(time.Unix(ts, 0))
+	if age < 0 {
+		age = -age
+	}
+	if age > tolerance {
+		return ErrStaleTimestamp
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	expected := Sign(secret, ts, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// VerifyRequest reads and verifies an incoming webhook request
+// It returns the raw body on success; the request body is replaced so it can be read again.
+func VerifyRequest(r *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	err = Verify(secret, r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body, tolerance, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}